@@ -2,13 +2,26 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/elonfeng/url2md/pkg/converter"
 	"github.com/elonfeng/url2md/pkg/converter/filetype"
+	"github.com/elonfeng/url2md/pkg/mcp"
 	"github.com/elonfeng/url2md/pkg/server"
 	"github.com/spf13/cobra"
 )
@@ -24,47 +37,104 @@ func rootCmd() *cobra.Command {
 		method        string
 		retainImages  bool
 		retainLinks   bool
+		linkMode      string
 		frontmatter   bool
 		enableBrowser bool
 		timeout       int
 		output        string
+		chunkTokens   int
+		chunkOverlap  int
+		tokenizer     string
+		maxTokens     int
+		cacheDir      string
+		pages         string
+		sheets        string
+		cellRange     string
+		maxRows       int
+		includeHidden bool
+		formulas      bool
+		dropOutputs   bool
+		docComments   bool
+		contentType   string
 	)
 
 	root := &cobra.Command{
-		Use:   "url2md [url]",
+		Use:   "url2md [url | file | -]",
 		Short: "Convert web pages to clean Markdown",
-		Long:  "url2md fetches a URL and converts it to clean, LLM-friendly Markdown using a three-layer fallback pipeline.",
+		Long:  "url2md fetches a URL and converts it to clean, LLM-friendly Markdown using a three-layer fallback pipeline.\nLocal files (a path or file:// URL) and stdin (-) go through the same file type converters.",
 		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			url := args[0]
-			if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-				url = "https://" + url
-			}
-
 			opts := &converter.Options{
 				Method:        method,
 				RetainImages:  retainImages,
 				RetainLinks:   retainLinks,
+				LinkMode:      linkMode,
 				Frontmatter:   frontmatter,
 				EnableBrowser: enableBrowser,
 				Timeout:       time.Duration(timeout) * time.Second,
 				UserAgent:     "url2md/1.0",
 				Vision:        visionFromEnv(),
+				Tokenizer:     tokenizer,
+				MaxTokens:     maxTokens,
+				Pages:         pages,
+				Sheets:        sheets,
+				CellRange:     cellRange,
+				MaxRows:       maxRows,
+				IncludeHidden: includeHidden,
+				Formulas:      formulas,
+				DropOutputs:   dropOutputs,
+				DocComments:   docComments,
+			}
+			if chunkTokens > 0 {
+				opts.Chunk = &converter.ChunkOptions{MaxTokens: chunkTokens, Overlap: chunkOverlap}
+			}
+			if cacheDir != "" {
+				cache, err := converter.NewDiskCache(cacheDir)
+				if err != nil {
+					return err
+				}
+				opts.Cache = cache
 			}
 
+			data, name, local, err := readLocal(args[0])
+			if err != nil {
+				return err
+			}
 			ctx := context.Background()
-			c := converter.New()
-			result, err := c.Convert(ctx, url, opts)
+			var result *converter.Result
+			if local {
+				result, err = converter.ConvertBytes(ctx, data, name, contentType, opts)
+			} else {
+				url := args[0]
+				if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+					url = "https://" + url
+				}
+				result, err = converter.New().Convert(ctx, url, opts)
+			}
 			if err != nil {
 				return fmt.Errorf("conversion failed: %w", err)
 			}
 
 			// print header info to stderr
 			fmt.Fprintf(os.Stderr, "Title:  %s\n", result.Title)
-			fmt.Fprintf(os.Stderr, "Method: %s\n", result.Method)
-			fmt.Fprintf(os.Stderr, "Tokens: ~%d\n", result.TokenCount)
+			if result.Cached {
+				fmt.Fprintf(os.Stderr, "Method: %s (cached)\n", result.Method)
+			} else {
+				fmt.Fprintf(os.Stderr, "Method: %s\n", result.Method)
+			}
+			if result.Tokenizer == "heuristic" {
+				fmt.Fprintf(os.Stderr, "Tokens: ~%d\n", result.TokenCount)
+			} else {
+				fmt.Fprintf(os.Stderr, "Tokens: %d (%s)\n", result.TokenCount, result.Tokenizer)
+			}
+			if result.Truncated {
+				fmt.Fprintf(os.Stderr, "Truncated from %d tokens\n", result.OriginalTokenCount)
+			}
 			fmt.Fprintf(os.Stderr, "Fetch:  %s\n", result.FetchTime.Round(time.Millisecond))
 
+			if opts.Chunk != nil {
+				return writeChunks(output, result.Chunks)
+			}
 			if output != "" {
 				return os.WriteFile(output, []byte(result.Markdown), 0644)
 			}
@@ -76,30 +146,139 @@ func rootCmd() *cobra.Command {
 	root.Flags().StringVarP(&method, "method", "m", "auto", "Conversion method: auto, negotiate, static, browser")
 	root.Flags().BoolVar(&retainImages, "images", false, "Retain images in output")
 	root.Flags().BoolVar(&retainLinks, "links", true, "Retain links in output")
+	root.Flags().StringVar(&linkMode, "link-mode", "inline", "Link style: inline, reference (numbered list at the end), text")
 	root.Flags().BoolVar(&frontmatter, "frontmatter", true, "Prepend YAML frontmatter (title, description, image)")
 	root.Flags().BoolVar(&enableBrowser, "browser", false, "Enable headless Chrome fallback")
 	root.Flags().IntVarP(&timeout, "timeout", "t", 30, "Timeout in seconds")
 	root.Flags().StringVarP(&output, "output", "o", "", "Output file (default: stdout)")
+	root.Flags().IntVar(&chunkTokens, "chunk-tokens", 0, "Split output into heading-aware chunks of at most N tokens, emitted as JSONL")
+	root.Flags().IntVar(&chunkOverlap, "chunk-overlap", 0, "Tokens of trailing context repeated at the start of each chunk")
+	root.Flags().StringVar(&tokenizer, "tokenizer", "heuristic", "Token counter: heuristic, cl100k_base, o200k_base")
+	root.Flags().IntVar(&maxTokens, "max-tokens", 0, "Trim output to at most N tokens, dropping low-value sections first")
+	root.Flags().StringVar(&cacheDir, "cache-dir", "", "Cache results in this directory and revalidate with ETag/Last-Modified")
+	root.Flags().StringVar(&pages, "pages", "", "PDF pages to convert, e.g. 1-5,10 or 20- (default: all)")
+	root.Flags().StringVar(&sheets, "sheets", "", "Spreadsheet sheets to convert by name or 1-based index, comma-separated (default: all visible)")
+	root.Flags().StringVar(&cellRange, "range", "", "Spreadsheet cells to convert, e.g. A1:F100, B:D or 5:20")
+	root.Flags().IntVar(&maxRows, "max-rows", 0, "Spreadsheet data rows per sheet, noting how many were omitted (0 = all)")
+	root.Flags().BoolVar(&includeHidden, "include-hidden", false, "Keep hidden spreadsheet sheets, rows and columns")
+	root.Flags().BoolVar(&formulas, "formulas", false, "Show spreadsheet formulas alongside computed values")
+	root.Flags().BoolVar(&dropOutputs, "drop-outputs", false, "Leave out Jupyter notebook cell outputs")
+	root.Flags().BoolVar(&docComments, "doc-comments", false, "Render a source file's leading doc comment as prose above the code")
+	root.Flags().StringVar(&contentType, "content-type", "", "Content type of a local file or stdin without a known extension, e.g. text/csv")
 
 	root.AddCommand(serveCmd())
 	root.AddCommand(batchCmd())
+	root.AddCommand(crawlCmd())
+	root.AddCommand(mcpCmd())
 
 	return root
 }
 
+// readLocal reads the document named by arg when it is "-" (stdin), a
+// file:// URL, or the path of an existing file, returning the name to detect
+// its type by. ok is false when arg should be fetched as a URL.
+func readLocal(arg string) (data []byte, name string, ok bool, err error) {
+	switch {
+	case arg == "-":
+		data, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("read stdin: %w", err)
+		}
+		return data, "", true, nil
+	case strings.HasPrefix(arg, "file://"):
+		u, err := url.Parse(arg)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("invalid file URL: %w", err)
+		}
+		name = filepath.FromSlash(u.Path)
+	case strings.HasPrefix(arg, "http://"), strings.HasPrefix(arg, "https://"):
+		return nil, "", false, nil
+	default:
+		info, err := os.Stat(arg)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil, "", false, nil // not a local file: treat as a URL
+		}
+		if name, err = filepath.Abs(arg); err != nil {
+			return nil, "", false, err
+		}
+	}
+
+	data, err = os.ReadFile(name)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return data, name, true, nil
+}
+
+// writeChunks emits one JSON object per chunk to output (or stdout).
+func writeChunks(output string, chunks []converter.Chunk) error {
+	w := os.Stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, chunk := range chunks {
+		if err := enc.Encode(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func serveCmd() *cobra.Command {
-	var port int
+	var (
+		port      int
+		cacheSize int
+		cacheDir  string
+		workers   int
+		timeout   int
+	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Start HTTP server",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			srv := server.New(port)
-			return srv.ListenAndServe()
+			srv.SetJobWorkers(workers)
+			srv.SetTimeout(time.Duration(timeout) * time.Second)
+			switch {
+			case cacheDir != "":
+				cache, err := converter.NewDiskCache(cacheDir)
+				if err != nil {
+					return err
+				}
+				srv.SetCache(cache)
+			case cacheSize > 0:
+				srv.SetCache(converter.NewMemoryCache(cacheSize))
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			go func() {
+				<-ctx.Done()
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				srv.Shutdown(shutdownCtx)
+			}()
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
 		},
 	}
 
 	cmd.Flags().IntVarP(&port, "port", "p", 8080, "Server port")
+	cmd.Flags().IntVar(&cacheSize, "cache-size", 0, "Keep up to N results in an in-memory LRU cache")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Cache results on disk in this directory (overrides --cache-size)")
+	cmd.Flags().IntVar(&workers, "job-workers", 4, "Number of async jobs converted concurrently")
+	cmd.Flags().IntVarP(&timeout, "timeout", "t", 30, "Timeout in seconds per page")
 	return cmd
 }
 
@@ -110,13 +289,24 @@ func batchCmd() *cobra.Command {
 		frontmatter   bool
 		enableBrowser bool
 		timeout       int
+		sitemaps      []string
+		since         string
+		robots        bool
+		cacheDir      string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "batch [url1] [url2] ...",
 		Short: "Convert multiple URLs",
-		Args:  cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && len(sitemaps) == 0 {
+				return fmt.Errorf("provide at least one URL or --sitemap")
+			}
+			modifiedSince, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+
 			opts := &converter.Options{
 				Method:        method,
 				RetainImages:  retainImages,
@@ -126,12 +316,34 @@ func batchCmd() *cobra.Command {
 				Timeout:       time.Duration(timeout) * time.Second,
 				UserAgent:     "url2md/1.0",
 				Vision:        visionFromEnv(),
+				RespectRobots: robots,
+			}
+			if cacheDir != "" {
+				cache, err := converter.NewDiskCache(cacheDir)
+				if err != nil {
+					return err
+				}
+				opts.Cache = cache
 			}
 
 			ctx := context.Background()
 			c := converter.New()
 
-			for _, url := range args {
+			urls := args
+			for _, sm := range sitemaps {
+				entries, err := converter.FetchSitemap(ctx, sm, opts)
+				if err != nil {
+					return err
+				}
+				for _, e := range entries {
+					if !modifiedSince.IsZero() && !e.LastMod.IsZero() && e.LastMod.Before(modifiedSince) {
+						continue
+					}
+					urls = append(urls, e.URL)
+				}
+			}
+
+			for _, url := range urls {
 				if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
 					url = "https://" + url
 				}
@@ -155,10 +367,215 @@ func batchCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&frontmatter, "frontmatter", true, "Prepend YAML frontmatter")
 	cmd.Flags().BoolVar(&enableBrowser, "browser", false, "Enable browser fallback")
 	cmd.Flags().IntVarP(&timeout, "timeout", "t", 30, "Timeout in seconds")
+	cmd.Flags().StringSliceVar(&sitemaps, "sitemap", nil, "Also convert every URL listed in this sitemap (repeatable)")
+	cmd.Flags().StringVar(&since, "since", "", "Skip sitemap entries last modified before this date (YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&robots, "robots", true, "Honor robots.txt Disallow and Crawl-delay rules")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Cache results in this directory and revalidate with ETag/Last-Modified")
+
+	return cmd
+}
+
+func crawlCmd() *cobra.Command {
+	var (
+		method        string
+		retainImages  bool
+		frontmatter   bool
+		enableBrowser bool
+		timeout       int
+		depth         int
+		maxPages      int
+		include       []string
+		exclude       []string
+		concurrency   int
+		outputDir     string
+		sitemaps      []string
+		since         string
+		robots        bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "crawl [url]",
+		Short: "Follow same-origin links from a URL and convert every page",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && len(sitemaps) == 0 {
+				return fmt.Errorf("provide a seed URL or --sitemap")
+			}
+			modifiedSince, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+
+			seed := ""
+			if len(args) > 0 {
+				seed = args[0]
+				if !strings.HasPrefix(seed, "http://") && !strings.HasPrefix(seed, "https://") {
+					seed = "https://" + seed
+				}
+			}
+
+			opts := &converter.Options{
+				Method:        method,
+				RetainImages:  retainImages,
+				Frontmatter:   frontmatter,
+				RetainLinks:   true,
+				EnableBrowser: enableBrowser,
+				Timeout:       time.Duration(timeout) * time.Second,
+				UserAgent:     "url2md/1.0",
+				Vision:        visionFromEnv(),
+				RespectRobots: robots,
+			}
+			crawl := &converter.CrawlOptions{
+				MaxDepth:      depth,
+				MaxPages:      maxPages,
+				Include:       include,
+				Exclude:       exclude,
+				Concurrency:   concurrency,
+				Sitemaps:      sitemaps,
+				ModifiedSince: modifiedSince,
+			}
+
+			var writeErr error
+			written := make(map[string]bool)
+			crawler := converter.NewCrawler(converter.New(), opts, crawl)
+			err = crawler.Crawl(context.Background(), seed, func(r *converter.CrawlResult) {
+				if r.Err != nil {
+					fmt.Fprintf(os.Stderr, "[FAIL] %s: %v\n", r.URL, r.Err)
+					return
+				}
+				fmt.Fprintf(os.Stderr, "[OK] %s → %d tokens (%s, depth %d)\n", r.URL, r.Result.TokenCount, r.Result.Method, r.Depth)
+
+				if outputDir == "" {
+					fmt.Println(r.Result.Markdown)
+					fmt.Print("\n---\n\n")
+					return
+				}
+				if err := writePage(outputDir, r.URL, r.Result.Markdown, written); err != nil && writeErr == nil {
+					writeErr = err
+				}
+			})
+			if err != nil {
+				return fmt.Errorf("crawl failed: %w", err)
+			}
+			return writeErr
+		},
+	}
+
+	cmd.Flags().StringVarP(&method, "method", "m", "auto", "Conversion method")
+	cmd.Flags().BoolVar(&retainImages, "images", false, "Retain images")
+	cmd.Flags().BoolVar(&frontmatter, "frontmatter", true, "Prepend YAML frontmatter")
+	cmd.Flags().BoolVar(&enableBrowser, "browser", false, "Enable browser fallback")
+	cmd.Flags().IntVarP(&timeout, "timeout", "t", 30, "Timeout in seconds per page")
+	cmd.Flags().IntVarP(&depth, "depth", "d", 3, "Maximum link depth from the seed URL")
+	cmd.Flags().IntVar(&maxPages, "max-pages", 100, "Maximum number of pages to convert (0 = unlimited)")
+	cmd.Flags().StringSliceVar(&include, "include", nil, "Only follow URL paths matching these globs (e.g. /docs/**)")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil, "Skip URL paths matching these globs")
+	cmd.Flags().IntVarP(&concurrency, "concurrency", "c", 4, "Pages converted in parallel")
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "", "Write one .md file per page into this directory (default: stdout)")
+	cmd.Flags().StringSliceVar(&sitemaps, "sitemap", nil, "Seed the crawl with every URL listed in this sitemap (repeatable)")
+	cmd.Flags().StringVar(&since, "since", "", "Skip sitemap entries last modified before this date (YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&robots, "robots", true, "Honor robots.txt Disallow and Crawl-delay rules")
+
+	return cmd
+}
+
+func mcpCmd() *cobra.Command {
+	var (
+		httpAddr      string
+		enableBrowser bool
+		timeout       int
+		cacheDir      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Run a Model Context Protocol server (stdio by default)",
+		Long:  "mcp exposes convert_url, convert_batch and crawl_site as MCP tools. It speaks JSON-RPC over stdin/stdout, or streamable HTTP at /mcp when --http is set.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := converter.DefaultOptions()
+			opts.EnableBrowser = enableBrowser
+			opts.Timeout = time.Duration(timeout) * time.Second
+			opts.Vision = visionFromEnv()
+			if cacheDir != "" {
+				cache, err := converter.NewDiskCache(cacheDir)
+				if err != nil {
+					return err
+				}
+				opts.Cache = cache
+			}
+
+			srv := mcp.New(converter.New(), opts, "1.0")
+			if httpAddr == "" {
+				return srv.ServeStdio(cmd.Context(), os.Stdin, os.Stdout)
+			}
+
+			// a bare port binds to loopback only; the tools fetch arbitrary URLs
+			if host, port, err := net.SplitHostPort(httpAddr); err == nil && host == "" {
+				httpAddr = net.JoinHostPort("127.0.0.1", port)
+			}
+			mux := http.NewServeMux()
+			mux.Handle("/mcp", srv.Handler())
+			fmt.Fprintf(os.Stderr, "url2md MCP server listening on %s/mcp\n", httpAddr)
+			return http.ListenAndServe(httpAddr, mux)
+		},
+	}
 
+	cmd.Flags().StringVar(&httpAddr, "http", "", "Serve streamable HTTP on this address (e.g. :8090, which binds 127.0.0.1) instead of stdio")
+	cmd.Flags().BoolVar(&enableBrowser, "browser", false, "Enable headless Chrome fallback by default")
+	cmd.Flags().IntVarP(&timeout, "timeout", "t", 30, "Timeout in seconds per page")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Cache results in this directory and revalidate with ETag/Last-Modified")
 	return cmd
 }
 
+// parseSince parses the --since flag; an empty value means no cutoff.
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: want YYYY-MM-DD", s)
+	}
+	return t, nil
+}
+
+// writePage stores a crawled page under dir, mirroring the URL's host and
+// path: a path ending in a slash becomes <path>/index.md and a query string
+// adds its hash to the file name. Pages that would overwrite a file already
+// in written get a numeric suffix, and pages whose path would resolve outside
+// dir are rejected.
+func writePage(dir, pageURL, markdown string, written map[string]bool) error {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return err
+	}
+	p := strings.Trim(path.Clean("/"+u.Path), "/")
+	if p == "" || strings.HasSuffix(u.Path, "/") {
+		p = path.Join(p, "index")
+	} else {
+		p = strings.TrimSuffix(p, path.Ext(p))
+	}
+	if u.RawQuery != "" {
+		sum := sha256.Sum256([]byte(u.RawQuery))
+		p += "-" + hex.EncodeToString(sum[:4])
+	}
+
+	file := filepath.Join(dir, u.Host, filepath.FromSlash(p)+".md")
+	if rel, err := filepath.Rel(dir, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("page %s resolves outside %s", pageURL, dir)
+	}
+	base := strings.TrimSuffix(file, ".md")
+	for n := 2; written[file]; n++ {
+		file = fmt.Sprintf("%s-%d.md", base, n)
+	}
+	written[file] = true
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(markdown), 0644)
+}
+
 // visionFromEnv reads Cloudflare Workers AI credentials from environment variables.
 func visionFromEnv() *filetype.VisionConfig {
 	accountID := os.Getenv("CLOUDFLARE_ACCOUNT_ID")
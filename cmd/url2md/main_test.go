@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWritePage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	written := make(map[string]bool)
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/", "example.com/index.md"},
+		{"https://example.com/docs/intro.html", "example.com/docs/intro.md"},
+		{"https://example.com/%2e%2e/%2e%2e/%2e%2e/tmp/pwn", "example.com/tmp/pwn.md"},
+		{"https://example.com/a/../../../b", "example.com/b.md"},
+		{"https://example.com/docs", "example.com/docs.md"},
+		{"https://example.com/docs/", "example.com/docs/index.md"},
+		{"https://example.com/docs.html", "example.com/docs-2.md"},
+		{"https://example.com/search?q=ferns", "example.com/search-9b7402b8.md"},
+		{"https://example.com/search?q=moss", "example.com/search-640eb57e.md"},
+	}
+	for _, tt := range tests {
+		if err := writePage(dir, tt.url, "# "+tt.url, written); err != nil {
+			t.Fatalf("writePage(%q): %v", tt.url, err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(tt.want)))
+		if err != nil || string(data) != "# "+tt.url {
+			t.Errorf("writePage(%q): expected %s: %q, %v", tt.url, tt.want, data, err)
+		}
+	}
+
+	if err := writePage(dir, "http://../pwn", "# page", written); err == nil {
+		t.Error("expected an error for a host that escapes the output directory")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "pwn.md")); err == nil {
+		t.Error("page written outside the output directory")
+	}
+}
@@ -11,6 +11,7 @@ type Meta struct {
 	Title       string
 	Description string
 	OG          map[string]string
+	Links       []string // raw href values of <a> elements, in document order
 }
 
 // Extract parses HTML and returns page metadata including title, description, and Open Graph tags.
@@ -42,6 +43,14 @@ func Extract(html string) *Meta {
 		}
 	})
 
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		if href, ok := s.Attr("href"); ok {
+			if href = strings.TrimSpace(href); href != "" {
+				m.Links = append(m.Links, href)
+			}
+		}
+	})
+
 	if m.Title == "" {
 		if ogTitle, ok := m.OG["og:title"]; ok {
 			m.Title = ogTitle
@@ -66,3 +66,20 @@ func TestExtract_InvalidHTML(t *testing.T) {
 		t.Error("expected non-nil OG map even for invalid HTML")
 	}
 }
+
+func TestExtract_Links(t *testing.T) {
+	html := `<html><body>
+		<a href="/docs/intro">Intro</a>
+		<a href=" https://example.com/about ">About</a>
+		<a href="">Empty</a>
+		<a>No href</a>
+	</body></html>`
+
+	m := Extract(html)
+	if len(m.Links) != 2 {
+		t.Fatalf("expected 2 links, got %d: %v", len(m.Links), m.Links)
+	}
+	if m.Links[0] != "/docs/intro" || m.Links[1] != "https://example.com/about" {
+		t.Errorf("unexpected links: %v", m.Links)
+	}
+}
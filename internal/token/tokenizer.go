@@ -0,0 +1,90 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Tokenizer names accepted by Get.
+const (
+	Heuristic = "heuristic"
+	CL100K    = "cl100k_base"
+	O200K     = "o200k_base"
+)
+
+// ErrUnknownTokenizer is returned by Get for unsupported tokenizer names.
+var ErrUnknownTokenizer = errors.New("unknown tokenizer")
+
+// Tokenizer counts tokens in text.
+type Tokenizer interface {
+	Name() string
+	Count(text string) int
+}
+
+func init() {
+	// use the vocabularies embedded in the binary instead of downloading them
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Names returns the supported tokenizer names.
+func Names() []string {
+	return []string{Heuristic, CL100K, O200K}
+}
+
+// Get returns the tokenizer registered under name; an empty name selects the
+// heuristic estimator. BPE vocabularies are loaded on first use.
+func Get(name string) (Tokenizer, error) {
+	switch name {
+	case "", Heuristic:
+		return heuristic{}, nil
+	case CL100K:
+		return cl100k.load()
+	case O200K:
+		return o200k.load()
+	}
+	return nil, fmt.Errorf("%w %q (supported: %v)", ErrUnknownTokenizer, name, Names())
+}
+
+// heuristic is the word-count based estimator, see Estimate.
+type heuristic struct{}
+
+func (heuristic) Name() string          { return Heuristic }
+func (heuristic) Count(text string) int { return Estimate(text) }
+
+// bpe is a byte-pair-encoding tokenizer backed by an embedded vocabulary.
+type bpe struct {
+	name string
+	once sync.Once
+	enc  *tiktoken.Tiktoken
+	err  error
+}
+
+var (
+	cl100k = &bpe{name: CL100K}
+	o200k  = &bpe{name: O200K}
+)
+
+func (b *bpe) load() (Tokenizer, error) {
+	b.once.Do(func() {
+		b.enc, b.err = tiktoken.GetEncoding(b.name)
+	})
+	if b.err != nil {
+		return nil, fmt.Errorf("load %s: %w", b.name, b.err)
+	}
+	return b, nil
+}
+
+func (b *bpe) Name() string { return b.name }
+
+// Count encodes text without special-token handling, so literal markers such
+// as "<|endoftext|>" are counted as ordinary text.
+func (b *bpe) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	return len(b.enc.EncodeOrdinary(text))
+}
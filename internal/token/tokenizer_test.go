@@ -0,0 +1,54 @@
+package token
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGet_Heuristic(t *testing.T) {
+	for _, name := range []string{"", Heuristic} {
+		tok, err := Get(name)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", name, err)
+		}
+		if tok.Name() != Heuristic {
+			t.Errorf("Get(%q).Name() = %q, want %q", name, tok.Name(), Heuristic)
+		}
+		if tok.Count("Hello world this is a test") != Estimate("Hello world this is a test") {
+			t.Error("expected heuristic tokenizer to match Estimate")
+		}
+	}
+}
+
+func TestGet_BPE(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{CL100K, "hello world", 2},
+		{CL100K, "", 0},
+		{CL100K, "<|endoftext|>", 7}, // special tokens are counted as plain text
+		{O200K, "hello world", 2},
+	}
+
+	for _, tt := range tests {
+		tok, err := Get(tt.name)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", tt.name, err)
+		}
+		if tok.Name() != tt.name {
+			t.Errorf("expected name %q, got %q", tt.name, tok.Name())
+		}
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("%s.Count(%q) = %d, want %d", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestGet_Unknown(t *testing.T) {
+	_, err := Get("gpt2-ish")
+	if !errors.Is(err, ErrUnknownTokenizer) {
+		t.Errorf("expected ErrUnknownTokenizer, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package converter
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func buildTarGz(t *testing.T, files [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f[0], Mode: 0o644, Size: int64(len(f[1])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f[1]))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestConverter_ZIPArchive(t *testing.T) {
+	nested := buildTarGz(t, [][2]string{
+		{"./notes.txt", "Collected in spring."},
+	})
+	data := buildZip(t, [][2]string{
+		{"release/README.md", "# Ferns\n\nA field guide.\n\n## Usage\n\n```\n# not a heading\n```"},
+		{"release/data/plants.csv", "name,qty\nfern,3\n"},
+		{"release/bin/tool", "\x7fELF\x00\x01\x02"},
+		{"release/LICENSE", "MIT License"},
+		{"release/extra.tar.gz", string(nested)},
+		{"__MACOSX/release/._README.md", "junk"},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"# download",
+		"## Contents",
+		"└── release/\n    ├── README.md\n    ├── data/\n    │   └── plants.csv\n    ├── bin/\n    │   └── tool\n    ├── LICENSE\n    └── extra.tar.gz/\n        └── notes.txt",
+		"## release/README.md",
+		"### Ferns",
+		"#### Usage",
+		"# not a heading",
+		"## release/data/plants.csv",
+		"| fern | 3 |",
+		"## release/LICENSE",
+		"MIT License",
+		"## release/extra.tar.gz/notes.txt",
+		"Collected in spring.",
+	})
+	for _, unwanted := range []string{"## release/bin/tool", "__MACOSX", "# plants.csv"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, md)
+		}
+	}
+}
+
+func TestConverter_TarGzArchive(t *testing.T) {
+	data := buildTarGz(t, [][2]string{
+		{"dataset/info.json", `{"rows": 2}`},
+		{"dataset/page.html", "<html><body><article><h1>About</h1><p>This dataset lists ferns found in the northern woods over three seasons.</p></article></body></html>"},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"└── dataset/\n    ├── info.json\n    └── page.html",
+		"## dataset/info.json",
+		`"rows": 2`,
+		"## dataset/page.html",
+		"This dataset lists ferns",
+	})
+}
+
+func TestConverter_ArchiveLimits(t *testing.T) {
+	var files [][2]string
+	for i := range 1005 {
+		files = append(files, [2]string{fmt.Sprintf("f%04d.txt", i), "x"})
+	}
+	md := convertServed(t, buildZip(t, files), nil)
+	if !strings.Contains(md, "_Archive truncated: more than 1000 files._") {
+		t.Errorf("expected truncation note")
+	}
+	if !strings.Contains(md, "f0999.txt") || strings.Contains(md, "f1000.txt") {
+		t.Errorf("expected exactly the first 1000 files")
+	}
+
+	// archives nested beyond the depth limit are listed, not expanded
+	inner := buildZip(t, [][2]string{{"deep.txt", "deep"}})
+	for range 3 {
+		inner = buildZip(t, [][2]string{{"inner.zip", string(inner)}})
+	}
+	md = convertServed(t, inner, nil)
+	if !strings.Contains(md, "Nested archive not expanded") || strings.Contains(md, "deep.txt") {
+		t.Errorf("expected depth limit, got:\n%s", md)
+	}
+}
@@ -0,0 +1,299 @@
+package converter
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// truncationNotice is appended when content had to be cut mid-document.
+const truncationNotice = "*[Content truncated to fit token budget]*"
+
+// Section values used to decide what to drop first; lower goes first.
+const (
+	valueBoilerplate = iota // references, "see also", external links, ...
+	valueLinkList           // blocks that are mostly links
+	valueLongTable          // tables with many rows
+	valueLinkHeavy          // sections dominated by links
+)
+
+// longTableRows is the row count above which a table is a drop candidate.
+const longTableRows = 15
+
+// lowValueHeadings are section titles whose content rarely helps an LLM.
+var lowValueHeadings = map[string]bool{
+	"references": true, "see also": true, "external links": true,
+	"further reading": true, "notes": true, "bibliography": true,
+	"footnotes": true, "citations": true, "sources": true, "related": true,
+	"related articles": true, "related posts": true, "related links": true,
+	"links": true, "comments": true, "acknowledgements": true,
+	"acknowledgments": true, "tags": true, "share": true, "share this": true,
+	"notes and references": true, "works cited": true,
+	"参考资料": true, "参考文献": true, "外部链接": true, "相关链接": true, "另见": true,
+}
+
+// mdLinkPattern matches inline [text](url) and reference [text][1] links.
+var mdLinkPattern = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[\d+\])`)
+
+// refUsePattern matches the label of a reference link; refDefPattern matches
+// a "[1]: url" definition line written for LinkReference.
+var (
+	refUsePattern = regexp.MustCompile(`\]\[(\d+)\]`)
+	refDefPattern = regexp.MustCompile(`^\[(\d+)\]: \S`)
+)
+
+// budgetUnit is a block or a whole section that may be dropped.
+type budgetUnit struct {
+	blocks []int // indexes into the block list
+	value  int
+}
+
+// fitBudget trims markdown to at most maxTokens. It first drops whole
+// low-value units, lowest value and latest position first: boilerplate
+// sections such as references, link lists, long tables and link-heavy
+// sections. If that is not enough it hard-truncates at a block boundary.
+// Front matter and the opening heading are never dropped, and the trailing
+// definitions of reference links are rebuilt for the links that remain.
+func fitBudget(markdown string, maxTokens int, count func(string) int) (string, bool) {
+	if maxTokens <= 0 || count(markdown) <= maxTokens {
+		return markdown, false
+	}
+
+	blocks := splitBlocks(markdown)
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = markdown[b.start:b.end]
+	}
+	var defs map[string]string
+	if n := len(texts); n > 1 {
+		if defs = refDefinitions(texts[n-1]); defs != nil {
+			blocks, texts = blocks[:n-1], texts[:n-1]
+		}
+	}
+	kept := make([]bool, len(blocks))
+	for i := range kept {
+		kept[i] = true
+	}
+
+	assemble := func() string {
+		var parts []string
+		for i, t := range texts {
+			if kept[i] {
+				parts = append(parts, t)
+			}
+		}
+		return withDefinitions(strings.Join(parts, "\n\n"), defs)
+	}
+
+	units := budgetUnits(blocks, texts)
+	sort.SliceStable(units, func(i, j int) bool {
+		if units[i].value != units[j].value {
+			return units[i].value < units[j].value
+		}
+		return units[i].blocks[0] > units[j].blocks[0] // trailing content first
+	})
+
+	for _, u := range units {
+		for _, i := range u.blocks {
+			kept[i] = false
+		}
+		if out := assemble(); count(out) <= maxTokens {
+			return out, true
+		}
+	}
+
+	// reserve room for the definitions the remaining links may still need
+	if defs != nil {
+		out := assemble()
+		if reserve := count(out) - count(withDefinitions(out, nil)) + 1; reserve < maxTokens {
+			out := hardTruncate(texts, kept, maxTokens-reserve, count)
+			return withDefinitions(out, defs), true
+		}
+	}
+	return hardTruncate(texts, kept, maxTokens, count), true
+}
+
+// refDefinitions parses a block made only of reference link definitions into
+// their URLs by label. It returns nil for any other block.
+func refDefinitions(text string) map[string]string {
+	defs := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		m := refDefPattern.FindStringSubmatch(line)
+		if m == nil {
+			return nil
+		}
+		defs[m[1]] = line
+	}
+	return defs
+}
+
+// withDefinitions appends the definitions of the reference links used in
+// markdown, in label order.
+func withDefinitions(markdown string, defs map[string]string) string {
+	var labels []int
+	seen := make(map[string]bool)
+	for _, m := range refUsePattern.FindAllStringSubmatch(markdown, -1) {
+		if _, ok := defs[m[1]]; ok && !seen[m[1]] {
+			seen[m[1]] = true
+			n, _ := strconv.Atoi(m[1])
+			labels = append(labels, n)
+		}
+	}
+	if len(labels) == 0 {
+		return markdown
+	}
+	sort.Ints(labels)
+	lines := make([]string, len(labels))
+	for i, n := range labels {
+		lines[i] = defs[strconv.Itoa(n)]
+	}
+	return markdown + "\n\n" + strings.Join(lines, "\n")
+}
+
+// budgetUnits lists drop candidates. The first heading, front matter and the
+// content before the second heading are protected.
+func budgetUnits(blocks []mdBlock, texts []string) []budgetUnit {
+	var units []budgetUnit
+
+	firstHeading := -1
+	for i, b := range blocks {
+		if b.level > 0 {
+			firstHeading = i
+			break
+		}
+	}
+
+	// sections: a heading and the blocks up to the next heading of the same
+	// or a higher level, so subsections belong to every enclosing section
+	type openSection struct {
+		level       int
+		blocks      []int
+		boilerplate bool
+	}
+	var open []*openSection
+	closeSection := func(s *openSection) {
+		switch {
+		case s.boilerplate:
+			units = append(units, budgetUnit{blocks: s.blocks, value: valueBoilerplate})
+		case linkRatio(joinBlocks(texts, s.blocks)) > 0.5:
+			units = append(units, budgetUnit{blocks: s.blocks, value: valueLinkHeavy})
+		}
+	}
+
+	for i, b := range blocks {
+		if b.level > 0 {
+			for len(open) > 0 && open[len(open)-1].level >= b.level {
+				closeSection(open[len(open)-1])
+				open = open[:len(open)-1]
+			}
+			if i == firstHeading {
+				continue
+			}
+			for _, s := range open {
+				s.blocks = append(s.blocks, i)
+			}
+			open = append(open, &openSection{
+				level:       b.level,
+				blocks:      []int{i},
+				boilerplate: lowValueHeadings[normalizeHeading(b.heading)],
+			})
+			continue
+		}
+		if len(open) == 0 {
+			continue // lead content, protected
+		}
+		for _, s := range open {
+			s.blocks = append(s.blocks, i)
+		}
+
+		text := texts[i]
+		switch {
+		case isTable(text) && strings.Count(text, "\n") > longTableRows:
+			units = append(units, budgetUnit{blocks: []int{i}, value: valueLongTable})
+		case isList(text) && linkRatio(text) > 0.6:
+			units = append(units, budgetUnit{blocks: []int{i}, value: valueLinkList})
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		closeSection(open[i])
+	}
+
+	return units
+}
+
+// hardTruncate keeps blocks in order until the budget is reached, cutting the
+// last block on a word boundary and appending a truncation notice. Budgets
+// too small for the notice get the cut text alone.
+func hardTruncate(texts []string, kept []bool, maxTokens int, count func(string) int) string {
+	var tail []string
+	budget := maxTokens
+	if n := count(truncationNotice); n < maxTokens {
+		tail = []string{truncationNotice}
+		budget -= n + 1
+	}
+	var parts []string
+	used := 0
+	for i, t := range texts {
+		if !kept[i] {
+			continue
+		}
+		n := count(t)
+		if used+n <= budget {
+			parts = append(parts, t)
+			used += n + 1 // blank-line separator
+			continue
+		}
+		if remaining := budget - used; remaining > 0 {
+			cut := t[:splitWords(t, 0, len(t), remaining, count)[0].end]
+			if fence := strings.TrimSpace(t); strings.HasPrefix(fence, "```") || strings.HasPrefix(fence, "~~~") {
+				cut += "\n" + fence[:3]
+			}
+			parts = append(parts, cut)
+		}
+		break
+	}
+
+	// sums of per-block counts can undercount the joined text slightly
+	for len(parts) > 0 && count(strings.Join(append(parts, tail...), "\n\n")) > maxTokens {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(append(parts, tail...), "\n\n")
+}
+
+func joinBlocks(texts []string, idx []int) string {
+	parts := make([]string, len(idx))
+	for i, j := range idx {
+		parts[i] = texts[j]
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// linkRatio returns the share of non-space characters that are link text or targets.
+func linkRatio(text string) float64 {
+	total := len(strings.Join(strings.Fields(text), ""))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	for _, m := range mdLinkPattern.FindAllString(text, -1) {
+		linked += len(strings.Join(strings.Fields(m), ""))
+	}
+	return float64(linked) / float64(total)
+}
+
+func isTable(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "|")
+}
+
+func isList(text string) bool {
+	t := strings.TrimSpace(text)
+	return strings.HasPrefix(t, "- ") || strings.HasPrefix(t, "* ") || strings.HasPrefix(t, "+ ") ||
+		(len(t) > 2 && t[0] >= '0' && t[0] <= '9' && strings.Contains(t[:min(len(t), 4)], "."))
+}
+
+func normalizeHeading(h string) string {
+	h = mdLinkPattern.ReplaceAllString(h, "$1")
+	h = strings.Trim(h, " *_:#")
+	return strings.ToLower(h)
+}
@@ -0,0 +1,170 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elonfeng/url2md/internal/token"
+)
+
+func budgetDoc() string {
+	var b strings.Builder
+	b.WriteString("# Article\n\nThe lead paragraph explains what this article is about.\n\n")
+	b.WriteString("## Details\n\n" + strings.Repeat("Important body text. ", 40) + "\n\n")
+	b.WriteString("## Data\n\n| Year | Value |\n| --- | --- |\n")
+	for i := 0; i < 30; i++ {
+		fmt.Fprintf(&b, "| %d | %d |\n", 1990+i, i*7)
+	}
+	b.WriteString("\n## References\n\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&b, "- [Source number %d](https://example.com/ref/%d)\n", i, i)
+	}
+	return b.String()
+}
+
+func TestFitBudget_DropsLowValueSectionsFirst(t *testing.T) {
+	md := budgetDoc()
+	total := token.Estimate(md)
+
+	// just enough room once the references are gone
+	withoutRefs := md[:strings.Index(md, "\n## References")]
+	out, truncated := fitBudget(md, token.Estimate(withoutRefs)+5, token.Estimate)
+	if !truncated {
+		t.Fatal("expected truncation")
+	}
+	if strings.Contains(out, "References") || strings.Contains(out, "Source number") {
+		t.Error("expected references section to be dropped")
+	}
+	if !strings.Contains(out, "| 2019 | 203 |") || !strings.Contains(out, "Important body text.") {
+		t.Error("expected table and body to survive")
+	}
+	if strings.Contains(out, truncationNotice) {
+		t.Error("dropping sections should not need a truncation notice")
+	}
+
+	// tighter budget: the long table goes next, body text stays
+	out, _ = fitBudget(md, total/2, token.Estimate)
+	if strings.Contains(out, "| 1990 |") {
+		t.Error("expected long table to be dropped")
+	}
+	if !strings.Contains(out, "## Data") || !strings.Contains(out, "Important body text.") {
+		t.Errorf("expected headings and body to survive, got:\n%s", out)
+	}
+}
+
+func TestFitBudget_DropsSubsections(t *testing.T) {
+	md := "# Article\n\nLead.\n\n## Body\n\n" + strings.Repeat("Body text. ", 40) +
+		"\n\n## References\n\n### Books\n\n" + strings.Repeat("A long citation of a book. ", 20) +
+		"\n\n### Papers\n\n" + strings.Repeat("A long citation of a paper. ", 20)
+	out, _ := fitBudget(md, token.Estimate(md[:strings.Index(md, "\n\n## References")])+5, token.Estimate)
+	if strings.Contains(out, "References") || strings.Contains(out, "Books") || strings.Contains(out, "citation") {
+		t.Errorf("expected subsections to go with the references section, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Body text.") {
+		t.Errorf("expected body to survive, got:\n%s", out)
+	}
+}
+
+func TestFitBudget_KeepsReferenceDefinitions(t *testing.T) {
+	md := "# Article\n\nSee the [guide][1] first.\n\n## Body\n\n" + strings.Repeat("Body text. ", 40) +
+		"\n\n## References\n\n- [Source][2]\n- [Archive][3]\n\n" +
+		"[1]: https://example.com/guide\n[2]: https://example.com/source\n[3]: https://example.com/archive"
+
+	want := md[:strings.Index(md, "\n\n## References")] + "\n\n[1]: https://example.com/guide"
+	out, _ := fitBudget(md, token.Estimate(want), token.Estimate)
+	if out != want {
+		t.Errorf("expected only the definition still referenced, got:\n%s", out)
+	}
+
+	out, _ = fitBudget(md, 40, token.Estimate)
+	if !strings.Contains(out, truncationNotice) || !strings.HasSuffix(out, "\n\n[1]: https://example.com/guide") {
+		t.Errorf("expected definitions after hard truncation, got:\n%s", out)
+	}
+	if n := token.Estimate(out); n > 40 {
+		t.Errorf("got %d tokens", n)
+	}
+}
+
+func TestFitBudget_HardTruncate(t *testing.T) {
+	md := "# Title\n\n" + strings.Repeat("word ", 500) + "\n\n## More\n\n" + strings.Repeat("tail ", 500)
+
+	for _, max := range []int{50, 100, 300} {
+		out, truncated := fitBudget(md, max, token.Estimate)
+		if !truncated {
+			t.Fatalf("max %d: expected truncation", max)
+		}
+		if n := token.Estimate(out); n > max {
+			t.Errorf("max %d: got %d tokens", max, n)
+		}
+		if !strings.HasPrefix(out, "# Title") || !strings.HasSuffix(out, truncationNotice) {
+			t.Errorf("max %d: unexpected output %q", max, out)
+		}
+	}
+
+	if out, truncated := fitBudget(md, 10000, token.Estimate); truncated || out != md {
+		t.Error("expected markdown under budget to be returned unchanged")
+	}
+}
+
+func TestFitBudget_TinyBudget(t *testing.T) {
+	md := "# Title\n\n" + strings.Repeat("word ", 500)
+
+	for max := 1; max <= 5; max++ {
+		out, truncated := fitBudget(md, max, token.Estimate)
+		if !truncated {
+			t.Fatalf("max %d: expected truncation", max)
+		}
+		if n := token.Estimate(out); n > max {
+			t.Errorf("max %d: got %d tokens in %q", max, n, out)
+		}
+		if strings.Contains(out, truncationNotice) {
+			t.Errorf("max %d: notice does not fit but was appended: %q", max, out)
+		}
+	}
+}
+
+func TestFitBudget_ClosesCodeFence(t *testing.T) {
+	md := "# Code\n\n```go\n" + strings.Repeat("fmt.Println(1)\n", 200) + "```"
+	out, _ := fitBudget(md, 60, token.Estimate)
+	if strings.Count(out, "```") != 2 {
+		t.Errorf("expected a closed code fence, got %q", out)
+	}
+}
+
+func TestConverter_MaxTokens(t *testing.T) {
+	md := budgetDoc()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprint(w, md)
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Method = "negotiate"
+	opts.MaxTokens = 200
+	opts.Tokenizer = token.CL100K
+
+	result, err := New().Convert(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Truncated {
+		t.Fatal("expected result to be truncated")
+	}
+	if result.TokenCount > 200 || result.OriginalTokenCount <= 200 {
+		t.Errorf("unexpected counts: %d of original %d", result.TokenCount, result.OriginalTokenCount)
+	}
+
+	opts.MaxTokens = 0
+	result, err = New().Convert(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Truncated || result.OriginalTokenCount != result.TokenCount {
+		t.Errorf("expected untouched result, got truncated=%v %d/%d", result.Truncated, result.TokenCount, result.OriginalTokenCount)
+	}
+}
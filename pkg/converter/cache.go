@@ -0,0 +1,228 @@
+package converter
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elonfeng/url2md/pkg/converter/filetype"
+)
+
+// Cache stores conversion results keyed by URL and output-affecting options.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+}
+
+// CacheEntry is a cached Result with the upstream validators used to
+// revalidate it.
+type CacheEntry struct {
+	Result       *Result
+	ETag         string
+	LastModified string
+	StoredAt     time.Time
+}
+
+// errNotModified is returned by a layer when the origin answered 304.
+var errNotModified = errors.New("not modified")
+
+// fetchState carries conditional-request validators between Convert and the
+// layers that fetch over HTTP.
+type fetchState struct {
+	etag         string // sent as If-None-Match
+	lastModified string // sent as If-Modified-Since
+
+	// filled in from the origin's response
+	respETag         string
+	respLastModified string
+	finalURL         string // after redirects
+
+	doc *filetype.DocMeta // document metadata from file converters such as PDF
+}
+
+type fetchStateKey struct{}
+
+func withFetchState(ctx context.Context, st *fetchState) context.Context {
+	return context.WithValue(ctx, fetchStateKey{}, st)
+}
+
+func fetchStateFrom(ctx context.Context) *fetchState {
+	st, _ := ctx.Value(fetchStateKey{}).(*fetchState)
+	return st
+}
+
+// setConditional adds validator headers to req when ctx carries them.
+func setConditional(ctx context.Context, req *http.Request) {
+	st := fetchStateFrom(ctx)
+	if st == nil {
+		return
+	}
+	if st.etag != "" {
+		req.Header.Set("If-None-Match", st.etag)
+	}
+	if st.lastModified != "" {
+		req.Header.Set("If-Modified-Since", st.lastModified)
+	}
+}
+
+// recordResponse stores the response validators and final URL in ctx's
+// fetch state.
+func recordResponse(ctx context.Context, resp *http.Response) {
+	st := fetchStateFrom(ctx)
+	if st == nil {
+		return
+	}
+	st.respETag = resp.Header.Get("ETag")
+	st.respLastModified = resp.Header.Get("Last-Modified")
+	if resp.Request != nil && resp.Request.URL != nil {
+		st.finalURL = resp.Request.URL.String()
+	}
+}
+
+// recordDocMeta stores document metadata in ctx's fetch state.
+func recordDocMeta(ctx context.Context, meta *filetype.DocMeta) {
+	if st := fetchStateFrom(ctx); st != nil {
+		st.doc = meta
+	}
+}
+
+// cacheKey derives the cache key from the URL and every option that changes
+// the output.
+func cacheKey(rawURL string, opts *Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%t|%t|%s|%t|%t|%s|%s|%t|%s|%d", rawURL,
+		opts.RetainImages, opts.RetainLinks, opts.linkMode(), opts.Frontmatter, opts.EnableBrowser,
+		opts.Method, opts.UserAgent, opts.Vision != nil, opts.Tokenizer, opts.MaxTokens)
+	if opts.Chunk != nil {
+		fmt.Fprintf(h, "|chunk %d %d", opts.Chunk.MaxTokens, opts.Chunk.Overlap)
+	}
+	if opts.Pages != "" {
+		fmt.Fprintf(h, "|pages %s", opts.Pages)
+	}
+	if opts.Sheets != "" || opts.CellRange != "" || opts.MaxRows > 0 || opts.IncludeHidden || opts.Formulas {
+		fmt.Fprintf(h, "|sheets %s %s %d %t %t", opts.Sheets, opts.CellRange, opts.MaxRows, opts.IncludeHidden, opts.Formulas)
+	}
+	if opts.DropOutputs {
+		fmt.Fprint(h, "|drop-outputs")
+	}
+	if opts.DocComments {
+		fmt.Fprint(h, "|doc-comments")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MemoryCache is an in-memory LRU Cache.
+type MemoryCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // front is most recently used
+	items map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryCache creates an LRU cache holding at most size entries.
+func NewMemoryCache(size int) *MemoryCache {
+	if size <= 0 {
+		size = 1
+	}
+	return &MemoryCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*memoryItem).entry, true
+}
+
+func (c *MemoryCache) Set(key string, entry *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*memoryItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&memoryItem{key: key, entry: entry})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*memoryItem).key)
+	}
+}
+
+// DiskCache is a Cache that stores one JSON file per entry in a directory.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a cache in dir, creating the directory if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+func (c *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *DiskCache) Get(key string) (*CacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry CacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Result == nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// Set writes the entry atomically; write errors are ignored since the cache
+// is only an optimization.
+func (c *DiskCache) Set(key string, entry *CacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+	tmp.Close()
+	if err := os.Rename(tmp.Name(), c.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
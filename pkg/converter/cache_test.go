@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_LRU(t *testing.T) {
+	c := NewMemoryCache(2)
+	c.Set("a", &CacheEntry{Result: &Result{Markdown: "a"}})
+	c.Set("b", &CacheEntry{Result: &Result{Markdown: "b"}})
+	c.Get("a") // a is now most recently used
+	c.Set("c", &CacheEntry{Result: &Result{Markdown: "c"}})
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if e, ok := c.Get(key); !ok || e.Result.Markdown != key {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+}
+
+func TestDiskCache_RoundTrip(t *testing.T) {
+	c, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.Get("missing"); ok {
+		t.Error("expected miss")
+	}
+
+	stored := time.Now().Truncate(time.Second)
+	c.Set("k", &CacheEntry{Result: &Result{Markdown: "# Hi", Method: "static"}, ETag: `"v1"`, StoredAt: stored})
+	e, ok := c.Get("k")
+	if !ok {
+		t.Fatal("expected hit")
+	}
+	if e.Result.Markdown != "# Hi" || e.ETag != `"v1"` || !e.StoredAt.Equal(stored) {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+}
+
+func TestConverter_CacheRevalidation(t *testing.T) {
+	var full, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprint(w, "# Cached page\n\nBody.")
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Method = "negotiate"
+	opts.Cache = NewMemoryCache(10)
+
+	first, err := New().Convert(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Cached {
+		t.Error("first conversion should not be cached")
+	}
+
+	second, err := New().Convert(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !second.Cached || second.Markdown != first.Markdown {
+		t.Errorf("expected cached copy of first result, got cached=%v %q", second.Cached, second.Markdown)
+	}
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Errorf("expected 1 full fetch and 1 revalidation, got %d/%d", full.Load(), notModified.Load())
+	}
+
+	// different output options must not share an entry
+	opts.Frontmatter = false
+	third, err := New().Convert(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if third.Cached || full.Load() != 2 {
+		t.Errorf("expected a fresh fetch for different options")
+	}
+}
+
+func TestConverter_CacheTTL(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprint(w, "no validators")
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Method = "negotiate"
+	opts.Cache = NewMemoryCache(10)
+	opts.CacheTTL = time.Hour
+
+	for i := 0; i < 3; i++ {
+		if _, err := New().Convert(context.Background(), srv.URL, opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected a single origin request within TTL, got %d", hits.Load())
+	}
+}
@@ -0,0 +1,317 @@
+package converter
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/elonfeng/url2md/internal/token"
+)
+
+// ChunkOptions configures splitting of the converted markdown into chunks.
+type ChunkOptions struct {
+	MaxTokens int // upper bound on tokens per chunk
+	Overlap   int // tokens of trailing context repeated at the start of the next chunk
+}
+
+// Chunk is a heading-aware slice of Result.Markdown, sized for embedding.
+type Chunk struct {
+	Index      int      `json:"index"`
+	Text       string   `json:"text"`
+	Headings   []string `json:"headings"` // enclosing headings, outermost first
+	URL        string   `json:"url"`
+	StartByte  int      `json:"start_byte"` // Text == markdown[StartByte:EndByte]
+	EndByte    int      `json:"end_byte"`
+	TokenCount int      `json:"token_count"`
+}
+
+// mdBlock is a contiguous region of markdown: a paragraph, list, table,
+// fenced code block, front matter, or a single heading line.
+type mdBlock struct {
+	start, end int
+	level      int // heading level, 0 for non-heading blocks
+	heading    string
+}
+
+// ChunkMarkdown splits markdown into chunks of at most opts.MaxTokens tokens.
+// Chunks never span a heading boundary, each carries its heading breadcrumb,
+// and blocks larger than the budget are split on lines and then words.
+// Tokens are counted with the heuristic estimator; Convert uses the tokenizer
+// selected in Options instead.
+func ChunkMarkdown(markdown, sourceURL string, opts *ChunkOptions) []Chunk {
+	return chunkMarkdown(markdown, sourceURL, opts, token.Estimate)
+}
+
+func chunkMarkdown(markdown, sourceURL string, opts *ChunkOptions, count func(string) int) []Chunk {
+	if opts == nil || opts.MaxTokens <= 0 || strings.TrimSpace(markdown) == "" {
+		return nil
+	}
+	maxTokens := opts.MaxTokens
+	overlap := opts.Overlap
+	if overlap >= maxTokens {
+		overlap = maxTokens / 2
+	}
+
+	var (
+		chunks     []Chunk
+		breadcrumb []string
+		current    []mdBlock
+		tokens     []int // token count per block in current
+		hasContent bool
+	)
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		start, end := current[0].start, current[len(current)-1].end
+		text := markdown[start:end]
+		chunks = append(chunks, Chunk{
+			Index:      len(chunks),
+			Text:       text,
+			Headings:   append([]string(nil), breadcrumb...),
+			URL:        sourceURL,
+			StartByte:  start,
+			EndByte:    end,
+			TokenCount: count(text),
+		})
+	}
+
+	for _, b := range splitBlocks(markdown) {
+		if b.level > 0 {
+			if hasContent {
+				flush()
+				current, tokens, hasContent = nil, nil, false
+			}
+			for len(breadcrumb) >= b.level {
+				breadcrumb = breadcrumb[:len(breadcrumb)-1]
+			}
+			for len(breadcrumb) < b.level-1 {
+				breadcrumb = append(breadcrumb, "")
+			}
+			breadcrumb = append(breadcrumb, b.heading)
+			current = append(current, b)
+			tokens = append(tokens, count(markdown[b.start:b.end]))
+			continue
+		}
+
+		budget := maxTokens
+		if !hasContent {
+			budget = max(1, maxTokens-sum(tokens)) // leave room for pending headings
+		}
+		for _, piece := range splitOversized(markdown, b, budget, count) {
+			n := count(markdown[piece.start:piece.end])
+			if hasContent && count(markdown[current[0].start:piece.end]) > maxTokens {
+				flush()
+				current, tokens = overlapTail(current, tokens, overlap, maxTokens-n)
+			}
+			current = append(current, piece)
+			tokens = append(tokens, n)
+			hasContent = true
+		}
+	}
+	flush()
+
+	// headings skipped between levels (e.g. # then ###) leave empty slots
+	for i := range chunks {
+		var hs []string
+		for _, h := range chunks[i].Headings {
+			if h != "" {
+				hs = append(hs, h)
+			}
+		}
+		chunks[i].Headings = hs
+	}
+	return chunks
+}
+
+// overlapTail returns the trailing blocks whose tokens fit within overlap and
+// leave room for room more tokens.
+func overlapTail(blocks []mdBlock, tokens []int, overlap, room int) ([]mdBlock, []int) {
+	if overlap <= 0 {
+		return nil, nil
+	}
+	limit := min(overlap, room)
+	total, i := 0, len(blocks)
+	for i > 0 && total+tokens[i-1] <= limit {
+		total += tokens[i-1]
+		i--
+	}
+	return append([]mdBlock(nil), blocks[i:]...), append([]int(nil), tokens[i:]...)
+}
+
+func sum(xs []int) int {
+	total := 0
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+// splitBlocks scans markdown into blocks separated by blank lines. Headings
+// are always their own block; fenced code and leading front matter are kept whole.
+func splitBlocks(markdown string) []mdBlock {
+	var (
+		blocks     []mdBlock
+		blockStart = -1
+		blockEnd   int
+		fence      string
+	)
+	closeBlock := func() {
+		if blockStart >= 0 {
+			blocks = append(blocks, mdBlock{start: blockStart, end: blockEnd})
+			blockStart = -1
+		}
+	}
+
+	pos := 0
+	inFrontmatter := strings.HasPrefix(markdown, "---\n")
+	for pos < len(markdown) {
+		lineEnd := strings.IndexByte(markdown[pos:], '\n')
+		next := len(markdown)
+		if lineEnd >= 0 {
+			lineEnd += pos
+			next = lineEnd + 1
+		} else {
+			lineEnd = len(markdown)
+		}
+		line := markdown[pos:lineEnd]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case inFrontmatter:
+			if blockStart < 0 {
+				blockStart = pos
+			}
+			blockEnd = lineEnd
+			if trimmed == "---" && pos > 0 {
+				inFrontmatter = false
+				closeBlock()
+			}
+
+		case fence != "":
+			blockEnd = lineEnd
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			if blockStart < 0 {
+				blockStart = pos
+			}
+			blockEnd = lineEnd
+			fence = trimmed[:3]
+
+		case trimmed == "":
+			closeBlock()
+
+		case headingLevel(trimmed) > 0:
+			closeBlock()
+			level := headingLevel(trimmed)
+			blocks = append(blocks, mdBlock{
+				start:   pos,
+				end:     lineEnd,
+				level:   level,
+				heading: strings.TrimSpace(strings.TrimRight(trimmed[level:], "#")),
+			})
+
+		default:
+			if blockStart < 0 {
+				blockStart = pos
+			}
+			blockEnd = lineEnd
+		}
+		pos = next
+	}
+	closeBlock()
+	return blocks
+}
+
+// headingLevel returns the ATX heading level of line, or 0 if it is not a heading.
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0
+	}
+	if level < len(line) && line[level] != ' ' && line[level] != '\t' {
+		return 0
+	}
+	return level
+}
+
+// splitOversized breaks a block that exceeds maxTokens into line-aligned
+// pieces, falling back to word boundaries for single overlong lines.
+func splitOversized(markdown string, b mdBlock, maxTokens int, count func(string) int) []mdBlock {
+	if count(markdown[b.start:b.end]) <= maxTokens {
+		return []mdBlock{b}
+	}
+
+	var pieces []mdBlock
+	start, total := b.start, 0
+	for pos := b.start; pos < b.end; {
+		lineEnd := strings.IndexByte(markdown[pos:b.end], '\n')
+		if lineEnd < 0 {
+			lineEnd = b.end
+		} else {
+			lineEnd += pos
+		}
+
+		n := count(markdown[pos:lineEnd])
+		switch {
+		case n > maxTokens:
+			if pos > start {
+				pieces = append(pieces, mdBlock{start: start, end: pos - 1})
+			}
+			pieces = append(pieces, splitWords(markdown, pos, lineEnd, maxTokens, count)...)
+			start, total = lineEnd+1, 0
+		case total+n > maxTokens && pos > start:
+			pieces = append(pieces, mdBlock{start: start, end: pos - 1})
+			start, total = pos, n
+		default:
+			total += n
+		}
+		pos = lineEnd + 1
+	}
+	if start < b.end {
+		pieces = append(pieces, mdBlock{start: start, end: b.end})
+	}
+	return pieces
+}
+
+// splitWords cuts markdown[start:end] into pieces of at most maxTokens,
+// preferring whitespace and otherwise cutting on a rune boundary.
+func splitWords(markdown string, start, end, maxTokens int, count func(string) int) []mdBlock {
+	var pieces []mdBlock
+	for start < end {
+		n := count(markdown[start:end])
+		if n <= maxTokens {
+			pieces = append(pieces, mdBlock{start: start, end: end})
+			break
+		}
+
+		// guess proportionally, then back off until the piece fits
+		cut := start + (end-start)*maxTokens/n
+		for cut > start && count(markdown[start:cut]) > maxTokens {
+			cut -= (cut - start + 9) / 10
+		}
+		if ws := strings.LastIndexAny(markdown[start:cut], " \t"); ws > 0 {
+			cut = start + ws
+		}
+		for cut < end && !utf8.RuneStart(markdown[cut]) {
+			cut++
+		}
+		if cut <= start {
+			_, size := utf8.DecodeRuneInString(markdown[start:])
+			cut = start + size
+		}
+
+		pieces = append(pieces, mdBlock{start: start, end: cut})
+		start = cut
+		for start < end && (markdown[start] == ' ' || markdown[start] == '\t') {
+			start++
+		}
+	}
+	return pieces
+}
@@ -0,0 +1,92 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/elonfeng/url2md/internal/token"
+)
+
+func TestChunkMarkdown_HeadingBreadcrumbs(t *testing.T) {
+	md := "# Guide\n\nIntro paragraph.\n\n## Install\n\nRun the installer.\n\n### Linux\n\nUse the package manager.\n\n## Usage\n\nCall the command."
+
+	chunks := ChunkMarkdown(md, "https://example.com/guide", &ChunkOptions{MaxTokens: 100})
+	if len(chunks) != 4 {
+		t.Fatalf("expected 4 chunks, got %d: %+v", len(chunks), chunks)
+	}
+
+	want := [][]string{
+		{"Guide"},
+		{"Guide", "Install"},
+		{"Guide", "Install", "Linux"},
+		{"Guide", "Usage"},
+	}
+	for i, c := range chunks {
+		if strings.Join(c.Headings, " > ") != strings.Join(want[i], " > ") {
+			t.Errorf("chunk %d: headings %v, want %v", i, c.Headings, want[i])
+		}
+		if c.Text != md[c.StartByte:c.EndByte] {
+			t.Errorf("chunk %d: text does not match byte offsets", i)
+		}
+		if c.URL != "https://example.com/guide" || c.Index != i {
+			t.Errorf("chunk %d: unexpected url/index %q/%d", i, c.URL, c.Index)
+		}
+	}
+	if !strings.HasPrefix(chunks[2].Text, "### Linux") {
+		t.Errorf("expected chunk to start with its heading, got %q", chunks[2].Text)
+	}
+}
+
+func TestChunkMarkdown_TokenBoundAndOverlap(t *testing.T) {
+	var paras []string
+	for i := 0; i < 20; i++ {
+		paras = append(paras, "This paragraph has exactly ten words in it for testing.")
+	}
+	md := "# Title\n\n" + strings.Join(paras, "\n\n")
+
+	chunks := ChunkMarkdown(md, "", &ChunkOptions{MaxTokens: 40, Overlap: 13})
+	if len(chunks) < 5 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	for i, c := range chunks {
+		if c.TokenCount > 40 {
+			t.Errorf("chunk %d exceeds budget: %d tokens", i, c.TokenCount)
+		}
+		if c.TokenCount != token.Estimate(c.Text) {
+			t.Errorf("chunk %d: token count mismatch", i)
+		}
+		if i > 0 && c.StartByte >= chunks[i-1].EndByte {
+			t.Errorf("chunk %d: expected overlap with previous chunk", i)
+		}
+	}
+	if last := chunks[len(chunks)-1]; last.EndByte != len(md) {
+		t.Errorf("expected last chunk to end at %d, got %d", len(md), last.EndByte)
+	}
+}
+
+func TestChunkMarkdown_OversizedBlockAndCodeFence(t *testing.T) {
+	long := strings.Repeat("word ", 200)
+	md := "```go\n# not a heading\nfunc main() {}\n```\n\n" + long
+
+	chunks := ChunkMarkdown(md, "", &ChunkOptions{MaxTokens: 50})
+	if len(chunks) < 5 {
+		t.Fatalf("expected oversized paragraph to be split, got %d chunks", len(chunks))
+	}
+	if len(chunks[0].Headings) != 0 {
+		t.Errorf("heading inside code fence should be ignored, got %v", chunks[0].Headings)
+	}
+	for i, c := range chunks {
+		if c.TokenCount > 50 {
+			t.Errorf("chunk %d exceeds budget: %d tokens", i, c.TokenCount)
+		}
+	}
+}
+
+func TestChunkMarkdown_Disabled(t *testing.T) {
+	if chunks := ChunkMarkdown("# Hi\n\ntext", "", nil); chunks != nil {
+		t.Errorf("expected nil chunks with nil options, got %v", chunks)
+	}
+	if chunks := ChunkMarkdown("", "", &ChunkOptions{MaxTokens: 10}); chunks != nil {
+		t.Errorf("expected nil chunks for empty markdown, got %v", chunks)
+	}
+}
@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testGoSource = `// Copyright 2024 The Ferns Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build linux
+
+// Package ferns counts ferns by site.
+//
+// Counts are cached per season.
+package ferns
+
+// Count returns the number of ferns at site.
+func Count(site string) int { return 0 }
+`
+
+func TestConverter_Code(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/blob/") {
+			// a repository file viewer page
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><head><title>ferns.go</title></head><body><article><p>Viewing ferns.go in the repository browser, with history and blame.</p></article></body></html>`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(testGoSource))
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	result, err := New().Convert(context.Background(), srv.URL+"/raw/ferns.go", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectInOrder(t, result.Markdown, []string{
+		"# ferns.go",
+		"- **Language**: go\n- **Lines**: 13\n- **Size**: 358 bytes",
+		"```go\n// Copyright 2024",
+		"func Count(site string) int { return 0 }\n```",
+	})
+	if strings.Contains(result.Markdown, "Package ferns counts ferns by site.\n\nCounts") {
+		t.Errorf("doc comment rendered without DocComments:\n%s", result.Markdown)
+	}
+
+	opts.DocComments = true
+	result, err = New().Convert(context.Background(), srv.URL+"/raw/ferns.go", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectInOrder(t, result.Markdown, []string{
+		"- **Size**: 358 bytes\n\nPackage ferns counts ferns by site.\n\nCounts are cached per season.\n\n```go",
+	})
+	if doc, _, _ := strings.Cut(result.Markdown, "```"); strings.Contains(doc, "Copyright") || strings.Contains(doc, "go:build") {
+		t.Errorf("license header or directive in doc comment:\n%s", result.Markdown)
+	}
+
+	result, err = New().Convert(context.Background(), srv.URL+"/blob/ferns.go", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(result.Markdown, "```") || !strings.Contains(result.Markdown, "repository browser") {
+		t.Errorf("expected the HTML page to be converted as a page, got:\n%s", result.Markdown)
+	}
+}
+
+func TestConvertBytes_CodeDocstring(t *testing.T) {
+	src := "#!/usr/bin/env python3\n# -*- coding: utf-8 -*-\n\"\"\"Fern survey tools.\n\nReads ``survey.csv``.\n\"\"\"\nimport csv\n"
+	opts := DefaultOptions()
+	opts.DocComments = true
+	result, err := ConvertBytes(context.Background(), []byte(src), "tools/survey.py", "", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectInOrder(t, result.Markdown, []string{
+		"# survey.py",
+		"- **Language**: python",
+		"Fern survey tools.\n\nReads ``survey.csv``.\n\n```python\n#!/usr/bin/env python3",
+	})
+}
+
+func TestConverter_AmbiguousCodeExtension(t *testing.T) {
+	// an MPEG transport stream packet: .ts is also an HLS video segment
+	segment := []byte("G\x40\x00\x10\x00\x00\xb0\x0d\x00\x01\xc1\x00\x00\x00\x01\xf0\x00")
+
+	result, err := ConvertBytes(context.Background(), segment, "video.ts", "", nil)
+	if (err != nil && strings.Contains(err.Error(), "not a text file")) || (err == nil && strings.Contains(result.Markdown, "```")) {
+		t.Errorf("binary .ts converted as code: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/segment1.ts") {
+			w.Header().Set("Content-Type", "video/mp2t")
+			w.Write(segment)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte("export const ferns: number = 12;\n"))
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	if _, err := New().Convert(context.Background(), srv.URL+"/hls/segment1.ts", opts); err != nil && strings.Contains(err.Error(), "not a text file") {
+		t.Errorf("binary .ts converted as code: %v", err)
+	}
+	result, err = New().Convert(context.Background(), srv.URL+"/src/ferns.ts", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectInOrder(t, result.Markdown, []string{"- **Language**: typescript", "```typescript\nexport const ferns"})
+}
@@ -2,12 +2,15 @@ package converter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/elonfeng/url2md/internal/metadata"
 	"github.com/elonfeng/url2md/internal/token"
+	"github.com/elonfeng/url2md/pkg/converter/filetype"
 )
 
 // Converter converts a URL to Markdown.
@@ -46,65 +49,206 @@ func (c *converter) Convert(ctx context.Context, rawURL string, opts *Options) (
 		return nil, fmt.Errorf("no conversion layers configured")
 	}
 
+	tok, err := prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		key    string
+		cached *CacheEntry
+	)
+	if opts.Cache != nil {
+		key = cacheKey(rawURL, opts)
+		if entry, ok := opts.Cache.Get(key); ok && entry.Result != nil {
+			if opts.CacheTTL > 0 && time.Since(entry.StoredAt) < opts.CacheTTL {
+				return cachedResult(entry, 0), nil
+			}
+			cached = entry
+		}
+	}
+
+	if opts.RespectRobots {
+		if err := defaultRobots.check(ctx, rawURL, opts); err != nil {
+			return nil, err
+		}
+	}
+
 	var lastErr error
 	for _, layer := range layers {
+		// revalidate against the layer that produced the cached result
+		state := &fetchState{}
+		if cached != nil && cached.Result.Method == layer.Name() {
+			state.etag, state.lastModified = cached.ETag, cached.LastModified
+		}
+
 		fetchStart := time.Now()
-		md, rawHTML, err := layer.Convert(ctx, rawURL, opts)
+		md, rawHTML, err := layer.Convert(withFetchState(ctx, state), rawURL, opts)
 		fetchTime := time.Since(fetchStart)
 
+		if errors.Is(err, errNotModified) && cached != nil {
+			entry := *cached
+			entry.StoredAt = time.Now()
+			if state.respETag != "" {
+				entry.ETag = state.respETag
+			}
+			if state.respLastModified != "" {
+				entry.LastModified = state.respLastModified
+			}
+			opts.Cache.Set(key, &entry)
+			return cachedResult(&entry, fetchTime), nil
+		}
+
 		if err != nil {
 			lastErr = fmt.Errorf("[%s] %w", layer.Name(), err)
 			continue
 		}
 
 		convertStart := time.Now()
-		meta := metadata.Extract(rawHTML)
-
-		// build final markdown with optional frontmatter and title
-		var final strings.Builder
+		result := buildResult(rawURL, md, rawHTML, state, tok, opts)
+		result.Method = layer.Name()
+		result.FetchTime = fetchTime
+		result.ConvertTime = time.Since(convertStart)
 
-		if opts.Frontmatter && (meta.Title != "" || meta.Description != "" || meta.OG["og:image"] != "") {
-			final.WriteString("---\n")
-			if meta.Title != "" {
-				final.WriteString(fmt.Sprintf("title: %s\n", meta.Title))
-			}
-			if meta.Description != "" {
-				final.WriteString(fmt.Sprintf("description: %s\n", meta.Description))
-			}
-			if img := meta.OG["og:image"]; img != "" {
-				final.WriteString(fmt.Sprintf("image: %s\n", img))
-			}
-			final.WriteString("---\n\n")
+		if opts.Cache != nil && (state.respETag != "" || state.respLastModified != "" || opts.CacheTTL > 0) {
+			stored := *result
+			opts.Cache.Set(key, &CacheEntry{
+				Result:       &stored,
+				ETag:         state.respETag,
+				LastModified: state.respLastModified,
+				StoredAt:     time.Now(),
+			})
 		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("all layers failed: %w", lastErr)
+}
 
-		// auto-prepend # Title if markdown doesn't already start with one
-		if meta.Title != "" && !strings.HasPrefix(md, "# ") {
-			final.WriteString("# ")
-			final.WriteString(meta.Title)
-			final.WriteString("\n\n")
+// ValidateOptions reports options that would fail every conversion: an
+// unknown tokenizer or link mode, or a malformed page or cell range. Convert
+// and ConvertBytes apply it too; servers call it to reject bad requests up
+// front.
+func ValidateOptions(opts *Options) error {
+	if _, err := token.Get(opts.Tokenizer); errors.Is(err, token.ErrUnknownTokenizer) {
+		return err
+	}
+	switch opts.LinkMode {
+	case "", LinkInline, LinkReference, LinkText:
+	default:
+		return fmt.Errorf("unknown link mode %q (supported: %s, %s, %s)", opts.LinkMode, LinkInline, LinkReference, LinkText)
+	}
+	if _, err := filetype.ParsePageRanges(opts.Pages); err != nil {
+		return err
+	}
+	if _, err := filetype.ParseCellRange(opts.CellRange); err != nil {
+		return err
+	}
+	return nil
+}
+
+// prepare validates the options and returns the tokenizer for counting
+// output tokens.
+func prepare(opts *Options) (token.Tokenizer, error) {
+	if err := ValidateOptions(opts); err != nil {
+		return nil, err
+	}
+	tok, err := token.Get(opts.Tokenizer)
+	if err != nil {
+		tok, _ = token.Get(token.Heuristic) // vocabulary failed to load
+	}
+	return tok, nil
+}
+
+// buildResult assembles the final markdown (frontmatter, title, token budget,
+// chunks) and metadata for a converted page or file.
+func buildResult(rawURL, md, rawHTML string, state *fetchState, tok token.Tokenizer, opts *Options) *Result {
+	meta := metadata.Extract(rawHTML)
+	if doc := state.doc; doc != nil {
+		if meta.Title == "" {
+			meta.Title = doc.Title
+		}
+		if meta.Description == "" {
+			meta.Description = doc.Subject
+		}
+		for k, v := range doc.Fields() {
+			meta.OG[k] = v
 		}
+	}
 
-		final.WriteString(md)
-		finalMd := final.String()
+	pageURL := rawURL
+	if state.finalURL != "" {
+		pageURL = state.finalURL
+	}
 
-		tokenCount := token.Estimate(finalMd)
-		convertTime := time.Since(convertStart)
+	// build final markdown with optional frontmatter and title
+	var final strings.Builder
 
-		result := &Result{
-			URL:         rawURL,
-			Markdown:    finalMd,
-			Title:       meta.Title,
-			Description: meta.Description,
-			TokenCount:  tokenCount,
-			Method:      layer.Name(),
-			Metadata:    meta.OG,
-			FetchTime:   fetchTime,
-			ConvertTime: convertTime,
+	if opts.Frontmatter && (meta.Title != "" || meta.Description != "" || meta.OG["og:image"] != "") {
+		final.WriteString("---\n")
+		if meta.Title != "" {
+			final.WriteString(fmt.Sprintf("title: %s\n", meta.Title))
 		}
-		return result, nil
+		if meta.Description != "" {
+			final.WriteString(fmt.Sprintf("description: %s\n", meta.Description))
+		}
+		// author and language come from document metadata (PDF, EPUB)
+		if author := meta.OG["author"]; author != "" {
+			final.WriteString(fmt.Sprintf("author: %s\n", author))
+		}
+		if lang := meta.OG["language"]; lang != "" {
+			final.WriteString(fmt.Sprintf("language: %s\n", lang))
+		}
+		if img := meta.OG["og:image"]; img != "" {
+			final.WriteString(fmt.Sprintf("image: %s\n", img))
+		}
+		final.WriteString("---\n\n")
 	}
 
-	return nil, fmt.Errorf("all layers failed: %w", lastErr)
+	// auto-prepend # Title if markdown doesn't already start with one
+	if meta.Title != "" && !strings.HasPrefix(md, "# ") {
+		final.WriteString("# ")
+		final.WriteString(meta.Title)
+		final.WriteString("\n\n")
+	}
+
+	final.WriteString(md)
+	finalMd := final.String()
+
+	originalTokens := tok.Count(finalMd)
+	tokenCount, truncated := originalTokens, false
+	if opts.MaxTokens > 0 && originalTokens > opts.MaxTokens {
+		finalMd, truncated = fitBudget(finalMd, opts.MaxTokens, tok.Count)
+		tokenCount = tok.Count(finalMd)
+	}
+
+	var chunks []Chunk
+	if opts.Chunk != nil {
+		chunks = chunkMarkdown(finalMd, rawURL, opts.Chunk, tok.Count)
+	}
+
+	return &Result{
+		URL:                rawURL,
+		Markdown:           finalMd,
+		Title:              meta.Title,
+		Description:        meta.Description,
+		TokenCount:         tokenCount,
+		Tokenizer:          tok.Name(),
+		Truncated:          truncated,
+		OriginalTokenCount: originalTokens,
+		Metadata:           meta.OG,
+		Links:              resolveLinks(pageURL, meta.Links),
+		Chunks:             chunks,
+	}
+}
+
+// cachedResult returns a copy of the cached Result marked as served from cache.
+func cachedResult(entry *CacheEntry, fetchTime time.Duration) *Result {
+	r := *entry.Result
+	r.Cached = true
+	r.FetchTime = fetchTime
+	r.ConvertTime = 0
+	return &r
 }
 
 func (c *converter) buildLayers(opts *Options) []Layer {
@@ -123,3 +267,32 @@ func (c *converter) buildLayers(opts *Options) []Layer {
 		return layers
 	}
 }
+
+// resolveLinks turns raw hrefs into absolute http(s) URLs relative to base,
+// dropping fragments, other schemes, and duplicates.
+func resolveLinks(base string, hrefs []string) []string {
+	if len(hrefs) == 0 {
+		return nil
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(hrefs))
+	var links []string
+	for _, href := range hrefs {
+		u, err := baseURL.Parse(href)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		u.RawFragment = ""
+		abs := u.String()
+		if !seen[abs] {
+			seen[abs] = true
+			links = append(links, abs)
+		}
+	}
+	return links
+}
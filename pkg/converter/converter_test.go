@@ -201,3 +201,37 @@ func TestConverter_NilOptions(t *testing.T) {
 		t.Error("expected non-empty markdown with nil options")
 	}
 }
+
+func TestConverter_Tokenizer(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprint(w, "hello world")
+	}))
+	defer srv.Close()
+
+	c := New()
+	opts := DefaultOptions()
+	opts.Method = "negotiate"
+
+	result, err := c.Convert(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Tokenizer != "heuristic" {
+		t.Errorf("expected default tokenizer heuristic, got %q", result.Tokenizer)
+	}
+
+	opts.Tokenizer = "cl100k_base"
+	result, err = c.Convert(context.Background(), srv.URL, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Tokenizer != "cl100k_base" || result.TokenCount != 2 {
+		t.Errorf("expected 2 cl100k_base tokens, got %d (%s)", result.TokenCount, result.Tokenizer)
+	}
+
+	opts.Tokenizer = "bogus"
+	if _, err := c.Convert(context.Background(), srv.URL, opts); err == nil {
+		t.Error("expected error for unknown tokenizer")
+	}
+}
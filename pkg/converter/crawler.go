@@ -0,0 +1,300 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CrawlOptions configures a site crawl.
+type CrawlOptions struct {
+	MaxDepth    int      // link hops to follow from the seed; 0 converts only the seed
+	MaxPages    int      // stop discovering after this many pages (0 = unlimited)
+	Include     []string // URL path globs a discovered page must match (empty = all)
+	Exclude     []string // URL path globs that exclude a discovered page
+	Concurrency int      // pages converted in parallel
+
+	Sitemaps      []string  // sitemap URLs whose pages are added to the first crawl level
+	ModifiedSince time.Time // skip sitemap entries with an older <lastmod>
+}
+
+// DefaultCrawlOptions returns sensible defaults for crawling.
+func DefaultCrawlOptions() *CrawlOptions {
+	return &CrawlOptions{
+		MaxDepth:    3,
+		MaxPages:    100,
+		Concurrency: 4,
+	}
+}
+
+// CrawlResult is the outcome of converting a single crawled page.
+type CrawlResult struct {
+	URL    string
+	Depth  int
+	Result *Result
+	Err    error
+}
+
+// Crawler converts every same-origin page reachable from a seed URL.
+type Crawler struct {
+	conv  Converter
+	opts  *Options
+	crawl *CrawlOptions
+}
+
+// NewCrawler creates a Crawler that converts pages with conv using opts.
+func NewCrawler(conv Converter, opts *Options, crawl *CrawlOptions) *Crawler {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	if crawl == nil {
+		crawl = DefaultCrawlOptions()
+	}
+	return &Crawler{conv: conv, opts: opts, crawl: crawl}
+}
+
+// Crawl walks the site breadth-first starting at seed and at the pages listed
+// in CrawlOptions.Sitemaps. Links are discovered from each converted page and
+// followed while they stay on the seed's origin and pass the include/exclude
+// filters. seed may be empty when sitemaps are given; the first sitemap then
+// defines the origin. fn is called once per page, never concurrently, as soon
+// as that page finishes.
+func (c *Crawler) Crawl(ctx context.Context, seed string, fn func(*CrawlResult)) error {
+	origin := seed
+	if origin == "" {
+		if len(c.crawl.Sitemaps) == 0 {
+			return fmt.Errorf("seed URL or sitemap is required")
+		}
+		origin = c.crawl.Sitemaps[0]
+	}
+	seedURL, err := url.Parse(origin)
+	if err != nil {
+		return fmt.Errorf("parse seed: %w", err)
+	}
+	include, err := compileGlobs(c.crawl.Include)
+	if err != nil {
+		return err
+	}
+	exclude, err := compileGlobs(c.crawl.Exclude)
+	if err != nil {
+		return err
+	}
+
+	visited := make(map[string]bool)
+	var level []string
+	if seed != "" {
+		visited[normalizeLink(seedURL)] = true
+		level = append(level, seed)
+	}
+
+	for _, sm := range c.crawl.Sitemaps {
+		entries, err := FetchSitemap(ctx, sm, c.opts)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !c.crawl.ModifiedSince.IsZero() && !e.LastMod.IsZero() && e.LastMod.Before(c.crawl.ModifiedSince) {
+				continue
+			}
+			u, err := url.Parse(e.URL)
+			if err != nil || !sameOrigin(seedURL, u) || !allowedPath(u.Path, include, exclude) {
+				continue
+			}
+			key := normalizeLink(u)
+			if visited[key] {
+				continue
+			}
+			if c.crawl.MaxPages > 0 && len(visited) >= c.crawl.MaxPages {
+				break
+			}
+			visited[key] = true
+			level = append(level, key)
+		}
+	}
+
+	for depth := 0; len(level) > 0; depth++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		results := c.convertLevel(ctx, level, depth, fn)
+		if depth >= c.crawl.MaxDepth {
+			break
+		}
+
+		var next []string
+	discover:
+		for _, r := range results {
+			if r.Result == nil {
+				continue
+			}
+			for _, link := range pageLinks(r.Result) {
+				u, err := url.Parse(link)
+				if err != nil || !sameOrigin(seedURL, u) {
+					continue
+				}
+				key := normalizeLink(u)
+				if visited[key] || !allowedPath(u.Path, include, exclude) {
+					continue
+				}
+				if c.crawl.MaxPages > 0 && len(visited) >= c.crawl.MaxPages {
+					break discover
+				}
+				visited[key] = true
+				next = append(next, key)
+			}
+		}
+		level = next
+	}
+
+	return ctx.Err()
+}
+
+// convertLevel converts all urls of one crawl depth with bounded concurrency
+// and returns their results in input order.
+func (c *Crawler) convertLevel(ctx context.Context, urls []string, depth int, fn func(*CrawlResult)) []*CrawlResult {
+	concurrency := c.crawl.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]*CrawlResult, len(urls))
+	sem := make(chan struct{}, concurrency)
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for i, u := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result, err := c.conv.Convert(ctx, u, c.opts)
+			r := &CrawlResult{URL: u, Depth: depth, Result: result, Err: err}
+			results[i] = r
+
+			if fn != nil {
+				mu.Lock()
+				fn(r)
+				mu.Unlock()
+			}
+		}(i, u)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// markdownLinkPattern matches inline markdown link targets: [text](target).
+var markdownLinkPattern = regexp.MustCompile(`\]\((https?://[^)\s]+)`)
+
+// pageLinks returns the links of a converted page. Pages without raw HTML
+// (e.g. served via content negotiation) fall back to links in the markdown.
+func pageLinks(r *Result) []string {
+	if len(r.Links) > 0 {
+		return r.Links
+	}
+	var links []string
+	for _, m := range markdownLinkPattern.FindAllStringSubmatch(r.Markdown, -1) {
+		u, err := url.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		links = append(links, cleanLinkPath(u).String())
+	}
+	return links
+}
+
+// cleanLinkPath resolves "." and ".." segments in the path of u, including
+// percent-encoded ones, keeping a trailing slash.
+func cleanLinkPath(u *url.URL) *url.URL {
+	n := *u
+	if n.Path == "" {
+		return &n
+	}
+	p := path.Clean("/" + n.Path)
+	if strings.HasSuffix(n.Path, "/") && p != "/" {
+		p += "/"
+	}
+	n.Path = p
+	n.RawPath = ""
+	return &n
+}
+
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
+// normalizeLink returns the URL without its fragment, used as the dedup key.
+func normalizeLink(u *url.URL) string {
+	n := *u
+	n.Fragment = ""
+	n.RawFragment = ""
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	return n.String()
+}
+
+func allowedPath(p string, include, exclude []*regexp.Regexp) bool {
+	if p == "" {
+		p = "/"
+	}
+	for _, re := range exclude {
+		if re.MatchString(p) {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, re := range include {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileGlobs converts path globs to regular expressions. "*" matches within
+// a path segment, "**" matches across segments, and "?" matches one character.
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, g := range globs {
+		var b strings.Builder
+		b.WriteString("^")
+		for i := 0; i < len(g); i++ {
+			switch g[i] {
+			case '*':
+				if i+1 < len(g) && g[i+1] == '*' {
+					b.WriteString(".*")
+					i++
+				} else {
+					b.WriteString("[^/]*")
+				}
+			case '?':
+				b.WriteString("[^/]")
+			default:
+				b.WriteString(regexp.QuoteMeta(string(g[i])))
+			}
+		}
+		b.WriteString("$")
+		re, err := regexp.Compile(b.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", g, err)
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
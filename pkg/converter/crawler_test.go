@@ -0,0 +1,142 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// newSiteServer serves a small linked site. Each page links to the paths in links.
+func newSiteServer(links map[string][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		targets, ok := links[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		var anchors strings.Builder
+		for _, t := range targets {
+			fmt.Fprintf(&anchors, `<a href="%s">%s</a> `, t, t)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><head><title>%s</title></head><body><article>
+		<p>This page has enough article content for the readability extraction to succeed reliably.</p>
+		<p>Second paragraph of meaningful content describing the page at %s in some detail.</p>
+		<p>%s</p></article></body></html>`, r.URL.Path, r.URL.Path, anchors.String())
+	}))
+}
+
+func crawlURLs(t *testing.T, seed string, crawl *CrawlOptions) []string {
+	t.Helper()
+	opts := DefaultOptions()
+	opts.Method = "static"
+
+	var urls []string
+	err := NewCrawler(New(), opts, crawl).Crawl(context.Background(), seed, func(r *CrawlResult) {
+		if r.Err != nil {
+			t.Errorf("crawl %s: %v", r.URL, r.Err)
+			return
+		}
+		urls = append(urls, strings.TrimPrefix(r.URL, strings.SplitN(seed, "/docs", 2)[0]))
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestCrawler_FollowsSameOriginLinks(t *testing.T) {
+	srv := newSiteServer(map[string][]string{
+		"/docs/":  {"/docs/a", "/docs/b#section", "https://other.example.com/x", "mailto:a@b.c"},
+		"/docs/a": {"/docs/b", "/docs/c"},
+		"/docs/b": {"/docs/"},
+		"/docs/c": {},
+	})
+	defer srv.Close()
+
+	got := crawlURLs(t, srv.URL+"/docs/", &CrawlOptions{MaxDepth: 5, Concurrency: 2})
+	want := []string{"/docs/", "/docs/a", "/docs/b", "/docs/c"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCrawler_MaxDepth(t *testing.T) {
+	srv := newSiteServer(map[string][]string{
+		"/docs/":  {"/docs/a"},
+		"/docs/a": {"/docs/b"},
+		"/docs/b": {},
+	})
+	defer srv.Close()
+
+	got := crawlURLs(t, srv.URL+"/docs/", &CrawlOptions{MaxDepth: 1, Concurrency: 1})
+	want := []string{"/docs/", "/docs/a"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCrawler_MaxPagesAndFilters(t *testing.T) {
+	srv := newSiteServer(map[string][]string{
+		"/docs/":          {"/docs/guide/one", "/docs/guide/two", "/docs/api/ref", "/blog/post"},
+		"/docs/guide/one": {},
+		"/docs/guide/two": {},
+		"/docs/api/ref":   {},
+	})
+	defer srv.Close()
+
+	got := crawlURLs(t, srv.URL+"/docs/", &CrawlOptions{
+		MaxDepth:    2,
+		Include:     []string{"/docs/**"},
+		Exclude:     []string{"/docs/api/*"},
+		Concurrency: 4,
+	})
+	want := []string{"/docs/", "/docs/guide/one", "/docs/guide/two"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	got = crawlURLs(t, srv.URL+"/docs/", &CrawlOptions{MaxDepth: 2, MaxPages: 2, Concurrency: 1})
+	if len(got) != 2 {
+		t.Errorf("expected 2 pages with MaxPages=2, got %v", got)
+	}
+}
+
+func TestCompileGlobs(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"/docs/*", "/docs/intro", true},
+		{"/docs/*", "/docs/a/b", false},
+		{"/docs/**", "/docs/a/b", true},
+		{"*.html", "/index.html", false},
+		{"**.html", "/a/index.html", true},
+		{"/v?/api", "/v2/api", true},
+	}
+
+	for _, tt := range tests {
+		res, err := compileGlobs([]string{tt.glob})
+		if err != nil {
+			t.Fatalf("compile %q: %v", tt.glob, err)
+		}
+		if got := res[0].MatchString(tt.path); got != tt.match {
+			t.Errorf("glob %q on %q: got %v, want %v", tt.glob, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestPageLinks_MarkdownFallbackCleansPaths(t *testing.T) {
+	r := &Result{Markdown: "[a](https://example.com/%2e%2e/%2e%2e/etc/passwd) [b](https://example.com/docs/./guide/../intro/) [c](https://example.com)"}
+	got := pageLinks(r)
+	want := []string{"https://example.com/etc/passwd", "https://example.com/docs/intro/", "https://example.com"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
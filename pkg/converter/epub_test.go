@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConverter_EPUB(t *testing.T) {
+	chapter := func(body string) string {
+		return `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Field Guide</title></head><body>` + body + `</body></html>`
+	}
+
+	// the spine order differs from the manifest order, and the NCX is only a
+	// fallback behind the nav document
+	data := buildZip(t, [][2]string{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<container xmlns="urn:oasis:names:tc:opendocument:xmlns:container" version="1.0">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`},
+		{"OEBPS/content.opf", `<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>Field Guide to Ferns</dc:title><dc:creator>Ada Green</dc:creator><dc:creator>Ben Moss</dc:creator>
+<dc:language>en</dc:language><dc:description>An open-licensed guide.</dc:description>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+<item id="ch2" href="text/ch%202.xhtml" media-type="application/xhtml+xml"/>
+<item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+<item id="cover" href="cover.xhtml" media-type="application/xhtml+xml"/>
+<item id="ncx" href="toc.ncx" media-type="application/x-dtbncx+xml"/>
+</manifest>
+<spine toc="ncx"><itemref idref="cover"/><itemref idref="ch1"/><itemref idref="ch2"/></spine>
+</package>`},
+		{"OEBPS/nav.xhtml", `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol>
+<li><a href="text/ch1.xhtml">Getting&nbsp;Started</a><ol><li><a href="text/ch1.xhtml#tools">Tools</a></li></ol></li>
+<li><a href="text/ch%202.xhtml">Identification</a></li>
+</ol></nav></body></html>`},
+		{"OEBPS/toc.ncx", `<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/"><navMap>
+<navPoint><navLabel><text>Wrong source</text></navLabel><content src="text/ch1.xhtml"/></navPoint>
+</navMap></ncx>`},
+		{"OEBPS/cover.xhtml", chapter(`<img src="cover.jpg" alt="Cover"/>`)},
+		{"OEBPS/text/ch1.xhtml", chapter(`<p>Ferns are <a href="ch%202.xhtml">easy</a> to find. See <a href="https://example.org/ferns">the society</a>.</p>
+<h2 id="tools">Tools</h2><p>A hand lens helps.</p>`)},
+		{"OEBPS/text/ch 2.xhtml", chapter(`<h1>Identification</h1><h2>Fronds</h2><p>Look at the fronds.</p>`)},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"---\ntitle: Field Guide to Ferns\ndescription: An open-licensed guide.\nauthor: Ada Green, Ben Moss\nlanguage: en\n---",
+		"# Field Guide to Ferns",
+		"## Contents\n\n- Getting Started\n  - Tools\n- Identification",
+		"## Getting Started\n\nFerns are easy to find. See [the society](https://example.org/ferns).",
+		"### Tools\n\nA hand lens helps.",
+		"## Identification\n\n### Fronds\n\nLook at the fronds.",
+	})
+	if strings.Contains(md, "Wrong source") || strings.Contains(md, "## Identification\n\n## Identification") {
+		t.Errorf("unexpected table of contents or duplicated heading:\n%s", md)
+	}
+}
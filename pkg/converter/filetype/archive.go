@@ -0,0 +1,317 @@
+package filetype
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Archive expansion limits. They apply to the archive as a whole, including
+// nested archives, so a small zip bomb cannot expand past them.
+const (
+	maxArchiveEntries = 1000      // files read
+	maxArchiveSize    = 200 << 20 // uncompressed bytes read
+	maxArchiveDepth   = 3         // archives inside archives
+)
+
+// EntryConverter converts one archive member of the given type to markdown.
+// name is the member's path inside the archive.
+type EntryConverter func(name string, t Type, data []byte) (string, error)
+
+// ConvertArchive converts a ZIP, tar or gzip-compressed archive to markdown:
+// a tree listing of its files followed by one section per supported file,
+// converted by convert. Nested archives are expanded in place. A gzip file
+// that does not hold a tar archive is treated as a single compressed file.
+func ConvertArchive(data []byte, t Type, filename string, convert EntryConverter) (string, error) {
+	if filename == "" {
+		filename = "archive"
+	}
+	w := &archiveWalker{convert: convert, root: &archiveDir{}}
+	if err := w.walk(data, t, filename, "", 0); err != nil && !errors.Is(err, errArchiveLimit) {
+		return "", fmt.Errorf("archive: %w", err)
+	}
+	if w.entries == 0 {
+		return "", fmt.Errorf("archive: no files found")
+	}
+
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	md.WriteString("## Contents\n\n```\n")
+	w.root.render(&md, "")
+	md.WriteString("```\n\n")
+	if w.limit != "" {
+		md.WriteString(fmt.Sprintf("_Archive truncated: %s._\n\n", w.limit))
+	}
+	for _, s := range w.sections {
+		md.WriteString(s)
+		md.WriteString("\n\n")
+	}
+	return strings.TrimSpace(md.String()), nil
+}
+
+// archiveWalker reads archive members, enforcing the expansion limits.
+type archiveWalker struct {
+	convert  EntryConverter
+	root     *archiveDir
+	sections []string
+	entries  int
+	size     int64
+	limit    string // the limit that stopped expansion, if any
+}
+
+// errArchiveLimit stops expansion once a limit is reached.
+var errArchiveLimit = errors.New("archive limit reached")
+
+// walk adds the members of an archive of type t, prefixing their paths with
+// prefix (the path of a nested archive).
+func (w *archiveWalker) walk(data []byte, t Type, filename, prefix string, depth int) error {
+	switch t {
+	case TypeZIP:
+		return w.walkZip(data, prefix, depth)
+	case TypeTAR:
+		return w.walkTar(bytes.NewReader(data), prefix, depth)
+	case TypeGZIP:
+		return w.walkGzip(data, filename, prefix, depth)
+	}
+	return fmt.Errorf("unsupported archive type %q", t)
+}
+
+func (w *archiveWalker) walkZip(data []byte, prefix string, depth int) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("open zip: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || skipArchiveMember(f.Name) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			w.add(prefix+f.Name, nil, fmt.Errorf("open: %w", err), depth)
+			continue
+		}
+		data, err := w.read(rc)
+		rc.Close()
+		if errors.Is(err, errArchiveLimit) {
+			return err
+		}
+		if err := w.add(prefix+f.Name, data, err, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *archiveWalker) walkTar(r io.Reader, prefix string, depth int) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || skipArchiveMember(hdr.Name) {
+			continue
+		}
+		data, err := w.read(tr)
+		if errors.Is(err, errArchiveLimit) {
+			return err
+		}
+		if err := w.add(prefix+strings.TrimPrefix(hdr.Name, "./"), data, err, depth); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *archiveWalker) walkGzip(data []byte, filename, prefix string, depth int) error {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("open gzip: %w", err)
+	}
+	defer zr.Close()
+
+	br := bufio.NewReader(zr)
+	if head, _ := br.Peek(262); isTar(head) {
+		return w.walkTar(br, prefix, depth)
+	}
+
+	// a single compressed file, named by the gzip header or the archive name
+	name := path.Base(zr.Name)
+	if zr.Name == "" {
+		name = strings.TrimSuffix(strings.TrimSuffix(filename, ".gz"), ".tgz")
+	}
+	inner, err := w.read(br)
+	if errors.Is(err, errArchiveLimit) {
+		return err
+	}
+	return w.add(prefix+name, inner, err, depth)
+}
+
+// read reads a member, counting it against the entry and size limits.
+func (w *archiveWalker) read(r io.Reader) ([]byte, error) {
+	if w.entries >= maxArchiveEntries {
+		w.limit = fmt.Sprintf("more than %d files", maxArchiveEntries)
+		return nil, errArchiveLimit
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxArchiveSize-w.size+1))
+	w.size += int64(len(data))
+	if w.size > maxArchiveSize {
+		w.limit = fmt.Sprintf("more than %d MB uncompressed", maxArchiveSize>>20)
+		return nil, errArchiveLimit
+	}
+	w.entries++
+	return data, err
+}
+
+// add lists a member and converts it, expanding nested archives.
+func (w *archiveWalker) add(name string, data []byte, readErr error, depth int) error {
+	if readErr != nil {
+		w.root.add(name)
+		w.sections = append(w.sections, fmt.Sprintf("## %s\n\n_Could not read: %v_", name, readErr))
+		return nil
+	}
+
+	t, ok := archiveEntryType(name, data)
+	if ok && t.IsArchive() && depth+1 < maxArchiveDepth {
+		// nested members are listed under the archive's name
+		err := w.walk(data, t, path.Base(name), name+"/", depth+1)
+		if err != nil && !errors.Is(err, errArchiveLimit) {
+			w.root.add(name)
+			w.sections = append(w.sections, fmt.Sprintf("## %s\n\n_Could not expand: %v_", name, err))
+			return nil
+		}
+		return err
+	}
+	w.root.add(name)
+	if !ok {
+		return nil // binary files are listed only
+	}
+	if t.IsArchive() {
+		w.sections = append(w.sections, fmt.Sprintf("## %s\n\n_Nested archive not expanded: more than %d levels deep._", name, maxArchiveDepth))
+		return nil
+	}
+
+	md, err := w.convert(name, t, data)
+	if err != nil {
+		w.sections = append(w.sections, fmt.Sprintf("## %s\n\n_Could not convert: %v_", name, err))
+		return nil
+	}
+	if md = nestEntry(md, path.Base(name)); md != "" {
+		w.sections = append(w.sections, fmt.Sprintf("## %s\n\n%s", name, md))
+	}
+	return nil
+}
+
+// skipArchiveMember reports whether a member is operating system metadata.
+func skipArchiveMember(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || path.Base(name) == ".DS_Store"
+}
+
+// isTar reports whether head starts a POSIX or GNU tar archive.
+func isTar(head []byte) bool {
+	return len(head) >= 262 && string(head[257:262]) == "ustar"
+}
+
+// archiveEntryType picks the converter for an archive member from its name
+// and content. Members without a known extension are converted as plain text
+// when they are UTF-8 text; other binary members are not converted.
+func archiveEntryType(name string, data []byte) (Type, bool) {
+	if t := DetectFromURL(name); t != TypeHTML {
+		return t, true
+	}
+	if isAmbiguousCode(name, data) {
+		return TypeCode, true
+	}
+	switch strings.ToLower(path.Ext(name)) {
+	case ".html", ".htm", ".xhtml":
+		return TypeHTML, true
+	}
+	if t := DetectFromBytes(data); t != TypeHTML {
+		return t, true
+	}
+	if len(data) > 0 && isText(data) {
+		return TypeTXT, true
+	}
+	return "", false
+}
+
+// nestEntry fits a member's markdown under its "## path" section: a leading
+// title that only repeats the file name is dropped and the remaining headings
+// move down two levels. Fenced code blocks are left untouched.
+func nestEntry(md, filename string) string {
+	md = strings.TrimSpace(md)
+	if first, rest, _ := strings.Cut(md, "\n"); first == "# "+filename {
+		md = strings.TrimSpace(rest)
+	}
+
+	lines := strings.Split(md, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		level := len(line) - len(strings.TrimLeft(line, "#"))
+		if level > 0 && level <= 6 && (len(line) == level || line[level] == ' ') {
+			lines[i] = strings.Repeat("#", min(level+2, 6)) + line[level:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// archiveDir is a directory in the tree listing of an archive.
+type archiveDir struct {
+	names []string // children in archive order
+	dirs  map[string]*archiveDir
+}
+
+// add inserts a member path, creating its parent directories.
+func (d *archiveDir) add(name string) {
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	for i, part := range parts {
+		if i == len(parts)-1 {
+			d.names = append(d.names, part)
+			return
+		}
+		if d.dirs == nil {
+			d.dirs = make(map[string]*archiveDir)
+		}
+		sub, ok := d.dirs[part]
+		if !ok {
+			sub = &archiveDir{}
+			d.dirs[part] = sub
+			d.names = append(d.names, part+"/")
+		}
+		d = sub
+	}
+}
+
+// render writes the tree below d with box-drawing branches.
+func (d *archiveDir) render(b *strings.Builder, indent string) {
+	for i, name := range d.names {
+		branch, next := "├── ", "│   "
+		if i == len(d.names)-1 {
+			branch, next = "└── ", "    "
+		}
+		b.WriteString(indent + branch + name + "\n")
+		if sub, ok := d.dirs[strings.TrimSuffix(name, "/")]; ok && strings.HasSuffix(name, "/") {
+			sub.render(b, indent+next)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package filetype
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// CodeOptions configures source code conversion.
+type CodeOptions struct {
+	// DocComments renders the file's leading doc comment as prose above the
+	// code. License headers and compiler directives are skipped.
+	DocComments bool
+}
+
+// codeExtensions maps source file extensions to their fence language.
+var codeExtensions = map[string]string{
+	".go": "go", ".py": "python", ".pyi": "python", ".rb": "ruby", ".php": "php", ".pl": "perl",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript", ".jsx": "jsx",
+	".ts": "typescript", ".mts": "typescript", ".cts": "typescript", ".tsx": "tsx",
+	".rs": "rust", ".java": "java", ".kt": "kotlin", ".kts": "kotlin", ".scala": "scala",
+	".groovy": "groovy", ".gradle": "groovy", ".swift": "swift", ".dart": "dart",
+	".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp", ".hpp": "cpp", ".hh": "cpp",
+	".cs": "csharp", ".m": "objectivec", ".zig": "zig", ".nim": "nim",
+	".lua": "lua", ".r": "r", ".jl": "julia", ".hs": "haskell", ".ml": "ocaml",
+	".ex": "elixir", ".exs": "elixir", ".erl": "erlang", ".clj": "clojure",
+	".sh": "bash", ".bash": "bash", ".zsh": "zsh", ".fish": "fish", ".ps1": "powershell", ".bat": "batch",
+	".sql": "sql", ".graphql": "graphql", ".proto": "protobuf",
+	".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".ini": "ini", ".cfg": "ini",
+	".tf": "hcl", ".hcl": "hcl", ".cmake": "cmake",
+	".css": "css", ".scss": "scss", ".less": "less", ".vue": "vue", ".svelte": "svelte",
+	".diff": "diff", ".patch": "diff",
+}
+
+// ambiguousCodeExtensions are source extensions that other formats share:
+// .ts is also an MPEG transport stream (HLS video segments) and .m a MATLAB
+// or Mathematica file. They only mark source code when the data is text.
+var ambiguousCodeExtensions = map[string]bool{".ts": true, ".m": true}
+
+// codeFilenames maps extensionless (or misleadingly named) source files to
+// their fence language.
+var codeFilenames = map[string]string{
+	"Makefile": "makefile", "GNUmakefile": "makefile", "Dockerfile": "dockerfile",
+	"Containerfile": "dockerfile", "CMakeLists.txt": "cmake", "Jenkinsfile": "groovy",
+	"Gemfile": "ruby", "Rakefile": "ruby", "Vagrantfile": "ruby",
+}
+
+// codeContentTypes maps source code media types to their fence language.
+var codeContentTypes = map[string]string{
+	"text/javascript": "javascript", "application/javascript": "javascript",
+	"application/x-javascript": "javascript", "application/typescript": "typescript",
+	"text/x-go": "go", "text/x-python": "python", "application/x-python": "python",
+	"text/x-rust": "rust", "text/x-java": "java", "text/x-java-source": "java",
+	"text/x-c": "c", "text/x-csrc": "c", "text/x-c++": "cpp", "text/x-c++src": "cpp",
+	"text/x-ruby": "ruby", "text/x-php": "php", "application/x-httpd-php": "php",
+	"application/x-sh": "bash", "text/x-sh": "bash", "text/x-shellscript": "bash",
+	"application/yaml": "yaml", "application/x-yaml": "yaml", "text/yaml": "yaml", "text/x-yaml": "yaml",
+	"application/toml": "toml", "application/sql": "sql", "text/x-sql": "sql", "text/css": "css",
+}
+
+// CodeLanguage returns the fence language of a source file from its name or,
+// failing that, its content type. It returns "" for anything else.
+func CodeLanguage(name, contentType string) string {
+	base := path.Base(strings.Split(name, "?")[0])
+	if lang, ok := codeFilenames[base]; ok {
+		return lang
+	}
+	if lang, ok := codeExtensions[strings.ToLower(path.Ext(base))]; ok {
+		return lang
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return codeContentTypes[mediaType]
+	}
+	return ""
+}
+
+// isText reports whether data is UTF-8 text without NUL bytes.
+func isText(data []byte) bool {
+	return utf8.Valid(data) && bytes.IndexByte(data, 0) < 0
+}
+
+// ConvertCode converts a source file to a fenced code block tagged with its
+// language, preceded by its line count and size.
+func ConvertCode(data []byte, filename, contentType string, opts *CodeOptions) (string, error) {
+	if !isText(data) {
+		return "", fmt.Errorf("code: %s is not a text file", filename)
+	}
+	if opts == nil {
+		opts = &CodeOptions{}
+	}
+	lang := CodeLanguage(filename, contentType)
+	if filename == "" {
+		filename = "source"
+	}
+
+	code := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	lines := 0
+	if code != "" {
+		lines = strings.Count(code, "\n") + 1
+	}
+
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	if lang != "" {
+		md.WriteString(fmt.Sprintf("- **Language**: %s\n", lang))
+	}
+	md.WriteString(fmt.Sprintf("- **Lines**: %d\n", lines))
+	md.WriteString(fmt.Sprintf("- **Size**: %d bytes\n\n", len(data)))
+	if opts.DocComments {
+		if doc := leadingDocComment(code, lang); doc != "" {
+			md.WriteString(doc)
+			md.WriteString("\n\n")
+		}
+	}
+	md.WriteString(fencedBlock(code, lang))
+	return md.String(), nil
+}
+
+// lineCommentPrefixes lists the line comment markers of each language; the
+// C family also has /* */ block comments.
+var lineCommentPrefixes = map[string][]string{
+	"hash":  {"#"},
+	"slash": {"///", "//!", "//"},
+	"dash":  {"--"},
+	"semi":  {";;", ";"},
+}
+
+func commentStyle(lang string) string {
+	switch lang {
+	case "python", "ruby", "perl", "r", "julia", "elixir", "nim", "bash", "zsh", "fish", "powershell",
+		"yaml", "toml", "makefile", "dockerfile", "cmake", "hcl", "graphql":
+		return "hash"
+	case "sql", "lua", "haskell":
+		return "dash"
+	case "clojure", "ini":
+		return "semi"
+	case "go", "javascript", "jsx", "typescript", "tsx", "rust", "java", "kotlin", "scala", "groovy",
+		"swift", "dart", "c", "cpp", "csharp", "objectivec", "zig", "php", "protobuf", "css", "scss", "less":
+		return "slash"
+	}
+	return ""
+}
+
+// leadingDocComment returns the comments (and Python module docstring) that
+// open a file, before the first line of code, as plain prose. Shebangs,
+// directives such as //go:build, and license headers are left out.
+func leadingDocComment(code, lang string) string {
+	style := commentStyle(lang)
+	if style == "" {
+		return ""
+	}
+	lines := strings.Split(code, "\n")
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "#!") {
+		lines = lines[1:]
+	}
+
+	var blocks []string
+	var cur []string
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(cur, "\n")); text != "" && !isLicenseHeader(text) {
+			blocks = append(blocks, text)
+		}
+		cur = nil
+	}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+			flush()
+		case style == "slash" && strings.HasPrefix(line, "/*"):
+			// block comment, up to the closing */
+			for ; i < len(lines); i++ {
+				l := strings.TrimSpace(lines[i])
+				done := strings.Contains(l, "*/")
+				l, _, _ = strings.Cut(l, "*/")
+				l = strings.TrimLeft(strings.TrimPrefix(l, "/"), "*!")
+				cur = append(cur, strings.TrimSpace(l))
+				if done {
+					break
+				}
+			}
+		case lang == "python" && isDocstring(line):
+			// module docstring, up to the closing quotes
+			flush()
+			line = strings.TrimLeft(line, "rRuU")
+			quote, rest := line[:3], line[3:]
+			for {
+				before, _, found := strings.Cut(rest, quote)
+				cur = append(cur, strings.TrimSpace(before))
+				if i++; found || i >= len(lines) {
+					break
+				}
+				rest = lines[i]
+			}
+			flush()
+			return strings.Join(blocks, "\n\n")
+		case isDirective(line):
+		default:
+			prefix := ""
+			for _, p := range lineCommentPrefixes[style] {
+				if strings.HasPrefix(line, p) {
+					prefix = p
+					break
+				}
+			}
+			if prefix == "" {
+				flush()
+				return strings.Join(blocks, "\n\n") // first line of code
+			}
+			cur = append(cur, strings.TrimSpace(strings.TrimPrefix(line, prefix)))
+		}
+	}
+	flush()
+	return strings.Join(blocks, "\n\n")
+}
+
+// isDocstring reports whether a Python line opens a triple-quoted string.
+func isDocstring(line string) bool {
+	line = strings.TrimLeft(line, "rRuU")
+	return strings.HasPrefix(line, `"""`) || strings.HasPrefix(line, "'''")
+}
+
+// isDirective reports whether a comment line is a build or tool directive
+// rather than documentation.
+func isDirective(line string) bool {
+	for _, p := range []string{"//go:", "// +build", "//nolint", "# -*-", "# type:", "# noqa", "# frozen_string_literal"} {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLicenseHeader reports whether a comment block is a copyright notice.
+func isLicenseHeader(text string) bool {
+	for _, marker := range []string{"Copyright", "SPDX-License-Identifier", "Licensed under", "license that can be found"} {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,30 +3,44 @@ package filetype
 import (
 	"bytes"
 	"net/http"
+	"net/url"
 	"path"
 	"strings"
+
+	"github.com/richardlehane/mscfb"
 )
 
 // Type represents a supported file type.
 type Type string
 
 const (
-	TypeHTML Type = "html"
-	TypePDF  Type = "pdf"
-	TypeDOCX Type = "docx"
-	TypeXLSX Type = "xlsx"
-	TypeXLS  Type = "xls"
-	TypeODT  Type = "odt"
-	TypeCSV  Type = "csv"
-	TypeJSON Type = "json"
-	TypeXML  Type = "xml"
-	TypeTXT  Type = "txt"
-	TypeMD   Type = "md"
-	TypePNG  Type = "png"
-	TypeJPEG Type = "jpeg"
-	TypeGIF  Type = "gif"
-	TypeWEBP Type = "webp"
-	TypeSVG  Type = "svg"
+	TypeHTML  Type = "html"
+	TypePDF   Type = "pdf"
+	TypeDOCX  Type = "docx"
+	TypeDOC   Type = "doc"
+	TypeRTF   Type = "rtf"
+	TypeXLSX  Type = "xlsx"
+	TypeXLS   Type = "xls"
+	TypeODS   Type = "ods"
+	TypeODT   Type = "odt"
+	TypePPTX  Type = "pptx"
+	TypeODP   Type = "odp"
+	TypeEPUB  Type = "epub"
+	TypeIPYNB Type = "ipynb"
+	TypeCode  Type = "code" // source files; see CodeLanguage
+	TypeCSV   Type = "csv"
+	TypeJSON  Type = "json"
+	TypeXML   Type = "xml"
+	TypeTXT   Type = "txt"
+	TypeMD    Type = "md"
+	TypePNG   Type = "png"
+	TypeJPEG  Type = "jpeg"
+	TypeGIF   Type = "gif"
+	TypeWEBP  Type = "webp"
+	TypeSVG   Type = "svg"
+	TypeZIP   Type = "zip"
+	TypeTAR   Type = "tar"
+	TypeGZIP  Type = "gzip" // tar.gz archives and single gzip-compressed files
 )
 
 // IsImage returns true if the type is an image format.
@@ -38,20 +52,58 @@ func (t Type) IsImage() bool {
 	return false
 }
 
-// DetectFromURL guesses the file type from the URL path extension.
+// IsArchive returns true if the type is a file archive.
+func (t Type) IsArchive() bool {
+	switch t {
+	case TypeZIP, TypeTAR, TypeGZIP:
+		return true
+	}
+	return false
+}
+
+// urlPath returns the path of rawURL, which may also be a plain file name or
+// path.
+func urlPath(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Path // not the host: https://tokio.rs is a page, not Rust
+	}
+	return strings.Split(rawURL, "?")[0]
+}
+
+// DetectFromURL guesses the file type from the URL path extension. rawURL
+// may also be a plain file name or path. Extensions that do not always mean
+// source code, such as .ts, are left to isAmbiguousCode.
 func DetectFromURL(rawURL string) Type {
-	ext := strings.ToLower(path.Ext(strings.Split(rawURL, "?")[0]))
+	p := urlPath(rawURL)
+	ext := strings.ToLower(path.Ext(p))
+	if CodeLanguage(p, "") != "" && !ambiguousCodeExtensions[ext] {
+		return TypeCode
+	}
 	switch ext {
 	case ".pdf":
 		return TypePDF
 	case ".docx":
 		return TypeDOCX
+	case ".doc":
+		return TypeDOC
+	case ".rtf":
+		return TypeRTF
 	case ".xlsx":
 		return TypeXLSX
 	case ".xls":
 		return TypeXLS
+	case ".ods":
+		return TypeODS
 	case ".odt":
 		return TypeODT
+	case ".pptx":
+		return TypePPTX
+	case ".odp":
+		return TypeODP
+	case ".epub":
+		return TypeEPUB
+	case ".ipynb":
+		return TypeIPYNB
 	case ".csv":
 		return TypeCSV
 	case ".json":
@@ -72,6 +124,12 @@ func DetectFromURL(rawURL string) Type {
 		return TypeWEBP
 	case ".svg":
 		return TypeSVG
+	case ".zip":
+		return TypeZIP
+	case ".tar":
+		return TypeTAR
+	case ".gz", ".tgz":
+		return TypeGZIP
 	}
 	return TypeHTML
 }
@@ -84,18 +142,34 @@ func DetectFromContentType(ct string) Type {
 		return TypePDF
 	case strings.Contains(ct, "application/vnd.openxmlformats-officedocument.wordprocessingml"):
 		return TypeDOCX
+	case strings.Contains(ct, "application/msword"):
+		return TypeDOC
+	case strings.Contains(ct, "application/rtf"), strings.Contains(ct, "text/rtf"):
+		return TypeRTF
 	case strings.Contains(ct, "application/vnd.openxmlformats-officedocument.spreadsheetml"):
 		return TypeXLSX
 	case strings.Contains(ct, "application/vnd.ms-excel"):
 		return TypeXLS
+	case strings.Contains(ct, "application/vnd.oasis.opendocument.spreadsheet"):
+		return TypeODS
 	case strings.Contains(ct, "application/vnd.oasis.opendocument.text"):
 		return TypeODT
+	case strings.Contains(ct, "application/vnd.openxmlformats-officedocument.presentationml"):
+		return TypePPTX
+	case strings.Contains(ct, "application/vnd.oasis.opendocument.presentation"):
+		return TypeODP
+	case strings.Contains(ct, "application/epub+zip"):
+		return TypeEPUB
+	case strings.Contains(ct, "application/x-ipynb+json"):
+		return TypeIPYNB
 	case strings.Contains(ct, "text/csv"):
 		return TypeCSV
 	case strings.Contains(ct, "application/json"):
 		return TypeJSON
 	case strings.Contains(ct, "application/xml"), strings.Contains(ct, "text/xml"):
 		return TypeXML
+	case CodeLanguage("", ct) != "":
+		return TypeCode
 	case strings.Contains(ct, "text/plain"):
 		return TypeTXT
 	case strings.Contains(ct, "text/markdown"):
@@ -110,6 +184,13 @@ func DetectFromContentType(ct string) Type {
 		return TypeWEBP
 	case strings.Contains(ct, "image/svg+xml"):
 		return TypeSVG
+	case strings.Contains(ct, "application/zip"), strings.Contains(ct, "application/x-zip"):
+		return TypeZIP
+	case strings.Contains(ct, "application/x-tar"):
+		return TypeTAR
+	case strings.Contains(ct, "application/gzip"), strings.Contains(ct, "application/x-gzip"),
+		strings.Contains(ct, "application/x-gtar"), strings.Contains(ct, "application/x-compressed-tar"):
+		return TypeGZIP
 	case strings.Contains(ct, "application/octet-stream"):
 		// generic binary — defer to magic bytes detection
 		return TypeHTML
@@ -122,16 +203,19 @@ func DetectFromContentType(ct string) Type {
 // Magic byte signatures for binary file detection.
 var (
 	magicPDF  = []byte("%PDF")
-	magicZIP  = []byte("PK\x03\x04") // DOCX, XLSX, ODT are ZIP-based
-	magicXLS  = []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1") // OLE2 Compound Binary (legacy .xls)
+	magicZIP  = []byte("PK\x03\x04") // DOCX, XLSX, ODS, PPTX, ODT, ODP, EPUB are ZIP-based
+	magicOLE  = []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1") // OLE2 Compound Binary (legacy .doc, .xls)
+	magicRTF  = []byte("{\\rtf")
 	magicPNG  = []byte("\x89PNG\r\n\x1a\n")
 	magicJPEG = []byte("\xff\xd8\xff")
 	magicGIF  = []byte("GIF8")
 	magicWEBP = []byte("RIFF") // WEBP starts with RIFF....WEBP
+	magicGZIP = []byte("\x1f\x8b")
 )
 
 // DetectFromBytes uses magic byte signatures to identify file types.
-// For ZIP-based formats (DOCX/XLSX), it peeks inside the archive markers.
+// For ZIP-based formats (DOCX/XLSX), it peeks inside the archive markers;
+// other ZIP files are plain archives.
 func DetectFromBytes(data []byte) Type {
 	if len(data) < 8 {
 		return TypeHTML
@@ -141,8 +225,10 @@ func DetectFromBytes(data []byte) Type {
 		return TypePDF
 	case bytes.HasPrefix(data, magicZIP):
 		return detectZIPType(data)
-	case bytes.HasPrefix(data, magicXLS):
-		return TypeXLS
+	case bytes.HasPrefix(data, magicOLE):
+		return detectOLEType(data)
+	case bytes.HasPrefix(data, magicRTF):
+		return TypeRTF
 	case bytes.HasPrefix(data, magicPNG):
 		return TypePNG
 	case bytes.HasPrefix(data, magicJPEG):
@@ -151,11 +237,32 @@ func DetectFromBytes(data []byte) Type {
 		return TypeGIF
 	case bytes.HasPrefix(data, magicWEBP) && len(data) >= 12 && string(data[8:12]) == "WEBP":
 		return TypeWEBP
+	case bytes.HasPrefix(data, magicGZIP):
+		return TypeGZIP
+	case isTar(data):
+		return TypeTAR
+	case IsNotebook(data):
+		return TypeIPYNB
 	}
 	return TypeHTML
 }
 
-// detectZIPType distinguishes DOCX, XLSX, and ODT by looking for known paths in the ZIP.
+// detectOLEType distinguishes Word and Excel OLE2 files by their streams;
+// unreadable files are assumed to be .xls.
+func detectOLEType(data []byte) Type {
+	doc, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return TypeXLS
+	}
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		if len(entry.Path) == 0 && entry.Name == "WordDocument" {
+			return TypeDOC
+		}
+	}
+	return TypeXLS
+}
+
+// detectZIPType distinguishes DOCX, XLSX, ODS, PPTX, ODT, ODP and EPUB by looking for known paths in the ZIP.
 func detectZIPType(data []byte) Type {
 	// Look for markers in the first 4KB
 	peek := data
@@ -168,19 +275,45 @@ func detectZIPType(data []byte) Type {
 	if bytes.Contains(peek, []byte("xl/")) || bytes.Contains(peek, []byte("xl\\")) {
 		return TypeXLSX
 	}
+	if bytes.Contains(peek, []byte("ppt/")) || bytes.Contains(peek, []byte("ppt\\")) {
+		return TypePPTX
+	}
+	if bytes.Contains(peek, []byte("mimetype")) && bytes.Contains(peek, []byte("opendocument.spreadsheet")) {
+		return TypeODS
+	}
 	if bytes.Contains(peek, []byte("mimetype")) && bytes.Contains(peek, []byte("opendocument.text")) {
 		return TypeODT
 	}
-	// Unknown ZIP type
-	return TypeHTML
+	if bytes.Contains(peek, []byte("mimetype")) && bytes.Contains(peek, []byte("opendocument.presentation")) {
+		return TypeODP
+	}
+	if bytes.Contains(peek, []byte("mimetype")) && bytes.Contains(peek, []byte("application/epub+zip")) {
+		return TypeEPUB
+	}
+	// a plain archive of other files
+	return TypeZIP
+}
+
+// isAmbiguousCode reports whether rawURL has a source extension that other
+// formats share and data is text, so the file is source code after all.
+func isAmbiguousCode(rawURL string, data []byte) bool {
+	ext := strings.ToLower(path.Ext(urlPath(rawURL)))
+	return ambiguousCodeExtensions[ext] && len(data) > 0 && isText(data)
 }
 
 // Detect determines file type using URL extension, final redirect URL, Content-Type,
 // and magic bytes (in that order of priority).
 func Detect(rawURL string, resp *http.Response, data []byte) Type {
+	// a source file URL that serves an HTML page (a repository's file
+	// viewer) is converted as that page
+	htmlPage := resp != nil && strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "text/html")
+
 	// 1. Original URL extension
 	t := DetectFromURL(rawURL)
-	if t != TypeHTML {
+	if t == TypeHTML && isAmbiguousCode(rawURL, data) {
+		t = TypeCode
+	}
+	if t != TypeHTML && !(t == TypeCode && htmlPage) {
 		return t
 	}
 
@@ -189,7 +322,10 @@ func Detect(rawURL string, resp *http.Response, data []byte) Type {
 		finalURL := resp.Request.URL.String()
 		if finalURL != rawURL {
 			t = DetectFromURL(finalURL)
-			if t != TypeHTML {
+			if t == TypeHTML && isAmbiguousCode(finalURL, data) {
+				t = TypeCode
+			}
+			if t != TypeHTML && !(t == TypeCode && htmlPage) {
 				return t
 			}
 		}
@@ -200,6 +336,9 @@ func Detect(rawURL string, resp *http.Response, data []byte) Type {
 		ct := resp.Header.Get("Content-Type")
 		if ct != "" {
 			t = DetectFromContentType(ct)
+			if t == TypeJSON && IsNotebook(data) {
+				return TypeIPYNB
+			}
 			if t != TypeHTML {
 				return t
 			}
@@ -216,3 +355,28 @@ func Detect(rawURL string, resp *http.Response, data []byte) Type {
 
 	return TypeHTML
 }
+
+// DetectFile determines the type of local file data from the file name
+// extension, a caller-supplied content type, and magic bytes (in that order of
+// priority). Either name or contentType may be empty.
+func DetectFile(name, contentType string, data []byte) Type {
+	if t := DetectFromURL(name); t != TypeHTML {
+		return t
+	}
+	if isAmbiguousCode(name, data) {
+		return TypeCode
+	}
+	if contentType != "" {
+		t := DetectFromContentType(contentType)
+		if t == TypeJSON && IsNotebook(data) {
+			return TypeIPYNB
+		}
+		if t != TypeHTML {
+			return t
+		}
+	}
+	if len(data) > 0 {
+		return DetectFromBytes(data)
+	}
+	return TypeHTML
+}
@@ -0,0 +1,508 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ConvertDOC converts a legacy Word 97-2003 (.doc) document to markdown.
+// Paragraphs, headings and tables are kept; formatting is not.
+func ConvertDOC(data []byte, filename string) (string, error) {
+	streams, err := oleStreams(data)
+	if err != nil {
+		return "", fmt.Errorf("doc open: %w", err)
+	}
+	word, ok := streams["WordDocument"]
+	if !ok {
+		return "", fmt.Errorf("doc: WordDocument stream not found")
+	}
+	fib, err := readDOCFib(word)
+	if err != nil {
+		return "", fmt.Errorf("doc: %w", err)
+	}
+	table, ok := streams[fib.tableStream]
+	if !ok {
+		return "", fmt.Errorf("doc: %s stream not found", fib.tableStream)
+	}
+
+	pieces, err := docPieces(fib.slice(table, docClx))
+	if err != nil {
+		return "", fmt.Errorf("doc: %w", err)
+	}
+	d := &docDoc{
+		word:   word,
+		papx:   docParagraphProps(word, fib.slice(table, docPlcfBtePapx)),
+		styles: docHeadingStyles(fib.slice(table, docStshf)),
+	}
+	d.read(pieces, fib.ccpText)
+
+	if filename == "" {
+		filename = "document.doc"
+	}
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	for _, b := range d.render() {
+		md.WriteString(b)
+		md.WriteString("\n\n")
+	}
+	return strings.TrimSpace(md.String()), nil
+}
+
+// oleStreams reads the top-level streams of an OLE2 compound file.
+func oleStreams(data []byte) (map[string][]byte, error) {
+	doc, err := mscfb.New(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	streams := make(map[string][]byte)
+	for entry, err := doc.Next(); err == nil; entry, err = doc.Next() {
+		if len(entry.Path) > 0 || entry.Size <= 0 || entry.Size > int64(len(data)) {
+			continue
+		}
+		buf := make([]byte, entry.Size)
+		if _, err := io.ReadFull(entry, buf); err != nil {
+			return nil, fmt.Errorf("read %s: %w", entry.Name, err)
+		}
+		streams[entry.Name] = buf
+	}
+	return streams, nil
+}
+
+// Indexes into FibRgFcLcb97 of the table stream structures we read.
+const (
+	docStshf       = 1  // style sheet
+	docPlcfBtePapx = 13 // paragraph property pages
+	docClx         = 33 // piece table
+)
+
+// docFib is the part of the File Information Block we need.
+type docFib struct {
+	tableStream string // "0Table" or "1Table"
+	ccpText     int    // characters in the main document
+	fcLcb       []byte // FibRgFcLcb
+}
+
+func readDOCFib(word []byte) (*docFib, error) {
+	le := binary.LittleEndian
+	if len(word) < 34 || le.Uint16(word) != 0xA5EC {
+		return nil, fmt.Errorf("not a Word document")
+	}
+	if nFib := le.Uint16(word[2:]); nFib < 0xC1 {
+		return nil, fmt.Errorf("Word 6/95 documents are not supported")
+	}
+	flags := le.Uint16(word[0x0A:])
+	if flags&0x0100 != 0 {
+		return nil, fmt.Errorf("document is encrypted")
+	}
+	fib := &docFib{tableStream: "0Table"}
+	if flags&0x0200 != 0 {
+		fib.tableStream = "1Table"
+	}
+
+	// FibBase, then the counted FibRgW, FibRgLw and FibRgFcLcb arrays
+	pos := 32 + 2 + int(le.Uint16(word[32:]))*2
+	if pos+2 > len(word) {
+		return nil, fmt.Errorf("truncated file information block")
+	}
+	cslw := int(le.Uint16(word[pos:]))
+	rgLw := pos + 2
+	pos = rgLw + cslw*4
+	if cslw < 4 || pos+2 > len(word) {
+		return nil, fmt.Errorf("truncated file information block")
+	}
+	fib.ccpText = int(int32(le.Uint32(word[rgLw+12:])))
+	n := int(le.Uint16(word[pos:]))
+	end := min(pos+2+n*8, len(word))
+	fib.fcLcb = word[pos+2 : end]
+	return fib, nil
+}
+
+// slice returns the structure at FibRgFcLcb index i in the table stream, or
+// nil when it is absent or out of bounds.
+func (f *docFib) slice(table []byte, i int) []byte {
+	if (i+1)*8 > len(f.fcLcb) {
+		return nil
+	}
+	fc := int64(binary.LittleEndian.Uint32(f.fcLcb[i*8:]))
+	lcb := int64(binary.LittleEndian.Uint32(f.fcLcb[i*8+4:]))
+	if lcb == 0 || fc+lcb > int64(len(table)) {
+		return nil
+	}
+	return table[fc : fc+lcb]
+}
+
+// docPiece maps a run of character positions to the WordDocument stream.
+type docPiece struct {
+	cpStart, cpEnd int
+	fc             int  // stream offset of the first character
+	compressed     bool // 8-bit Windows-1252 text rather than UTF-16
+}
+
+// docPieces parses the piece table in the Clx.
+func docPieces(clx []byte) ([]docPiece, error) {
+	le := binary.LittleEndian
+	for i := 0; i < len(clx); {
+		switch clx[i] {
+		case 1: // Prc: property modifiers, skipped
+			if i+3 > len(clx) {
+				return nil, fmt.Errorf("truncated piece table")
+			}
+			i += 3 + int(le.Uint16(clx[i+1:]))
+		case 2: // Pcdt: the piece table
+			if i+5 > len(clx) {
+				return nil, fmt.Errorf("truncated piece table")
+			}
+			lcb := int(le.Uint32(clx[i+1:]))
+			plc := clx[i+5:]
+			if lcb < 4 || lcb > len(plc) {
+				return nil, fmt.Errorf("truncated piece table")
+			}
+			plc = plc[:lcb]
+			n := (lcb - 4) / 12
+			pieces := make([]docPiece, 0, n)
+			for j := range n {
+				fc := le.Uint32(plc[(n+1)*4+j*8+2:])
+				p := docPiece{
+					cpStart: int(le.Uint32(plc[j*4:])),
+					cpEnd:   int(le.Uint32(plc[(j+1)*4:])),
+					fc:      int(fc &^ 0x40000000),
+				}
+				if fc&0x40000000 != 0 {
+					p.compressed = true
+					p.fc /= 2
+				}
+				pieces = append(pieces, p)
+			}
+			return pieces, nil
+		default:
+			return nil, fmt.Errorf("malformed piece table")
+		}
+	}
+	return nil, fmt.Errorf("piece table not found")
+}
+
+// docPapx is the paragraph formatting of a range of stream offsets.
+type docPapx struct {
+	fcStart, fcEnd int
+	istd           int  // paragraph style
+	outline        int  // outline level 1-9, 0 for body text
+	inTable        bool // paragraph is inside a table cell
+	rowEnd         bool // paragraph is a table row end mark
+}
+
+// docParagraphProps reads the paragraph formatting runs from the FKP pages
+// listed in the PlcBtePapx, ordered by stream offset.
+func docParagraphProps(word, plc []byte) []docPapx {
+	le := binary.LittleEndian
+	if len(plc) < 4 {
+		return nil
+	}
+	n := (len(plc) - 4) / 8
+	var runs []docPapx
+	for i := range n {
+		pn := int(le.Uint32(plc[(n+1)*4+i*4:]) & 0x3FFFFF)
+		if (pn+1)*512 > len(word) {
+			continue
+		}
+		page := word[pn*512 : (pn+1)*512]
+		crun := int(page[511])
+		if (crun+1)*4+crun*13 > 511 {
+			continue
+		}
+		for j := range crun {
+			run := docPapx{
+				fcStart: int(le.Uint32(page[j*4:])),
+				fcEnd:   int(le.Uint32(page[(j+1)*4:])),
+			}
+			if off := int(page[(crun+1)*4+j*13]) * 2; off > 0 && off < 511 {
+				run.parse(page[off:511])
+			}
+			runs = append(runs, run)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].fcStart < runs[j].fcStart })
+	return runs
+}
+
+// parse reads a PapxInFkp: the style index and the paragraph sprms.
+func (p *docPapx) parse(b []byte) {
+	var grpprl []byte
+	if cb := int(b[0]); cb != 0 && 2*cb <= len(b) {
+		grpprl = b[1 : 2*cb]
+	} else if cb == 0 && len(b) > 1 && 2+2*int(b[1]) <= len(b) {
+		grpprl = b[2 : 2+2*int(b[1])]
+	}
+	if len(grpprl) < 2 {
+		return
+	}
+	p.istd = int(binary.LittleEndian.Uint16(grpprl))
+	docSprms(grpprl[2:], func(sprm uint16, operand []byte) {
+		switch sprm {
+		case 0x2416: // sprmPFInTable
+			p.inTable = operand[0] != 0
+		case 0x2417: // sprmPFTtp
+			p.rowEnd = operand[0] != 0
+		case 0x6649: // sprmPItap
+			p.inTable = p.inTable || binary.LittleEndian.Uint32(operand) > 0
+		case 0x2640: // sprmPOutLvl
+			if operand[0] < 9 {
+				p.outline = int(operand[0]) + 1
+			}
+		}
+	})
+}
+
+// docSprms calls fn for each property modifier in grpprl.
+func docSprms(grpprl []byte, fn func(sprm uint16, operand []byte)) {
+	le := binary.LittleEndian
+	for i := 0; i+2 <= len(grpprl); {
+		sprm := le.Uint16(grpprl[i:])
+		i += 2
+		var size int
+		switch sprm >> 13 {
+		case 0, 1:
+			size = 1
+		case 2, 4, 5:
+			size = 2
+		case 3:
+			size = 4
+		case 7:
+			size = 3
+		case 6:
+			if sprm == 0xD608 || sprm == 0xD606 { // sprmTDefTable has a 2-byte size
+				if i+2 > len(grpprl) {
+					return
+				}
+				size = int(le.Uint16(grpprl[i:])) + 1
+			} else if i < len(grpprl) {
+				size = int(grpprl[i]) + 1
+			}
+		}
+		if size == 0 || i+size > len(grpprl) {
+			return
+		}
+		fn(sprm, grpprl[i:i+size])
+		i += size
+	}
+}
+
+// docHeadingStyles maps style indexes to heading levels: the built-in
+// "heading 1" to "heading 9" styles, and "Title" and "Subtitle".
+func docHeadingStyles(stsh []byte) map[int]int {
+	le := binary.LittleEndian
+	levels := make(map[int]int)
+	if len(stsh) < 2 {
+		return levels
+	}
+	cbStshi := int(le.Uint16(stsh))
+	if cbStshi < 4 || 2+cbStshi > len(stsh) {
+		return levels
+	}
+	cstd := int(le.Uint16(stsh[2:]))
+	cbBase := int(le.Uint16(stsh[4:]))
+	pos := 2 + cbStshi
+	for istd := 0; istd < cstd && pos+2 <= len(stsh); istd++ {
+		cb := int(le.Uint16(stsh[pos:]))
+		pos += 2
+		if cb == 0 || pos+cb > len(stsh) {
+			pos += cb
+			continue
+		}
+		std := stsh[pos : pos+cb]
+		pos += cb
+		if len(std) < 2 {
+			continue
+		}
+		sti := int(le.Uint16(std) & 0x0FFF)
+		name := ""
+		if cbBase+2 <= len(std) {
+			cch := int(le.Uint16(std[cbBase:]))
+			if cbBase+2+cch*2 <= len(std) {
+				u := make([]uint16, cch)
+				for i := range u {
+					u[i] = le.Uint16(std[cbBase+2+i*2:])
+				}
+				name = strings.ToLower(string(utf16.Decode(u)))
+			}
+		}
+		switch {
+		case sti >= 1 && sti <= 9:
+			levels[istd] = sti
+		case name == "title":
+			levels[istd] = 1
+		case name == "subtitle":
+			levels[istd] = 2
+		default:
+			var n int
+			if _, err := fmt.Sscanf(name, "heading %d", &n); err == nil && n >= 1 && n <= 9 {
+				levels[istd] = n
+			}
+		}
+	}
+	return levels
+}
+
+// docDoc holds a document while its text is split into paragraphs.
+type docDoc struct {
+	word   []byte
+	papx   []docPapx
+	styles map[int]int // style index -> heading level
+	paras  []docPara
+}
+
+// docPara is a paragraph of the main document text.
+type docPara struct {
+	text    string
+	cellEnd bool // ended by a cell mark rather than a paragraph mark
+	props   docPapx
+}
+
+// read splits the first ccpText characters into paragraphs. Field codes are
+// dropped in favor of their results, and special characters such as
+// picture anchors are removed.
+func (d *docDoc) read(pieces []docPiece, ccpText int) {
+	var (
+		text   strings.Builder
+		fields []bool // open fields; true while in the field code
+	)
+	inCode := func() bool {
+		for _, code := range fields {
+			if code {
+				return true
+			}
+		}
+		return false
+	}
+	for _, p := range pieces {
+		for cp := p.cpStart; cp < min(p.cpEnd, ccpText); cp++ {
+			var (
+				fc int
+				r  rune
+			)
+			if p.compressed {
+				fc = p.fc + cp - p.cpStart
+				if fc >= len(d.word) {
+					break
+				}
+				r = charmap.Windows1252.DecodeByte(d.word[fc])
+			} else {
+				fc = p.fc + (cp-p.cpStart)*2
+				if fc+2 > len(d.word) {
+					break
+				}
+				r = rune(binary.LittleEndian.Uint16(d.word[fc:]))
+			}
+
+			switch r {
+			case 0x13: // field begin
+				fields = append(fields, true)
+				continue
+			case 0x14: // field separator: the result follows
+				if len(fields) > 0 {
+					fields[len(fields)-1] = false
+				}
+				continue
+			case 0x15: // field end
+				if len(fields) > 0 {
+					fields = fields[:len(fields)-1]
+				}
+				continue
+			}
+			if inCode() && r != 0x0D && r != 0x07 {
+				continue
+			}
+
+			switch {
+			case r == 0x0D, r == 0x07, r == 0x0C:
+				d.paras = append(d.paras, docPara{text: text.String(), cellEnd: r == 0x07, props: d.propsAt(fc)})
+				text.Reset()
+			case r == 0x09, r == 0x0B, r == 0xA0:
+				text.WriteByte(' ')
+			case r == 0x1E:
+				text.WriteByte('-')
+			case r < 0x20, r == 0x1F, r >= 0xD800 && r < 0xE000:
+				// anchors, optional hyphens and unpaired surrogates
+			default:
+				text.WriteRune(r)
+			}
+		}
+	}
+	if text.Len() > 0 {
+		d.paras = append(d.paras, docPara{text: text.String()})
+	}
+}
+
+// propsAt returns the paragraph formatting of the paragraph mark at fc.
+func (d *docDoc) propsAt(fc int) docPapx {
+	i := sort.Search(len(d.papx), func(i int) bool { return d.papx[i].fcEnd > fc })
+	if i < len(d.papx) && d.papx[i].fcStart <= fc {
+		return d.papx[i]
+	}
+	return docPapx{}
+}
+
+// render converts the paragraphs to markdown blocks, gathering table cells
+// into pipe tables.
+func (d *docDoc) render() []string {
+	var (
+		blocks []string
+		rows   [][]string
+		row    []string
+		cell   []string
+	)
+	flushTable := func() {
+		if len(row) > 0 {
+			rows = append(rows, row)
+			row = nil
+		}
+		if t := markdownTable(rows); t != "" {
+			blocks = append(blocks, t)
+		}
+		rows = nil
+	}
+	for _, p := range d.paras {
+		text := collapseSpace(p.text)
+		switch {
+		case p.props.rowEnd:
+			rows = append(rows, row)
+			row = nil
+		case p.cellEnd:
+			if text != "" {
+				cell = append(cell, text)
+			}
+			row = append(row, strings.Join(cell, " "))
+			cell = nil
+		case p.props.inTable:
+			if text != "" {
+				cell = append(cell, text)
+			}
+		default:
+			if len(rows) > 0 || len(row) > 0 {
+				flushTable()
+			}
+			if text == "" {
+				continue
+			}
+			level := d.styles[p.props.istd]
+			if level == 0 {
+				level = p.props.outline
+			}
+			if level > 0 {
+				text = strings.Repeat("#", min(level, 6)) + " " + text
+			}
+			blocks = append(blocks, text)
+		}
+	}
+	if len(rows) > 0 || len(row) > 0 {
+		flushTable()
+	}
+	return blocks
+}
@@ -1,142 +1,732 @@
 package filetype
 
 import (
-	"bytes"
+	"encoding/base64"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
-
-	"github.com/fumiama/go-docx"
 )
 
-// ConvertDOCX extracts text from DOCX bytes and returns markdown.
-func ConvertDOCX(data []byte, filename string) (string, error) {
-	r, err := docx.Parse(bytes.NewReader(data), int64(len(data)))
+// DOCXOptions configures DOCX conversion.
+type DOCXOptions struct {
+	// RetainImages embeds images as data URIs. Images too large to inline,
+	// or in formats that are not images, are replaced by their alt text.
+	RetainImages bool
+}
+
+// maxDataURIImage is the largest image embedded as a data URI.
+const maxDataURIImage = 256 << 10
+
+// ConvertDOCX converts a Word (.docx) document to markdown. Headings, lists,
+// tables, hyperlinks, footnotes, endnotes and comments are kept; images are
+// included when opts.RetainImages is set.
+func ConvertDOCX(data []byte, filename string, opts *DOCXOptions) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", fmt.Errorf("docx open zip: %w", err)
+	}
+	root, err := zr.xml("word/document.xml")
 	if err != nil {
-		return "", fmt.Errorf("docx parse: %w", err)
+		return "", fmt.Errorf("docx: %w", err)
+	}
+	body := root.child("body")
+	if body == nil {
+		return "", fmt.Errorf("docx: document body not found")
+	}
+	if opts == nil {
+		opts = &DOCXOptions{}
 	}
 
-	var md strings.Builder
+	d := &docxDoc{
+		zip:      zr,
+		opts:     opts,
+		rels:     zr.rels("word/document.xml"),
+		styles:   readDOCXStyles(zr),
+		num:      readDOCXNumbering(zr),
+		counters: make(map[string][]int),
+		labels:   make(map[string]string),
+	}
+	d.notes = d.readNotes("word/footnotes.xml", "footnote")
+	for id, n := range d.readNotes("word/endnotes.xml", "endnote") {
+		d.notes["endnote:"+id] = n
+	}
+	d.comments = d.readComments()
+
+	d.blocks(body.children)
+	d.flushList()
 
-	if filename == "" {
-		filename = "document.docx"
+	title := d.title
+	if title == "" {
+		title = filename
 	}
-	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	if title == "" {
+		title = "document.docx"
+	}
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", title))
+	for _, b := range d.out {
+		md.WriteString(b)
+		md.WriteString("\n\n")
+	}
+	if len(d.footnotes) > 0 {
+		md.WriteString(strings.Join(d.footnotes, "\n"))
+	}
+	return strings.TrimSpace(md.String()), nil
+}
 
-	for _, item := range r.Document.Body.Items {
-		switch v := item.(type) {
-		case *docx.Paragraph:
-			text := extractParagraphText(v)
-			if text == "" {
-				continue
+// docxDoc holds the parts needed while rendering document.xml.
+type docxDoc struct {
+	zip      *zipArchive
+	opts     *DOCXOptions
+	rels     map[string]relationship
+	styles   map[string]docxStyle
+	num      map[string]docxNumbering
+	notes    map[string]*xmlNode // footnotes by id, endnotes by "endnote:" + id
+	comments map[string]docxComment
+
+	out       []string // rendered blocks
+	list      []string // lines of the list being built
+	widths    []int    // marker widths by list level, for nested indentation
+	counters  map[string][]int
+	footnotes []string          // footnote definitions in reference order
+	labels    map[string]string // footnote labels by note or "comment:" + id
+	noteCount int
+	title     string // text of the first Title paragraph
+}
+
+// blocks renders body-level elements.
+func (d *docxDoc) blocks(nodes []*xmlNode) {
+	for _, n := range nodes {
+		switch {
+		case n.is("p"):
+			d.paragraph(n)
+		case n.is("tbl"):
+			d.flushList()
+			if t := d.table(n); t != "" {
+				d.out = append(d.out, t)
+			}
+		case n.is("sdt"):
+			if c := n.child("sdtContent"); c != nil {
+				d.blocks(c.children)
 			}
+		case n.is("customXml"), n.is("ins"):
+			d.blocks(n.children)
+		}
+	}
+}
+
+func (d *docxDoc) paragraph(p *xmlNode) {
+	pr := p.child("pPr")
+	styleID := ""
+	if pr != nil {
+		if s := pr.child("pStyle"); s != nil {
+			styleID = s.attr("val")
+		}
+	}
+
+	text := strings.TrimSpace(d.inline(p))
+	if text == "" {
+		return
+	}
+
+	if d.title == "" && d.titleStyle(styleID) {
+		d.title = collapseSpace(text) // replaces the file name heading
+		return
+	}
+	if level := d.headingLevel(styleID); level > 0 {
+		d.flushList()
+		d.out = append(d.out, strings.Repeat("#", level)+" "+collapseSpace(text))
+		return
+	}
+
+	numID, ilvl := d.numbering(pr, styleID)
+	if numID == "" {
+		d.flushList()
+		d.out = append(d.out, text)
+		return
+	}
+	d.listItem(numID, ilvl, collapseSpace(text))
+}
+
+// numbering returns the list a paragraph belongs to, from its own properties
+// or its style.
+func (d *docxDoc) numbering(pr *xmlNode, styleID string) (string, int) {
+	var numPr *xmlNode
+	if pr != nil {
+		numPr = pr.child("numPr")
+	}
+	numID, ilvl := "", 0
+	if numPr != nil {
+		if n := numPr.child("numId"); n != nil {
+			numID = n.attr("val")
+		}
+		if l := numPr.child("ilvl"); l != nil {
+			ilvl, _ = strconv.Atoi(l.attr("val"))
+		}
+	}
+	if numID == "" {
+		numID = d.styleNumID(styleID)
+	}
+	if numID == "0" {
+		return "", 0 // numbering explicitly removed
+	}
+	if _, ok := d.num[numID]; !ok {
+		return "", 0
+	}
+	return numID, min(max(ilvl, 0), 8)
+}
+
+func (d *docxDoc) listItem(numID string, ilvl int, text string) {
+	format := d.num[numID].levels[ilvl]
+
+	marker := "-"
+	if format.numbered() {
+		counts := d.counters[numID]
+		for len(counts) <= ilvl {
+			counts = append(counts, 0)
+		}
+		if counts[ilvl] == 0 {
+			counts[ilvl] = format.start
+		} else {
+			counts[ilvl]++
+		}
+		// a shallower item restarts the numbering of deeper levels
+		for i := ilvl + 1; i < len(counts); i++ {
+			counts[i] = 0
+		}
+		d.counters[numID] = counts
+		marker = strconv.Itoa(counts[ilvl]) + "."
+	}
+
+	for len(d.widths) <= ilvl {
+		d.widths = append(d.widths, 2)
+	}
+	indent := 0
+	for _, w := range d.widths[:ilvl] {
+		indent += w
+	}
+	d.widths[ilvl] = len(marker) + 1
+	d.list = append(d.list, strings.Repeat(" ", indent)+marker+" "+text)
+}
 
-			// detect heading style
-			if v.Properties != nil && v.Properties.Style != nil {
-				style := v.Properties.Style.Val
-				switch {
-				case strings.Contains(style, "Heading1") || strings.HasPrefix(style, "heading 1") || style == "1":
-					md.WriteString("# " + text + "\n\n")
-					continue
-				case strings.Contains(style, "Heading2") || strings.HasPrefix(style, "heading 2") || style == "2":
-					md.WriteString("## " + text + "\n\n")
-					continue
-				case strings.Contains(style, "Heading3") || strings.HasPrefix(style, "heading 3") || style == "3":
-					md.WriteString("### " + text + "\n\n")
-					continue
+func (d *docxDoc) flushList() {
+	if len(d.list) > 0 {
+		d.out = append(d.out, strings.Join(d.list, "\n"))
+		d.list = nil
+		d.widths = nil
+	}
+}
+
+// titleStyle reports whether a paragraph style is the document title.
+func (d *docxDoc) titleStyle(styleID string) bool {
+	for _, name := range []string{styleID, d.styles[styleID].name} {
+		if strings.ToLower(strings.ReplaceAll(name, " ", "")) == "title" {
+			return true
+		}
+	}
+	return false
+}
+
+// headingLevel maps a paragraph style to a heading level, or 0.
+func (d *docxDoc) headingLevel(styleID string) int {
+	for range 10 { // bounds basedOn chains
+		if styleID == "" {
+			return 0
+		}
+		style, ok := d.styles[styleID]
+		for _, name := range []string{styleID, style.name} {
+			key := strings.ToLower(strings.ReplaceAll(name, " ", ""))
+			switch {
+			case key == "title":
+				return 1
+			case key == "subtitle":
+				return 2
+			case strings.HasPrefix(key, "heading"):
+				if n, err := strconv.Atoi(strings.TrimPrefix(key, "heading")); err == nil && n >= 1 {
+					return min(n, 6)
 				}
 			}
+		}
+		if !ok {
+			return 0
+		}
+		// localized style names still carry the outline level
+		if style.outline >= 0 && style.outline < 9 {
+			return min(style.outline+1, 6)
+		}
+		styleID = style.basedOn
+	}
+	return 0
+}
 
-			md.WriteString(text + "\n\n")
+func (d *docxDoc) styleNumID(styleID string) string {
+	for range 10 {
+		style, ok := d.styles[styleID]
+		if !ok {
+			return ""
+		}
+		if style.numID != "" {
+			return style.numID
+		}
+		styleID = style.basedOn
+	}
+	return ""
+}
 
-		case *docx.Table:
-			md.WriteString(convertTable(v))
+// inline renders the runs of a paragraph-level element with formatting,
+// links, note references and images.
+func (d *docxDoc) inline(p *xmlNode) string {
+	var (
+		segs     []docxSegment
+		field    *docxField
+		fieldOut []docxSegment
+	)
+	emit := func(s docxSegment) {
+		if field != nil && field.result {
+			fieldOut = append(fieldOut, s)
+			return
 		}
+		segs = append(segs, s)
 	}
 
-	return strings.TrimSpace(md.String()), nil
+	var (
+		walk    func(n *xmlNode)
+		capture func(n *xmlNode) []docxSegment
+	)
+	walk = func(n *xmlNode) {
+		for _, c := range n.children {
+			switch {
+			case c.is("r"):
+				f := runFormat(c.child("rPr"))
+				for _, rc := range c.children {
+					switch {
+					case rc.is("t"):
+						emit(docxSegment{text: rc.textContent(), format: f})
+					case rc.is("tab"):
+						emit(docxSegment{text: "\t"})
+					case rc.is("br"), rc.is("cr"):
+						emit(docxSegment{text: "\n"})
+					case rc.is("noBreakHyphen"):
+						emit(docxSegment{text: "-", format: f})
+					case rc.is("fldChar"):
+						switch rc.attr("fldCharType") {
+						case "begin":
+							field = &docxField{}
+						case "separate":
+							if field != nil {
+								field.result = true
+							}
+						case "end":
+							if field != nil {
+								segs = append(segs, field.render(fieldOut)...)
+								field, fieldOut = nil, nil
+							}
+						}
+					case rc.is("instrText"):
+						if field != nil && !field.result {
+							field.instr += rc.textContent()
+						}
+					case rc.is("footnoteReference"):
+						emit(docxSegment{text: d.noteRef(rc.attr("id"))})
+					case rc.is("endnoteReference"):
+						emit(docxSegment{text: d.noteRef("endnote:" + rc.attr("id"))})
+					case rc.is("commentReference"):
+						emit(docxSegment{text: d.commentRef(rc.attr("id"))})
+					case rc.is("drawing"), rc.is("pict"), rc.is("object"):
+						if img := d.image(rc); img != "" {
+							emit(docxSegment{text: img})
+						}
+					}
+				}
+			case c.is("hyperlink"):
+				target := ""
+				if r, ok := d.rels[c.attrNS(relNS, "id")]; ok {
+					target = r.target
+				} else if a := c.attr("anchor"); a != "" {
+					target = "#" + a
+				}
+				inner := capture(c)
+				text := strings.TrimSpace(renderSegments(inner))
+				if target == "" || text == "" {
+					segs = append(segs, inner...)
+				} else {
+					emit(docxSegment{text: fmt.Sprintf("[%s](%s)", text, target)})
+				}
+			case c.is("fldSimple"):
+				f := &docxField{instr: c.attr("instr"), result: true}
+				segs = append(segs, f.render(capture(c))...)
+			case c.is("del"), c.is("moveFrom"), c.is("pPr"):
+				// deleted revisions and paragraph properties carry no text
+			case c.isText():
+			default:
+				// ins, smartTag, sdt, sdtContent and similar wrappers
+				walk(c)
+			}
+		}
+	}
+	// capture renders n's children into their own segments
+	capture = func(n *xmlNode) []docxSegment {
+		saved := segs
+		segs = nil
+		walk(n)
+		inner := segs
+		segs = saved
+		return inner
+	}
+	walk(p)
+	if field != nil {
+		segs = append(segs, fieldOut...)
+	}
+	return renderSegments(segs)
 }
 
-func extractParagraphText(p *docx.Paragraph) string {
+// noteRef returns the markdown reference for a footnote or endnote and
+// records its definition on first use.
+func (d *docxDoc) noteRef(id string) string {
+	note, ok := d.notes[id]
+	if !ok {
+		return ""
+	}
+	if label, ok := d.labels[id]; ok {
+		return "[^" + label + "]"
+	}
+	d.noteCount++
+	label := strconv.Itoa(d.noteCount)
+	d.labels[id] = label
 	var parts []string
-	for _, child := range p.Children {
-		switch v := child.(type) {
-		case *docx.Run:
-			text := extractRunText(v)
-			if text == "" {
-				continue
+	for _, p := range note.all("p") {
+		if t := collapseSpace(d.inline(p)); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	d.footnotes = append(d.footnotes, fmt.Sprintf("[^%s]: %s", label, strings.Join(parts, " ")))
+	return "[^" + label + "]"
+}
+
+// commentRef returns the markdown reference for a comment and records the
+// comment as a footnote on first use.
+func (d *docxDoc) commentRef(id string) string {
+	c, ok := d.comments[id]
+	if !ok {
+		return ""
+	}
+	label := "c" + id
+	if _, ok := d.labels["comment:"+id]; ok {
+		return "[^" + label + "]"
+	}
+	d.labels["comment:"+id] = label
+	text := c.text
+	if c.author != "" {
+		text = c.author + ": " + text
+	}
+	d.footnotes = append(d.footnotes, fmt.Sprintf("[^%s]: %s", label, text))
+	return "[^" + label + "]"
+}
+
+// image renders an embedded picture when images are retained, or its alt
+// text when it cannot be inlined as a data URI.
+func (d *docxDoc) image(n *xmlNode) string {
+	if !d.opts.RetainImages {
+		return ""
+	}
+	id, alt := "", ""
+	n.walk(func(c *xmlNode) bool {
+		switch {
+		case c.is("blip"):
+			id = c.attrNS(relNS, "embed")
+		case c.is("imagedata"):
+			id = c.attrNS(relNS, "id")
+		case c.is("docPr"):
+			alt = c.attr("descr")
+			if alt == "" {
+				alt = c.attr("title")
 			}
-			// apply inline formatting
-			if v.RunProperties != nil {
-				if v.RunProperties.Bold != nil {
-					text = "**" + text + "**"
-				}
-				if v.RunProperties.Italic != nil {
-					text = "_" + text + "_"
+		}
+		return id == ""
+	})
+	r, ok := d.rels[id]
+	if !ok {
+		return ""
+	}
+	alt = strings.ReplaceAll(collapseSpace(alt), "]", "\\]")
+
+	if data, err := d.zip.read(r.target); err == nil && len(data) <= maxDataURIImage {
+		if ct := http.DetectContentType(data); strings.HasPrefix(ct, "image/") {
+			return fmt.Sprintf("![%s](data:%s;base64,%s)", alt, ct, base64.StdEncoding.EncodeToString(data))
+		}
+	}
+	// a path inside the archive would not resolve from the markdown
+	return alt
+}
+
+func (d *docxDoc) table(tbl *xmlNode) string {
+	var rows [][]string
+	for _, tr := range tbl.all("tr") {
+		var row []string
+		for _, tc := range tr.all("tc") {
+			var parts []string
+			tc.walk(func(n *xmlNode) bool {
+				if n.is("p") {
+					if t := collapseSpace(d.inline(n)); t != "" {
+						parts = append(parts, t)
+					}
+					return false
 				}
-				if v.RunProperties.Strike != nil {
-					text = "~~" + text + "~~"
+				return true
+			})
+			row = append(row, strings.Join(parts, " "))
+			// merged cells keep the grid aligned with the header
+			if pr := tc.child("tcPr"); pr != nil {
+				if span := pr.child("gridSpan"); span != nil {
+					n, _ := strconv.Atoi(span.attr("val"))
+					for i := 1; i < min(n, 64); i++ {
+						row = append(row, "")
+					}
 				}
 			}
-			parts = append(parts, text)
-		case *docx.Hyperlink:
-			var linkText string
-			for _, hc := range v.Run.Children {
-				if t, ok := hc.(*docx.Text); ok {
-					linkText += t.Text
-				}
+		}
+		rows = append(rows, row)
+	}
+	return markdownTable(rows)
+}
+
+// docxFormat is the inline formatting of a run.
+type docxFormat struct {
+	bold, italic, strike bool
+}
+
+func runFormat(rPr *xmlNode) docxFormat {
+	if rPr == nil {
+		return docxFormat{}
+	}
+	on := func(name string) bool {
+		n := rPr.child(name)
+		if n == nil {
+			return false
+		}
+		switch n.attr("val") {
+		case "0", "false", "off", "none":
+			return false
+		}
+		return true
+	}
+	return docxFormat{bold: on("b"), italic: on("i"), strike: on("strike") || on("dstrike")}
+}
+
+// docxSegment is a piece of inline text with its formatting.
+type docxSegment struct {
+	text   string
+	format docxFormat
+}
+
+// renderSegments joins segments, merging runs with the same formatting so
+// markers wrap whole phrases and leaving surrounding spaces outside them.
+func renderSegments(segs []docxSegment) string {
+	var merged []docxSegment
+	for _, s := range segs {
+		if s.text == "" {
+			continue
+		}
+		if n := len(merged); n > 0 && merged[n-1].format == s.format {
+			merged[n-1].text += s.text
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	var b strings.Builder
+	for _, s := range merged {
+		core := strings.TrimSpace(s.text)
+		if core == "" || s.format == (docxFormat{}) {
+			b.WriteString(s.text)
+			continue
+		}
+		lead := s.text[:strings.Index(s.text, core)]
+		trail := s.text[len(lead)+len(core):]
+		if s.format.bold {
+			core = "**" + core + "**"
+		}
+		if s.format.italic {
+			core = "_" + core + "_"
+		}
+		if s.format.strike {
+			core = "~~" + core + "~~"
+		}
+		b.WriteString(lead + core + trail)
+	}
+	return b.String()
+}
+
+// docxField is a complex field; HYPERLINK fields become links around their
+// displayed result.
+type docxField struct {
+	instr  string
+	result bool
+}
+
+func (f *docxField) render(result []docxSegment) []docxSegment {
+	fields := strings.Fields(f.instr)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], "HYPERLINK") {
+		return result
+	}
+	target := ""
+	for i := 1; i < len(fields); i++ {
+		switch fields[i] {
+		case `\l`:
+			if i+1 < len(fields) {
+				target = "#" + strings.Trim(fields[i+1], `"`)
 			}
-			if linkText != "" {
-				parts = append(parts, linkText)
+			i++
+		case `\o`, `\t`, `\m`, `\n`:
+			i++
+		default:
+			if target == "" && !strings.HasPrefix(fields[i], `\`) {
+				target = strings.Trim(fields[i], `"`)
 			}
 		}
 	}
-	return strings.Join(parts, "")
+	text := strings.TrimSpace(renderSegments(result))
+	if target == "" || text == "" {
+		return result
+	}
+	return []docxSegment{{text: fmt.Sprintf("[%s](%s)", text, target)}}
 }
 
-func extractRunText(r *docx.Run) string {
-	var parts []string
-	for _, child := range r.Children {
-		switch v := child.(type) {
-		case *docx.Text:
-			parts = append(parts, v.Text)
-		case *docx.Tab:
-			parts = append(parts, "\t")
-		case *docx.BarterRabbet:
-			parts = append(parts, "\n")
+// docxStyle is the part of a paragraph style relevant to markdown.
+type docxStyle struct {
+	name    string
+	basedOn string
+	numID   string
+	outline int // outline level, -1 when not set
+}
+
+func readDOCXStyles(zr *zipArchive) map[string]docxStyle {
+	styles := make(map[string]docxStyle)
+	root, err := zr.xml("word/styles.xml")
+	if err != nil {
+		return styles
+	}
+	for _, s := range root.all("style") {
+		st := docxStyle{outline: -1}
+		if n := s.child("name"); n != nil {
+			st.name = n.attr("val")
+		}
+		if n := s.child("basedOn"); n != nil {
+			st.basedOn = n.attr("val")
+		}
+		if pr := s.child("pPr"); pr != nil {
+			if numPr := pr.child("numPr"); numPr != nil {
+				if n := numPr.child("numId"); n != nil {
+					st.numID = n.attr("val")
+				}
+			}
+			if o := pr.child("outlineLvl"); o != nil {
+				if lvl, err := strconv.Atoi(o.attr("val")); err == nil {
+					st.outline = lvl
+				}
+			}
 		}
+		styles[s.attr("styleId")] = st
 	}
-	return strings.Join(parts, "")
+	return styles
 }
 
-func convertTable(t *docx.Table) string {
-	if len(t.TableRows) == 0 {
-		return ""
+// docxNumbering is a numbering instance with the formats of its levels.
+type docxNumbering struct {
+	levels [9]docxLevel
+}
+
+type docxLevel struct {
+	format string // numFmt value, e.g. "bullet" or "decimal"
+	start  int
+}
+
+func (l docxLevel) numbered() bool {
+	return l.format != "" && l.format != "bullet" && l.format != "none"
+}
+
+func readDOCXNumbering(zr *zipArchive) map[string]docxNumbering {
+	nums := make(map[string]docxNumbering)
+	root, err := zr.xml("word/numbering.xml")
+	if err != nil {
+		return nums
 	}
 
-	var md strings.Builder
-	md.WriteString("\n")
-
-	for i, row := range t.TableRows {
-		md.WriteString("|")
-		for _, cell := range row.TableCells {
-			cellText := ""
-			for _, item := range cell.Paragraphs {
-				cellText += extractParagraphText(item)
+	readLevels := func(parent *xmlNode, into *docxNumbering) {
+		for _, lvl := range parent.all("lvl") {
+			i, err := strconv.Atoi(lvl.attr("ilvl"))
+			if err != nil || i < 0 || i >= len(into.levels) {
+				continue
+			}
+			l := docxLevel{start: 1}
+			if f := lvl.child("numFmt"); f != nil {
+				l.format = f.attr("val")
+			}
+			if s := lvl.child("start"); s != nil {
+				if n, err := strconv.Atoi(s.attr("val")); err == nil {
+					l.start = n
+				}
 			}
-			cellText = strings.ReplaceAll(strings.TrimSpace(cellText), "\n", " ")
-			md.WriteString(" " + cellText + " |")
+			into.levels[i] = l
 		}
-		md.WriteString("\n")
+	}
 
-		if i == 0 {
-			md.WriteString("|")
-			for range row.TableCells {
-				md.WriteString(" --- |")
-			}
-			md.WriteString("\n")
+	abstract := make(map[string]docxNumbering)
+	for _, a := range root.all("abstractNum") {
+		var n docxNumbering
+		readLevels(a, &n)
+		abstract[a.attr("abstractNumId")] = n
+	}
+	for _, num := range root.all("num") {
+		var n docxNumbering
+		if a := num.child("abstractNumId"); a != nil {
+			n = abstract[a.attr("val")]
+		}
+		for _, o := range num.all("lvlOverride") {
+			readLevels(o, &n)
+		}
+		nums[num.attr("numId")] = n
+	}
+	return nums
+}
+
+// readNotes returns the footnotes or endnotes of a notes part by id,
+// skipping the separator notes Word adds.
+func (d *docxDoc) readNotes(part, element string) map[string]*xmlNode {
+	notes := make(map[string]*xmlNode)
+	root, err := d.zip.xml(part)
+	if err != nil {
+		return notes
+	}
+	for _, n := range root.all(element) {
+		if t := n.attr("type"); t == "separator" || t == "continuationSeparator" || t == "continuationNotice" {
+			continue
 		}
+		notes[n.attr("id")] = n
 	}
-	md.WriteString("\n")
+	return notes
+}
+
+type docxComment struct {
+	author string
+	text   string
+}
 
-	return md.String()
+func (d *docxDoc) readComments() map[string]docxComment {
+	comments := make(map[string]docxComment)
+	root, err := d.zip.xml("word/comments.xml")
+	if err != nil {
+		return comments
+	}
+	for _, c := range root.all("comment") {
+		var parts []string
+		for _, p := range c.all("p") {
+			if t := collapseSpace(d.inline(p)); t != "" {
+				parts = append(parts, t)
+			}
+		}
+		comments[c.attr("id")] = docxComment{author: c.attr("author"), text: strings.Join(parts, " ")}
+	}
+	return comments
 }
@@ -0,0 +1,390 @@
+package filetype
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLConverter converts an HTML fragment to markdown. EPUB chapters are run
+// through it so they follow the same rules as web pages.
+type HTMLConverter func(html string) (string, error)
+
+// epubItem is a manifest entry of the OPF package document.
+type epubItem struct {
+	href       string // archive path
+	mediaType  string
+	properties string
+}
+
+// tocEntry is one entry of a book's table of contents.
+type tocEntry struct {
+	title string
+	href  string // archive path, without fragment
+	depth int
+}
+
+// maxTOCEntries bounds the table of contents of malformed books.
+const maxTOCEntries = 1000
+
+// ConvertEPUB converts an EPUB e-book to markdown along with the book
+// metadata. The output has the book title, a table of contents from the EPUB 3
+// nav document or the EPUB 2 NCX, and every spine chapter in reading order
+// under its own heading.
+func ConvertEPUB(data []byte, filename string, toMarkdown HTMLConverter) (string, *DocMeta, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("epub open zip: %w", err)
+	}
+
+	opfPath, err := epubRootfile(zr)
+	if err != nil {
+		return "", nil, fmt.Errorf("epub: %w", err)
+	}
+	opf, err := zr.xml(opfPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("epub: %w", err)
+	}
+
+	dir := path.Dir(opfPath)
+	manifest := make(map[string]epubItem)
+	if m := opf.child("manifest"); m != nil {
+		for _, item := range m.all("item") {
+			manifest[item.attr("id")] = epubItem{
+				href:       epubPath(dir, item.attr("href")),
+				mediaType:  item.attr("media-type"),
+				properties: item.attr("properties"),
+			}
+		}
+	}
+
+	meta := epubMeta(opf)
+	toc := epubTOC(zr, opf, manifest)
+	titles := make(map[string]string)
+	for _, e := range toc {
+		if _, ok := titles[e.href]; !ok {
+			titles[e.href] = e.title
+		}
+	}
+
+	var md strings.Builder
+	title := meta.Title
+	if title == "" {
+		title = filename
+	}
+	if title == "" {
+		title = "book.epub"
+	}
+	md.WriteString(fmt.Sprintf("# %s\n\n", title))
+
+	if len(toc) > 0 {
+		md.WriteString("## Contents\n\n")
+		for _, e := range toc {
+			md.WriteString(strings.Repeat("  ", e.depth))
+			md.WriteString("- ")
+			md.WriteString(e.title)
+			md.WriteString("\n")
+		}
+		md.WriteString("\n")
+	}
+
+	chapters := 0
+	if spine := opf.child("spine"); spine != nil {
+		for _, ref := range spine.all("itemref") {
+			item, ok := manifest[ref.attr("idref")]
+			if !ok || ref.attr("linear") == "no" || !strings.Contains(item.mediaType, "html") {
+				continue
+			}
+			raw, err := zr.read(item.href)
+			if err != nil {
+				continue
+			}
+			chapter, err := toMarkdown(epubChapterHTML(raw, titles[item.href]))
+			if err != nil {
+				return "", nil, fmt.Errorf("epub %s: %w", item.href, err)
+			}
+			chapter = strings.TrimSpace(chapter)
+			if chapter == "" {
+				continue // cover and image-only pages
+			}
+			md.WriteString(chapter)
+			md.WriteString("\n\n")
+			chapters++
+		}
+	}
+	if chapters == 0 {
+		return "", nil, fmt.Errorf("epub: no chapters found")
+	}
+
+	return strings.TrimSpace(md.String()), meta, nil
+}
+
+// epubRootfile returns the path of the OPF package document.
+func epubRootfile(zr *zipArchive) (string, error) {
+	container, err := zr.xml("META-INF/container.xml")
+	if err == nil {
+		if rf := container.find("rootfile"); rf != nil && rf.attr("full-path") != "" {
+			return rf.attr("full-path"), nil
+		}
+	}
+	for _, name := range zr.order {
+		if strings.HasSuffix(name, ".opf") {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("package document not found")
+}
+
+// epubPath resolves an href relative to dir into an archive path, dropping
+// any fragment.
+func epubPath(dir, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if u, err := url.PathUnescape(href); err == nil {
+		href = u
+	}
+	return path.Join(dir, href)
+}
+
+// epubMeta reads the Dublin Core metadata of the package document.
+func epubMeta(opf *xmlNode) *DocMeta {
+	meta := &DocMeta{}
+	m := opf.child("metadata")
+	if m == nil {
+		return meta
+	}
+
+	var creators, subjects []string
+	for _, c := range m.children {
+		text := collapseSpace(c.textContent())
+		if c.isText() || text == "" {
+			continue
+		}
+		switch {
+		case c.is("title") && meta.Title == "":
+			meta.Title = text
+		case c.is("creator"):
+			creators = append(creators, text)
+		case c.is("language") && meta.Language == "":
+			meta.Language = text
+		case c.is("description") && meta.Subject == "":
+			meta.Subject = text
+		case c.is("subject"):
+			subjects = append(subjects, text)
+		case c.is("date") && meta.Created == "":
+			meta.Created = text
+		case c.is("meta") && c.attr("property") == "dcterms:modified":
+			meta.Modified = text
+		}
+	}
+	meta.Author = strings.Join(creators, ", ")
+	meta.Keywords = strings.Join(subjects, ", ")
+	return meta
+}
+
+// epubTOC reads the table of contents from the EPUB 3 nav document, falling
+// back to the EPUB 2 NCX.
+func epubTOC(zr *zipArchive, opf *xmlNode, manifest map[string]epubItem) []tocEntry {
+	for _, item := range manifest {
+		if !strings.Contains(" "+item.properties+" ", " nav ") {
+			continue
+		}
+		doc, err := zr.xml(item.href)
+		if err != nil {
+			break
+		}
+		var toc []tocEntry
+		doc.walk(func(n *xmlNode) bool {
+			if toc != nil || !n.is("nav") {
+				return toc == nil
+			}
+			if t := n.attr("type"); t == "toc" || strings.Contains(" "+t+" ", " toc ") {
+				if ol := n.child("ol"); ol != nil {
+					toc = navList(ol, path.Dir(item.href), 0, []tocEntry{})
+				}
+			}
+			return false
+		})
+		if len(toc) > 0 {
+			return toc
+		}
+	}
+
+	ncxID := ""
+	if spine := opf.child("spine"); spine != nil {
+		ncxID = spine.attr("toc")
+	}
+	item, ok := manifest[ncxID]
+	if !ok {
+		for _, it := range manifest {
+			if it.mediaType == "application/x-dtbncx+xml" {
+				item, ok = it, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	ncx, err := zr.xml(item.href)
+	if err != nil {
+		return nil
+	}
+	navMap := ncx.find("navMap")
+	if navMap == nil {
+		return nil
+	}
+	return ncxPoints(navMap, path.Dir(item.href), 0, nil)
+}
+
+// navList collects the entries of a nav document's ol element.
+func navList(ol *xmlNode, dir string, depth int, toc []tocEntry) []tocEntry {
+	for _, li := range ol.all("li") {
+		if len(toc) >= maxTOCEntries {
+			break
+		}
+		label := li.child("a")
+		if label == nil {
+			label = li.child("span")
+		}
+		if label != nil {
+			if title := collapseSpace(label.textContent()); title != "" {
+				toc = append(toc, tocEntry{title: title, href: epubPath(dir, label.attr("href")), depth: depth})
+			}
+		}
+		if sub := li.child("ol"); sub != nil {
+			toc = navList(sub, dir, depth+1, toc)
+		}
+	}
+	return toc
+}
+
+// ncxPoints collects the navPoint entries below n.
+func ncxPoints(n *xmlNode, dir string, depth int, toc []tocEntry) []tocEntry {
+	for _, p := range n.all("navPoint") {
+		if len(toc) >= maxTOCEntries {
+			break
+		}
+		title := ""
+		if label := p.child("navLabel"); label != nil {
+			title = collapseSpace(label.textContent())
+		}
+		src := ""
+		if c := p.child("content"); c != nil {
+			src = epubPath(dir, c.attr("src"))
+		}
+		if title != "" {
+			toc = append(toc, tocEntry{title: title, href: src, depth: depth})
+		}
+		toc = ncxPoints(p, dir, depth+1, toc)
+	}
+	return toc
+}
+
+// epubChapterHTML returns the body of a chapter with its headings shifted
+// below the book title. When the chapter does not open with a heading, title
+// is inserted as one. Links into the archive are reduced to their text.
+func epubChapterHTML(data []byte, title string) string {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return string(data)
+	}
+
+	var body *html.Node
+	top, leads, seenText := 7, false, false
+	var walk func(n *html.Node, inHeading bool)
+	walk = func(n *html.Node, inHeading bool) {
+		switch n.Type {
+		case html.ElementNode:
+			if n.DataAtom == atom.Body && body == nil {
+				body = n
+			}
+			if l := headingLevel(n); l > 0 {
+				top = min(top, l)
+				inHeading = true
+			}
+		case html.TextNode:
+			if body != nil && !seenText && strings.TrimSpace(n.Data) != "" {
+				seenText, leads = true, inHeading
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, inHeading)
+		}
+	}
+	walk(doc, false)
+	if body == nil {
+		return string(data)
+	}
+
+	insert := title != "" && !leads
+	base := 2
+	if insert {
+		base = 3
+	}
+
+	var rewrite func(n *html.Node)
+	rewrite = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			if l := headingLevel(n); l > 0 {
+				n.Data = fmt.Sprintf("h%d", min(max(l-top+base, 2), 6))
+				n.DataAtom = atom.Lookup([]byte(n.Data))
+			}
+			if n.DataAtom == atom.A && !isExternalLink(attrValue(n, "href")) {
+				n.Data, n.DataAtom = "span", atom.Span
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			rewrite(c)
+		}
+	}
+	rewrite(body)
+
+	if insert {
+		h := &html.Node{Type: html.ElementNode, Data: "h2", DataAtom: atom.H2}
+		h.AppendChild(&html.Node{Type: html.TextNode, Data: title})
+		body.InsertBefore(h, body.FirstChild)
+	}
+
+	var b strings.Builder
+	for c := body.FirstChild; c != nil; c = c.NextSibling {
+		html.Render(&b, c)
+	}
+	return b.String()
+}
+
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func headingLevel(n *html.Node) int {
+	switch n.DataAtom {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	return 0
+}
+
+func isExternalLink(href string) bool {
+	u, err := url.Parse(strings.TrimSpace(href))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https" || u.Scheme == "mailto")
+}
@@ -0,0 +1,268 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// NotebookOptions configures Jupyter notebook conversion.
+type NotebookOptions struct {
+	// RetainImages embeds image outputs and cell attachments as data URIs;
+	// otherwise they are left out.
+	RetainImages bool
+	// DropOutputs leaves out cell outputs, keeping only the source.
+	DropOutputs bool
+}
+
+type notebook struct {
+	NBFormat int              `json:"nbformat"`
+	Cells    []notebookCell   `json:"cells"`
+	Metadata notebookMetadata `json:"metadata"`
+}
+
+type notebookMetadata struct {
+	Title      string `json:"title"`
+	KernelSpec struct {
+		Language string `json:"language"`
+	} `json:"kernelspec"`
+	LanguageInfo struct {
+		Name string `json:"name"`
+	} `json:"language_info"`
+}
+
+type notebookCell struct {
+	CellType    string                                `json:"cell_type"`
+	Source      notebookText                          `json:"source"`
+	Outputs     []notebookOutput                      `json:"outputs"`
+	Attachments map[string]map[string]json.RawMessage `json:"attachments"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Text       notebookText               `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	EName      string                     `json:"ename"`
+	EValue     string                     `json:"evalue"`
+	Traceback  []string                   `json:"traceback"`
+}
+
+// notebookText is multiline notebook text, stored either as one string or
+// as a list of lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = notebookText(s)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*t = notebookText(strings.Join(lines, ""))
+	return nil
+}
+
+// IsNotebook reports whether JSON data is a Jupyter notebook.
+func IsNotebook(data []byte) bool {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("{")) {
+		return false
+	}
+	var nb struct {
+		NBFormat *int            `json:"nbformat"`
+		Cells    json.RawMessage `json:"cells"`
+	}
+	return json.Unmarshal(data, &nb) == nil && nb.NBFormat != nil && nb.Cells != nil
+}
+
+// ConvertIPYNB converts a Jupyter notebook (nbformat 4) to markdown: markdown
+// cells as-is, code cells as fenced blocks in the kernel language, and their
+// outputs after them. HTML outputs such as data frames go through toMarkdown.
+func ConvertIPYNB(data []byte, filename string, opts *NotebookOptions, toMarkdown HTMLConverter) (string, error) {
+	if opts == nil {
+		opts = &NotebookOptions{}
+	}
+	var nb notebook
+	if err := json.Unmarshal(data, &nb); err != nil {
+		return "", fmt.Errorf("ipynb: %w", err)
+	}
+	if nb.NBFormat < 4 {
+		return "", fmt.Errorf("ipynb: nbformat %d is not supported", nb.NBFormat)
+	}
+
+	lang := nb.Metadata.LanguageInfo.Name
+	if lang == "" {
+		lang = nb.Metadata.KernelSpec.Language
+	}
+
+	var blocks []string
+	for _, cell := range nb.Cells {
+		source := strings.TrimSpace(string(cell.Source))
+		switch cell.CellType {
+		case "markdown":
+			if source != "" {
+				blocks = append(blocks, notebookAttachments(source, cell.Attachments, opts.RetainImages))
+			}
+		case "code":
+			if source != "" {
+				blocks = append(blocks, fencedBlock(source, lang))
+			}
+			if !opts.DropOutputs {
+				out, err := notebookOutputs(cell.Outputs, opts, toMarkdown)
+				if err != nil {
+					return "", err
+				}
+				blocks = append(blocks, out...)
+			}
+		case "raw":
+			if source != "" {
+				blocks = append(blocks, fencedBlock(source, ""))
+			}
+		}
+	}
+
+	// a notebook that opens with its own title needs no other heading
+	var md strings.Builder
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0], "# ") {
+		title := nb.Metadata.Title
+		if title == "" {
+			title = filename
+		}
+		if title == "" {
+			title = "notebook.ipynb"
+		}
+		md.WriteString(fmt.Sprintf("# %s\n\n", title))
+	}
+	for _, b := range blocks {
+		md.WriteString(b)
+		md.WriteString("\n\n")
+	}
+	return strings.TrimSpace(md.String()), nil
+}
+
+// notebookOutputs renders the outputs of a code cell. Consecutive stream
+// outputs are merged into one block.
+func notebookOutputs(outputs []notebookOutput, opts *NotebookOptions, toMarkdown HTMLConverter) ([]string, error) {
+	var blocks []string
+	var stream strings.Builder
+	flush := func() {
+		if s := strings.TrimSpace(stream.String()); s != "" {
+			blocks = append(blocks, fencedBlock(s, "text"))
+		}
+		stream.Reset()
+	}
+	for _, out := range outputs {
+		switch out.OutputType {
+		case "stream":
+			stream.WriteString(string(out.Text))
+			continue
+		case "execute_result", "display_data":
+			flush()
+			b, err := notebookDisplay(out.Data, opts, toMarkdown)
+			if err != nil {
+				return nil, err
+			}
+			if b != "" {
+				blocks = append(blocks, b)
+			}
+		case "error":
+			flush()
+			text := out.EName + ": " + out.EValue
+			if len(out.Traceback) > 0 {
+				text = ansiEscape.ReplaceAllString(strings.Join(out.Traceback, "\n"), "")
+			}
+			blocks = append(blocks, fencedBlock(strings.TrimSpace(text), "text"))
+		}
+	}
+	flush()
+	return blocks, nil
+}
+
+// notebookImageTypes are the image representations embedded when images are
+// retained, in order of preference.
+var notebookImageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/svg+xml"}
+
+// notebookDisplay renders the richest representation of a display output:
+// markdown, then HTML, then an image, then plain text. Outputs that are only
+// a placeholder for an image (like "<Figure size 640x480>") render nothing
+// when images are not retained.
+func notebookDisplay(bundle map[string]json.RawMessage, opts *NotebookOptions, toMarkdown HTMLConverter) (string, error) {
+	text := func(mime string) (string, bool) {
+		raw, ok := bundle[mime]
+		if !ok {
+			return "", false
+		}
+		var t notebookText
+		if json.Unmarshal(raw, &t) != nil {
+			return "", false
+		}
+		return strings.TrimSpace(string(t)), true
+	}
+
+	if md, ok := text("text/markdown"); ok {
+		return md, nil
+	}
+	if html, ok := text("text/html"); ok && toMarkdown != nil {
+		md, err := toMarkdown(html)
+		if err != nil {
+			return "", fmt.Errorf("ipynb html output: %w", err)
+		}
+		return strings.TrimSpace(md), nil
+	}
+	for _, mime := range notebookImageTypes {
+		if _, ok := bundle[mime]; !ok {
+			continue
+		}
+		if !opts.RetainImages {
+			return "", nil
+		}
+		img, _ := text(mime)
+		if mime == "image/svg+xml" {
+			img = base64.StdEncoding.EncodeToString([]byte(img))
+		}
+		return fmt.Sprintf("![output](data:%s;base64,%s)", mime, strings.Join(strings.Fields(img), "")), nil
+	}
+	if plain, ok := text("text/plain"); ok && plain != "" {
+		return fencedBlock(plain, "text"), nil
+	}
+	return "", nil
+}
+
+var (
+	// attachmentImage matches markdown images that reference cell attachments.
+	attachmentImage = regexp.MustCompile(`!\[([^\]]*)\]\(attachment:([^)\s]+)\)`)
+	// ansiEscape matches the terminal color codes in tracebacks.
+	ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+)
+
+// notebookAttachments replaces images that reference cell attachments with
+// data URIs, or removes them when images are not retained.
+func notebookAttachments(md string, attachments map[string]map[string]json.RawMessage, retain bool) string {
+	return attachmentImage.ReplaceAllStringFunc(md, func(m string) string {
+		sub := attachmentImage.FindStringSubmatch(m)
+		if !retain {
+			return ""
+		}
+		for mime, raw := range attachments[sub[2]] {
+			var b64 string
+			if strings.HasPrefix(mime, "image/") && json.Unmarshal(raw, &b64) == nil {
+				return fmt.Sprintf("![%s](data:%s;base64,%s)", sub[1], mime, strings.Join(strings.Fields(b64), ""))
+			}
+		}
+		return ""
+	})
+}
+
+// fencedBlock wraps code in a fence longer than any backtick run inside it.
+func fencedBlock(code, lang string) string {
+	fence := "```"
+	for strings.Contains(code, fence) {
+		fence += "`"
+	}
+	return fence + lang + "\n" + code + "\n" + fence
+}
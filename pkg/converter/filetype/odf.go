@@ -0,0 +1,301 @@
+package filetype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// odfRenderer renders OpenDocument text content (ODT documents and the text
+// boxes of ODP slides) as markdown.
+type odfRenderer struct {
+	lists        map[string]bool     // list style name -> first level is numbered
+	styles       map[string]odfStyle // text and paragraph styles by family + ":" + name
+	headingShift int                 // added to text:h outline levels
+}
+
+// odfStyle is the part of a style:style that affects inline formatting.
+type odfStyle struct {
+	parent string
+	props  map[string]string // text-properties attributes by local name
+}
+
+// odfListStyles maps automatic list style names to whether their first level
+// is numbered.
+func odfListStyles(root *xmlNode) map[string]bool {
+	styles := make(map[string]bool)
+	root.walk(func(n *xmlNode) bool {
+		if n.is("list-style") {
+			for _, level := range n.children {
+				if level.attr("level") == "1" || level.attr("level") == "" {
+					styles[n.attr("name")] = level.is("list-level-style-number")
+					break
+				}
+			}
+			return false
+		}
+		return true
+	})
+	return styles
+}
+
+// odfTextStyles reads the text and paragraph styles of each root into styles.
+func odfTextStyles(styles map[string]odfStyle, root *xmlNode) {
+	root.walk(func(n *xmlNode) bool {
+		if !n.is("style") {
+			return true
+		}
+		family := n.attr("family")
+		if family != "text" && family != "paragraph" {
+			return false
+		}
+		s := odfStyle{parent: n.attr("parent-style-name"), props: make(map[string]string)}
+		if p := n.child("text-properties"); p != nil {
+			for _, a := range p.attrs {
+				s.props[a.Name.Local] = a.Value
+			}
+		}
+		styles[family+":"+n.attr("name")] = s
+		return false
+	})
+}
+
+// textProps returns inherited overlaid with the properties of the named
+// style, following parent styles when parents is set.
+func (r *odfRenderer) textProps(family, name string, inherited map[string]string, parents bool) map[string]string {
+	var chain []odfStyle
+	for name != "" && len(chain) < 10 {
+		s, ok := r.styles[family+":"+name]
+		if !ok {
+			break
+		}
+		chain = append(chain, s)
+		if !parents {
+			break
+		}
+		name = s.parent
+	}
+	if len(chain) == 0 {
+		return inherited
+	}
+	props := make(map[string]string, len(inherited))
+	for k, v := range inherited {
+		props[k] = v
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].props {
+			props[k] = v
+		}
+	}
+	return props
+}
+
+// odfFormat converts text properties to inline formatting.
+func odfFormat(props map[string]string) docxFormat {
+	weight := props["font-weight"]
+	bold := weight == "bold"
+	if n, err := strconv.Atoi(weight); err == nil {
+		bold = n >= 600
+	}
+	style := props["font-style"]
+	strike := props["text-line-through-style"]
+	return docxFormat{
+		bold:   bold,
+		italic: style == "italic" || style == "oblique",
+		strike: strike != "" && strike != "none",
+	}
+}
+
+// blocks renders ODF body elements (text:p, text:h, text:list, table:table)
+// as markdown blocks; consecutive paragraphs stay separate blocks and lists
+// become one block each. Sections and other containers are rendered in
+// document order.
+func (r *odfRenderer) blocks(nodes []*xmlNode) []string {
+	var blocks []string
+	for _, n := range nodes {
+		switch {
+		case n.isText():
+		case n.is("h"):
+			level, _ := strconv.Atoi(n.attr("outline-level"))
+			level = min(max(level, 1), 6-r.headingShift) + r.headingShift
+			if t := collapseSpace(r.inline(n, nil)); t != "" {
+				blocks = append(blocks, strings.Repeat("#", level)+" "+t)
+			}
+		case n.is("p"):
+			props := r.textProps("paragraph", n.attr("style-name"), nil, false)
+			if t := collapseSpace(r.inline(n, props)); t != "" {
+				blocks = append(blocks, t)
+			}
+		case n.is("list"):
+			var lines []string
+			r.list(n, r.lists[n.attr("style-name")], 0, &lines)
+			if len(lines) > 0 {
+				blocks = append(blocks, strings.Join(lines, "\n"))
+			}
+		case n.is("table"):
+			if t := r.table(n); t != "" {
+				blocks = append(blocks, t)
+			}
+		case n.is("tracked-changes"), n.is("sequence-decls"), n.is("variable-decls"):
+		default:
+			blocks = append(blocks, r.blocks(n.children)...)
+		}
+	}
+	return blocks
+}
+
+// list appends the items of a text:list, indenting nested lists.
+func (r *odfRenderer) list(list *xmlNode, numbered bool, depth int, lines *[]string) {
+	if name := list.attr("style-name"); name != "" {
+		numbered = r.lists[name]
+	}
+	n := 0
+	for _, item := range list.children {
+		if !item.is("list-item") && !item.is("list-header") {
+			continue
+		}
+		var text []string
+		for _, c := range item.children {
+			switch {
+			case c.is("p"), c.is("h"):
+				if t := collapseSpace(r.inline(c, nil)); t != "" {
+					text = append(text, t)
+				}
+			case c.is("list"):
+				if len(text) > 0 {
+					*lines = append(*lines, odfListItem(numbered, &n, depth, text))
+					text = nil
+				}
+				r.list(c, numbered, depth+1, lines)
+			}
+		}
+		if len(text) > 0 {
+			*lines = append(*lines, odfListItem(numbered, &n, depth, text))
+		}
+	}
+}
+
+func odfListItem(numbered bool, n *int, depth int, text []string) string {
+	indent := strings.Repeat("  ", depth)
+	if numbered {
+		*n++
+		return fmt.Sprintf("%s%d. %s", indent, *n, strings.Join(text, " "))
+	}
+	return indent + "- " + strings.Join(text, " ")
+}
+
+// inline renders the content of a paragraph-level element with links and
+// the bold, italic and strikethrough of its spans.
+func (r *odfRenderer) inline(n *xmlNode, props map[string]string) string {
+	return renderSegments(r.segments(n, props))
+}
+
+func (r *odfRenderer) segments(n *xmlNode, props map[string]string) []docxSegment {
+	var segs []docxSegment
+	for _, c := range n.children {
+		switch {
+		case c.isText():
+			segs = append(segs, docxSegment{text: c.text, format: odfFormat(props)})
+		case c.is("s"):
+			count, err := strconv.Atoi(c.attr("c"))
+			if err != nil || count < 1 {
+				count = 1
+			}
+			segs = append(segs, docxSegment{text: strings.Repeat(" ", min(count, 64)), format: odfFormat(props)})
+		case c.is("tab"), c.is("line-break"):
+			segs = append(segs, docxSegment{text: " ", format: odfFormat(props)})
+		case c.is("note"), c.is("annotation"), c.is("list"):
+			// footnotes, comments and nested lists are handled elsewhere
+		case c.is("frame") && c.child("text-box") == nil:
+			// images and objects anchored in the paragraph
+		case c.is("span"):
+			segs = append(segs, r.segments(c, r.textProps("text", c.attr("style-name"), props, true))...)
+		case c.is("a"):
+			inner := r.segments(c, props)
+			text := strings.TrimSpace(renderSegments(inner))
+			href := c.attr("href")
+			if href == "" || text == "" {
+				segs = append(segs, inner...)
+				continue
+			}
+			segs = append(segs, docxSegment{text: fmt.Sprintf("[%s](%s)", text, href)})
+		default:
+			segs = append(segs, r.segments(c, props)...)
+		}
+	}
+	return segs
+}
+
+// odfText returns the plain text of a paragraph-level ODF element, expanding
+// space, tab and line-break elements and skipping notes and annotations.
+func odfText(n *xmlNode) string {
+	var b strings.Builder
+	var walk func(*xmlNode)
+	walk = func(n *xmlNode) {
+		for _, c := range n.children {
+			switch {
+			case c.isText():
+				b.WriteString(c.text)
+			case c.is("s"):
+				count, err := strconv.Atoi(c.attr("c"))
+				if err != nil || count < 1 {
+					count = 1
+				}
+				b.WriteString(strings.Repeat(" ", count))
+			case c.is("tab"), c.is("line-break"):
+				b.WriteString(" ")
+			case c.is("note"), c.is("annotation"), c.is("list"):
+				// footnotes, comments and nested lists are handled elsewhere
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// table renders a table:table as a pipe table, expanding repeated cells.
+func (r *odfRenderer) table(tbl *xmlNode) string {
+	var rows [][]string
+	var addRows func(n *xmlNode)
+	addRows = func(n *xmlNode) {
+		for _, c := range n.children {
+			switch {
+			case c.is("table-row"):
+				var row []string
+				for _, cell := range c.children {
+					if !cell.is("table-cell") && !cell.is("covered-table-cell") {
+						continue
+					}
+					var parts []string
+					for _, p := range cell.children {
+						if p.is("p") || p.is("h") {
+							if t := collapseSpace(r.inline(p, nil)); t != "" {
+								parts = append(parts, t)
+							}
+						}
+					}
+					repeat, _ := strconv.Atoi(cell.attr("number-columns-repeated"))
+					repeat = min(max(repeat, 1), 64)
+					for range repeat {
+						row = append(row, strings.Join(parts, " "))
+					}
+				}
+				rows = append(rows, row)
+			case c.is("table-header-rows"), c.is("table-rows"), c.is("table-row-group"):
+				addRows(c)
+			}
+		}
+	}
+	addRows(tbl)
+
+	// trailing empty cells come from repeated formatting-only columns
+	for i, row := range rows {
+		for len(row) > 0 && row[len(row)-1] == "" {
+			row = row[:len(row)-1]
+		}
+		rows[i] = row
+	}
+	return markdownTable(rows)
+}
@@ -0,0 +1,107 @@
+package filetype
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConvertODP converts an OpenDocument presentation (.odp) to markdown with
+// one section per slide, like ConvertPPTX.
+func ConvertODP(data []byte, filename string) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", fmt.Errorf("odp open zip: %w", err)
+	}
+	root, err := zr.xml("content.xml")
+	if err != nil {
+		return "", fmt.Errorf("odp: %w", err)
+	}
+
+	// slide titles take the second heading level
+	r := &odfRenderer{lists: odfListStyles(root), headingShift: 1}
+	var slides []slide
+	if pres := root.find("presentation"); pres != nil {
+		for _, page := range pres.all("page") {
+			slides = append(slides, odpSlide(page, r))
+		}
+	}
+	if len(slides) == 0 {
+		return "", fmt.Errorf("odp: no slides found")
+	}
+
+	if filename == "" {
+		filename = "presentation.odp"
+	}
+	return renderSlides(filename, slides), nil
+}
+
+// Presentation classes that carry slide furniture rather than content.
+var odpSkipClasses = map[string]bool{
+	"page-number": true, "footer": true, "header": true, "date-time": true, "page": true,
+}
+
+func odpSlide(page *xmlNode, r *odfRenderer) slide {
+	var s slide
+	for _, c := range page.children {
+		switch {
+		case c.isText():
+		case c.is("notes"):
+			s.notes = strings.Join(odpShapes(c, r), "\n\n")
+		case c.is("frame") && c.attr("class") == "title" && s.title == "" && c.child("text-box") != nil:
+			s.title = strings.Join(r.blocks(c.child("text-box").children), " ")
+		default:
+			s.blocks = append(s.blocks, odpShapes(c, r)...)
+		}
+	}
+	return s
+}
+
+// odpShapes renders the drawing shapes in n: text boxes, tables and images.
+// Shapes nested in groups are visited in document order.
+func odpShapes(n *xmlNode, r *odfRenderer) []string {
+	var blocks []string
+	n.walk(func(c *xmlNode) bool {
+		switch {
+		case c.isText(), odpSkipClasses[c.attr("class")]:
+			return false
+		case c.is("table"):
+			if t := r.table(c); t != "" {
+				blocks = append(blocks, t)
+			}
+			return false
+		case c.is("frame") && c.child("image") != nil && c.child("text-box") == nil:
+			if img := odpImage(c); img != "" {
+				blocks = append(blocks, img)
+			}
+			return false
+		case c.is("p"), c.is("h"), c.is("list"):
+			blocks = append(blocks, r.blocks([]*xmlNode{c})...)
+			return false
+		}
+		return true
+	})
+	return blocks
+}
+
+// odpImage renders a frame's image with its alt text (svg:title or svg:desc).
+func odpImage(frame *xmlNode) string {
+	alt := ""
+	for _, key := range []string{"title", "desc"} {
+		if n := frame.child(key); n != nil {
+			if alt = collapseSpace(n.textContent()); alt != "" {
+				break
+			}
+		}
+	}
+	if alt == "" {
+		return ""
+	}
+	src := ""
+	if img := frame.child("image"); img != nil {
+		src = img.attr("href")
+		if i := strings.LastIndex(src, "/"); i >= 0 {
+			src = src[i+1:]
+		}
+	}
+	return fmt.Sprintf("![%s](%s)", strings.ReplaceAll(alt, "]", "\\]"), src)
+}
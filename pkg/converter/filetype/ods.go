@@ -0,0 +1,247 @@
+package filetype
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Sheet bounds; Excel's limits, which also cap the repeated empty rows and
+// columns LibreOffice writes to the end of every sheet.
+const (
+	maxSheetRows = 1 << 20
+	maxSheetCols = 16384
+)
+
+// ConvertODS converts an OpenDocument spreadsheet (.ods) to markdown tables
+// (one per sheet), with the same options as ConvertXLSX.
+func ConvertODS(data []byte, filename string, opts *SheetOptions) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", fmt.Errorf("ods open zip: %w", err)
+	}
+	root, err := zr.xml("content.xml")
+	if err != nil {
+		return "", fmt.Errorf("ods: %w", err)
+	}
+	if opts == nil {
+		opts = &SheetOptions{}
+	}
+
+	var tables []*xmlNode
+	if body := root.find("spreadsheet"); body != nil {
+		tables = body.all("table")
+	}
+	hiddenStyles := odsHiddenTableStyles(root)
+	names := make([]string, len(tables))
+	hidden := make([]bool, len(tables))
+	for i, t := range tables {
+		names[i] = t.attr("name")
+		hidden[i] = hiddenStyles[t.attr("style-name")]
+	}
+	selected, err := selectSheets(names, hidden, opts)
+	if err != nil {
+		return "", fmt.Errorf("ods: %w", err)
+	}
+
+	var sheets []*sheet
+	for _, i := range selected {
+		sheets = append(sheets, odsSheet(tables[i], opts))
+	}
+
+	if filename == "" {
+		filename = "spreadsheet.ods"
+	}
+	return renderSheets(filename, sheets, len(tables) > 1, opts), nil
+}
+
+// odsHiddenTableStyles returns the automatic table styles that hide a sheet.
+func odsHiddenTableStyles(root *xmlNode) map[string]bool {
+	hidden := make(map[string]bool)
+	styles := root.child("automatic-styles")
+	if styles == nil {
+		return hidden
+	}
+	for _, s := range styles.all("style") {
+		if p := s.child("table-properties"); p != nil && p.attr("display") == "false" {
+			hidden[s.attr("name")] = true
+		}
+	}
+	return hidden
+}
+
+// odsSheet reads a table:table into a sheet. Cells covered by a merged cell
+// take its value.
+func odsSheet(tbl *xmlNode, opts *SheetOptions) *sheet {
+	s := &sheet{name: tbl.attr("name"), hiddenRows: make(map[int]bool), hiddenCols: make(map[int]bool)}
+	formulas := make(map[[2]int]string)
+	spans := make(map[[2]int]string) // covered cell -> value of the merged cell
+
+	col := 0
+	var columns func(n *xmlNode)
+	columns = func(n *xmlNode) {
+		for _, c := range n.children {
+			switch {
+			case c.is("table-column"):
+				repeat := odsRepeat(c.attr("number-columns-repeated"), maxSheetCols-col)
+				if c.attr("visibility") == "collapse" {
+					for i := range repeat {
+						s.hiddenCols[col+i] = true
+					}
+				}
+				col += repeat
+			case c.is("table-columns"), c.is("table-header-columns"), c.is("table-column-group"):
+				columns(c)
+			}
+		}
+	}
+	columns(tbl)
+
+	pendingEmpty := 0 // empty rows are only kept when content follows
+	var rows func(n *xmlNode)
+	rows = func(n *xmlNode) {
+		for _, tr := range n.children {
+			switch {
+			case tr.is("table-row"):
+				repeat := odsRepeat(tr.attr("number-rows-repeated"), maxSheetRows-len(s.rows)-pendingEmpty)
+				cells, rowFormulas := odsRow(tr, len(s.rows)+pendingEmpty, spans, opts.Formulas)
+				if cells == nil {
+					pendingEmpty += repeat
+					continue
+				}
+				for range pendingEmpty {
+					s.rows = append(s.rows, nil)
+				}
+				pendingEmpty = 0
+				for range repeat {
+					r := len(s.rows)
+					if v := tr.attr("visibility"); v == "collapse" || v == "filter" {
+						s.hiddenRows[r] = true
+					}
+					for c, f := range rowFormulas {
+						formulas[[2]int{r, c}] = f
+					}
+					s.rows = append(s.rows, slices.Clone(cells))
+				}
+			case tr.is("table-rows"), tr.is("table-header-rows"), tr.is("table-row-group"):
+				rows(tr)
+			}
+		}
+	}
+	rows(tbl)
+
+	if opts.Formulas {
+		s.formula = func(row, col int) string { return formulas[[2]int{row, col}] }
+	}
+	return s
+}
+
+// odsRow reads the cells of a table-row at row index r, recording the cells
+// a merged cell covers in spans. It returns nil cells for an empty row.
+func odsRow(tr *xmlNode, r int, spans map[[2]int]string, withFormulas bool) ([]string, map[int]string) {
+	var (
+		cells    []string
+		formulas map[int]string
+		col      int
+	)
+	for _, tc := range tr.children {
+		covered := tc.is("covered-table-cell")
+		if !covered && !tc.is("table-cell") {
+			continue
+		}
+		if col >= maxSheetCols {
+			break
+		}
+		repeat := odsRepeat(tc.attr("number-columns-repeated"), maxSheetCols-col)
+		text := odsCellText(tc)
+		formula := ""
+		if withFormulas {
+			formula = odsFormula(tc.attr("formula"))
+		}
+		if !covered && text == "" && formula == "" {
+			col += repeat
+			continue
+		}
+
+		colSpan := odsRepeat(tc.attr("number-columns-spanned"), maxSheetCols-col)
+		rowSpan := odsRepeat(tc.attr("number-rows-spanned"), maxSheetRows-r)
+		for i := range repeat {
+			c := col + i
+			v := text
+			if covered {
+				v = spans[[2]int{r, c}]
+			}
+			if v == "" && formula == "" {
+				continue
+			}
+			for len(cells) < c {
+				cells = append(cells, "")
+			}
+			cells = append(cells, v)
+			if formula != "" {
+				if formulas == nil {
+					formulas = make(map[int]string)
+				}
+				formulas[c] = formula
+			}
+			if !covered && v != "" {
+				for dr := range min(rowSpan, 1000) {
+					for dc := range min(colSpan, 1000) {
+						if dr > 0 || dc > 0 {
+							spans[[2]int{r + dr, c + dc}] = v
+						}
+					}
+				}
+			}
+		}
+		col += repeat
+	}
+	return cells, formulas
+}
+
+// odsRepeat parses a repeat or span count, at least 1 and at most limit.
+func odsRepeat(s string, limit int) int {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		n = 1
+	}
+	return max(min(n, limit), 1)
+}
+
+func odsCellText(tc *xmlNode) string {
+	var parts []string
+	for _, p := range tc.children {
+		if p.is("p") || p.is("h") {
+			if t := collapseSpace(odfText(p)); t != "" {
+				parts = append(parts, t)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// odsCellRef matches a cell or range reference within the current sheet,
+// such as [.B3] or [.A1:.A9].
+var odsCellRef = regexp.MustCompile(`\[\.([A-Z$]+[0-9$]+)(?::\.([A-Z$]+[0-9$]+))?\]`)
+
+// odsFormula converts an OpenFormula expression such as "of:=[.B3]+[.C3]"
+// to the spreadsheet notation "B3+C3".
+func odsFormula(f string) string {
+	if f == "" {
+		return ""
+	}
+	// a namespace prefix such as "of:" or "msoxl:" precedes the expression
+	if i := strings.Index(f, ":="); i >= 0 && !strings.ContainsAny(f[:i], "[(") {
+		f = f[i+2:]
+	}
+	f = strings.TrimPrefix(f, "=")
+	return odsCellRef.ReplaceAllStringFunc(f, func(ref string) string {
+		m := odsCellRef.FindStringSubmatch(ref)
+		if m[2] != "" {
+			return m[1] + ":" + m[2]
+		}
+		return m[1]
+	})
+}
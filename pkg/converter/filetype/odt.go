@@ -1,107 +1,50 @@
 package filetype
 
 import (
-	"archive/zip"
-	"bytes"
-	"encoding/xml"
 	"fmt"
-	"io"
 	"strings"
 )
 
-// ConvertODT converts OpenDocument Text (.odt) bytes to markdown.
+// ConvertODT converts OpenDocument Text (.odt) bytes to markdown. Headings
+// keep their outline level, and lists, tables, links and bold, italic and
+// strikethrough text are preserved.
 func ConvertODT(data []byte, filename string) (string, error) {
-	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	zr, err := openZip(data)
 	if err != nil {
 		return "", fmt.Errorf("odt open zip: %w", err)
 	}
+	root, err := zr.xml("content.xml")
+	if err != nil {
+		return "", fmt.Errorf("odt: %w", err)
+	}
+	var text *xmlNode
+	if body := root.child("body"); body != nil {
+		text = body.child("text")
+	}
+	if text == nil {
+		return "", fmt.Errorf("odt: document body not found")
+	}
 
-	var contentXML []byte
-	for _, f := range zr.File {
-		if f.Name == "content.xml" {
-			rc, err := f.Open()
-			if err != nil {
-				return "", fmt.Errorf("odt open content.xml: %w", err)
-			}
-			contentXML, err = io.ReadAll(rc)
-			rc.Close()
-			if err != nil {
-				return "", fmt.Errorf("odt read content.xml: %w", err)
+	r := &odfRenderer{lists: odfListStyles(root), styles: make(map[string]odfStyle)}
+	// common styles such as "Strong Emphasis" live in styles.xml
+	if styles, err := zr.xml("styles.xml"); err == nil {
+		for name, numbered := range odfListStyles(styles) {
+			if _, ok := r.lists[name]; !ok {
+				r.lists[name] = numbered
 			}
-			break
 		}
+		odfTextStyles(r.styles, styles)
 	}
-
-	if contentXML == nil {
-		return "", fmt.Errorf("odt: content.xml not found")
-	}
-
-	text := extractODTText(contentXML)
+	odfTextStyles(r.styles, root)
 
 	if filename == "" {
 		filename = "document.odt"
 	}
-
 	var md strings.Builder
 	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
-	md.WriteString(text)
-	md.WriteString("\n")
-
-	return strings.TrimSpace(md.String()), nil
-}
-
-// extractODTText walks the ODF XML and extracts paragraphs and headings.
-func extractODTText(data []byte) string {
-	decoder := xml.NewDecoder(bytes.NewReader(data))
-	var result strings.Builder
-	var inParagraph, inHeading bool
-	var lineBuffer strings.Builder
-
-	for {
-		tok, err := decoder.Token()
-		if err != nil {
-			break
-		}
-
-		switch t := tok.(type) {
-		case xml.StartElement:
-			local := t.Name.Local
-			switch local {
-			case "p":
-				inParagraph = true
-				lineBuffer.Reset()
-			case "h":
-				inHeading = true
-				lineBuffer.Reset()
-			case "tab":
-				lineBuffer.WriteString("\t")
-			case "s":
-				lineBuffer.WriteString(" ")
-			case "line-break":
-				lineBuffer.WriteString("\n")
-			}
-		case xml.EndElement:
-			local := t.Name.Local
-			switch local {
-			case "p":
-				if inParagraph {
-					result.WriteString(lineBuffer.String())
-					result.WriteString("\n\n")
-					inParagraph = false
-				}
-			case "h":
-				if inHeading {
-					result.WriteString("## " + lineBuffer.String())
-					result.WriteString("\n\n")
-					inHeading = false
-				}
-			}
-		case xml.CharData:
-			if inParagraph || inHeading {
-				lineBuffer.Write(t)
-			}
-		}
+	for _, b := range r.blocks(text.children) {
+		md.WriteString(b)
+		md.WriteString("\n\n")
 	}
-
-	return strings.TrimSpace(result.String())
+	return strings.TrimSpace(md.String()), nil
 }
@@ -0,0 +1,135 @@
+package filetype
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxZipEntry bounds how much of a single archive entry is read.
+const maxZipEntry = 64 << 20
+
+// relNS is the namespace of r:id style attributes that reference a part's
+// relationships.
+const relNS = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+
+// zipArchive gives access to the entries of a ZIP-based document.
+type zipArchive struct {
+	files map[string]*zip.File
+	order []string
+}
+
+func openZip(data []byte) (*zipArchive, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	a := &zipArchive{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+		a.order = append(a.order, f.Name)
+	}
+	return a, nil
+}
+
+// read returns the contents of the named entry.
+func (a *zipArchive) read(name string) ([]byte, error) {
+	f, ok := a.files[name]
+	if !ok {
+		return nil, fmt.Errorf("%s not found", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipEntry+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxZipEntry {
+		return nil, fmt.Errorf("%s is too large", name)
+	}
+	return data, nil
+}
+
+// xml parses the named entry as XML.
+func (a *zipArchive) xml(name string) (*xmlNode, error) {
+	data, err := a.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return parseXMLTree(data)
+}
+
+// rels reads the OOXML relationships of a part, keyed by relationship ID,
+// with internal targets resolved against the part's directory.
+func (a *zipArchive) rels(part string) map[string]relationship {
+	dir, file := path.Split(part)
+	root, err := a.xml(dir + "_rels/" + file + ".rels")
+	if err != nil {
+		return nil
+	}
+	out := make(map[string]relationship)
+	for _, r := range root.all("Relationship") {
+		target := r.attr("Target")
+		if r.attr("TargetMode") != "External" {
+			if strings.HasPrefix(target, "/") {
+				target = strings.TrimPrefix(target, "/")
+			} else {
+				target = path.Join(dir, target)
+			}
+		}
+		out[r.attr("Id")] = relationship{target: target, kind: path.Base(r.attr("Type"))}
+	}
+	return out
+}
+
+// relationship is a resolved OOXML relationship; kind is the last segment of
+// the relationship type URI, e.g. "slide" or "notesSlide".
+type relationship struct {
+	target string
+	kind   string
+}
+
+// markdownTable renders rows as a pipe table with the first row as header.
+func markdownTable(rows [][]string) string {
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return ""
+	}
+
+	var md strings.Builder
+	for i, row := range rows {
+		md.WriteString("|")
+		for j := 0; j < cols; j++ {
+			cell := ""
+			if j < len(row) {
+				cell = collapseSpace(row[j])
+				cell = strings.ReplaceAll(cell, "|", "\\|")
+			}
+			md.WriteString(" " + cell + " |")
+		}
+		md.WriteString("\n")
+
+		if i == 0 {
+			md.WriteString("|")
+			for j := 0; j < cols; j++ {
+				md.WriteString(" --- |")
+			}
+			md.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(md.String(), "\n")
+}
+
+// collapseSpace trims s and collapses runs of whitespace to single spaces.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
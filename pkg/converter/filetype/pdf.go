@@ -2,6 +2,7 @@ package filetype
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/url"
 	"path"
@@ -10,46 +11,92 @@ import (
 	"github.com/ledongthuc/pdf"
 )
 
-// ConvertPDF extracts text from PDF bytes and returns markdown.
-func ConvertPDF(data []byte, filename string) (string, error) {
+// PDFOptions configures PDF conversion.
+type PDFOptions struct {
+	Vision *VisionConfig // OCR pages without text when configured
+	Pages  PageRanges    // convert only these pages; empty converts all
+}
+
+// ConvertPDF extracts text from PDF bytes and returns markdown along with the
+// document metadata. Headings, paragraphs, lists and tables are reconstructed
+// from the text layout; pages whose layout cannot be read fall back to plain
+// text. Pages without any text, such as scans, are run through vision OCR
+// when vision is configured. The outline, if any, becomes a table of contents.
+func ConvertPDF(ctx context.Context, data []byte, filename string, opts *PDFOptions) (string, *DocMeta, error) {
 	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		return "", fmt.Errorf("pdf open: %w", err)
+		return "", nil, fmt.Errorf("pdf open: %w", err)
+	}
+	if opts == nil {
+		opts = &PDFOptions{}
+	}
+
+	meta := readPDFMeta(reader)
+	if len(opts.Pages) > 0 {
+		meta.Pages = opts.Pages.String()
 	}
 
 	var md strings.Builder
 
-	// filename as title
-	if filename == "" {
-		filename = "document.pdf"
+	// document title, or filename as title
+	title := meta.Title
+	if title == "" {
+		title = filename
 	}
-	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	if title == "" {
+		title = "document.pdf"
+	}
+	md.WriteString(fmt.Sprintf("# %s\n\n", title))
 
-	numPages := reader.NumPage()
+	if toc := pdfOutline(reader); toc != "" {
+		md.WriteString("## Contents\n\n")
+		md.WriteString(toc)
+		md.WriteString("\n\n")
+	}
+
+	var ocr *pdfOCR
+	if opts.Vision != nil && opts.Vision.IsConfigured() {
+		ocr = &pdfOCR{vision: opts.Vision, file: data}
+	}
 
-	md.WriteString("## Contents\n\n")
-	for i := 1; i <= numPages; i++ {
+	var pages []pdfPage
+	for i := 1; i <= reader.NumPage(); i++ {
+		if !opts.Pages.Contains(i) {
+			continue
+		}
 		p := reader.Page(i)
 		if p.V.IsNull() {
 			continue
 		}
-
-		text, err := p.GetPlainText(nil)
-		if err != nil {
-			continue
+		page := extractPageLayout(p, i)
+		if ocr != nil && len(page.lines) == 0 && page.plain == "" {
+			page.plain = ocr.recognizePage(ctx, p, i)
 		}
+		pages = append(pages, page)
+	}
+	removeMargins(pages)
 
-		text = strings.TrimSpace(text)
-		if text == "" {
+	// lines flow across pages so paragraphs split by a page break are rejoined
+	var blocks []string
+	var lines []pdfLine
+	flush := func() {
+		if len(lines) > 0 {
+			blocks = append(blocks, renderLayout(lines)...)
+			lines = nil
+		}
+	}
+	for i := range pages {
+		if pages[i].plain != "" {
+			flush()
+			blocks = append(blocks, pages[i].plain)
 			continue
 		}
-
-		md.WriteString(fmt.Sprintf("### Page %d\n\n", i))
-		md.WriteString(text)
-		md.WriteString("\n\n")
+		lines = append(lines, orderColumns(&pages[i])...)
 	}
+	flush()
 
-	return strings.TrimSpace(md.String()), nil
+	md.WriteString(strings.Join(blocks, "\n\n"))
+	return strings.TrimSpace(md.String()), meta, nil
 }
 
 // FilenameFromURL extracts a filename from a URL, decoding percent-encoding.
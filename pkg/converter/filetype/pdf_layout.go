@@ -0,0 +1,647 @@
+package filetype
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// Layout thresholds, in multiples of the font size.
+const (
+	wordGapEm      = 0.15 // horizontal gap that implies a space
+	cellGapEm      = 2.0  // horizontal gap that separates table cells or columns
+	lineTolEm      = 0.5  // vertical distance within which glyphs share a line
+	paragraphGapEm = 1.6  // vertical distance that starts a new paragraph
+	headingRatio   = 1.15 // font size relative to body text that marks a heading
+	marginBand     = 0.08 // fraction of page height treated as header/footer area
+)
+
+// pdfSpan is a run of text on a line without a cell-sized gap.
+type pdfSpan struct {
+	x0, x1 float64
+	text   string
+}
+
+// pdfLine is one visual line of text.
+type pdfLine struct {
+	spans  []pdfSpan
+	x0, x1 float64
+	y      float64
+	size   float64 // dominant font size
+	bold   bool
+	page   int
+	col    int     // 0 full width, 1 left column, 2 right column
+	right  float64 // right edge of the text flow the line belongs to
+}
+
+func (l *pdfLine) text() string {
+	parts := make([]string, len(l.spans))
+	for i, s := range l.spans {
+		parts[i] = s.text
+	}
+	return strings.Join(parts, " ")
+}
+
+// pdfPage is the extracted layout of one page.
+type pdfPage struct {
+	num           int
+	width, height float64
+	lines         []pdfLine // top to bottom
+	plain         string    // plain text fallback when positions are unusable
+}
+
+// extractPageLayout reads glyph positions from the page content stream. It
+// falls back to plain text when the stream cannot be interpreted or the fonts
+// carry no width information.
+func extractPageLayout(p pdf.Page, num int) pdfPage {
+	page := pdfPage{num: num, width: 612, height: 792}
+	if box := mediaBox(p.V); box.Kind() == pdf.Array && box.Len() == 4 {
+		if w := box.Index(2).Float64() - box.Index(0).Float64(); w > 0 {
+			page.width = w
+		}
+		if h := box.Index(3).Float64() - box.Index(1).Float64(); h > 0 {
+			page.height = h
+		}
+	}
+
+	glyphs, ok := pageGlyphs(p)
+	if ok && usableGlyphs(glyphs) {
+		page.lines = buildLines(glyphs, num)
+		return page
+	}
+
+	text, _ := plainText(p)
+	page.plain = strings.TrimSpace(text)
+	return page
+}
+
+// mediaBox returns the page's MediaBox, which may be inherited from an
+// ancestor in the page tree.
+func mediaBox(v pdf.Value) pdf.Value {
+	for depth := 0; depth < 32 && !v.IsNull(); depth++ {
+		if box := v.Key("MediaBox"); !box.IsNull() {
+			return box
+		}
+		v = v.Key("Parent")
+	}
+	return pdf.Value{}
+}
+
+// pageGlyphs returns the page's glyphs; the pdf package panics on malformed
+// content streams.
+func pageGlyphs(p pdf.Page) (glyphs []pdf.Text, ok bool) {
+	defer func() {
+		if recover() != nil {
+			glyphs, ok = nil, false
+		}
+	}()
+	return p.Content().Text, true
+}
+
+func plainText(p pdf.Page) (text string, err error) {
+	defer func() {
+		if recover() != nil {
+			text = ""
+		}
+	}()
+	return p.GetPlainText(nil)
+}
+
+// usableGlyphs reports whether glyph widths are known; without them every
+// glyph is placed at the same position.
+func usableGlyphs(glyphs []pdf.Text) bool {
+	visible, sized := 0, 0
+	for _, g := range glyphs {
+		if strings.TrimSpace(g.S) == "" {
+			continue
+		}
+		visible++
+		if g.W > 0 {
+			sized++
+		}
+	}
+	return visible > 0 && sized*2 >= visible
+}
+
+func isBoldFont(name string) bool {
+	n := strings.ToLower(name)
+	return strings.Contains(n, "bold") || strings.Contains(n, "black") || strings.Contains(n, "heavy")
+}
+
+// buildLines groups glyphs into lines and lines into spans.
+func buildLines(glyphs []pdf.Text, pageNum int) []pdfLine {
+	var gs []pdf.Text
+	for _, g := range glyphs {
+		g.FontSize = math.Abs(g.FontSize)
+		if g.S == "" || g.S == "\n" || g.S == "\r" || g.FontSize == 0 {
+			continue
+		}
+		gs = append(gs, g)
+	}
+	sort.SliceStable(gs, func(i, j int) bool {
+		if gs[i].Y != gs[j].Y {
+			return gs[i].Y > gs[j].Y
+		}
+		return gs[i].X < gs[j].X
+	})
+
+	var lines []pdfLine
+	for start := 0; start < len(gs); {
+		end := start + 1
+		for end < len(gs) && gs[start].Y-gs[end].Y <= lineTolEm*math.Max(gs[start].FontSize, gs[end].FontSize) {
+			end++
+		}
+		if line, ok := makeLine(gs[start:end], pageNum); ok {
+			lines = append(lines, line)
+		}
+		start = end
+	}
+	return lines
+}
+
+func makeLine(group []pdf.Text, pageNum int) (pdfLine, bool) {
+	sort.SliceStable(group, func(i, j int) bool { return group[i].X < group[j].X })
+
+	line := pdfLine{page: pageNum, y: group[0].Y}
+	sizes := make(map[float64]int)
+	var (
+		cur          strings.Builder
+		spanX0, end  float64
+		pendingSpace bool
+		lastX        = math.Inf(-1)
+		lastS        string
+		visible      int
+		bold         int
+	)
+	flush := func() {
+		if text := strings.TrimSpace(cur.String()); text != "" {
+			line.spans = append(line.spans, pdfSpan{x0: spanX0, x1: end, text: text})
+		}
+		cur.Reset()
+	}
+
+	for _, g := range group {
+		w := g.W
+		if w <= 0 {
+			w = 0.5 * g.FontSize
+		}
+		if strings.TrimSpace(g.S) == "" {
+			pendingSpace = true
+			continue
+		}
+		// fake bold: the same glyph drawn twice with a small offset
+		if g.S == lastS && g.X-lastX < 0.2*g.FontSize {
+			continue
+		}
+
+		if cur.Len() > 0 {
+			gap := g.X - end
+			switch {
+			case gap > cellGapEm*g.FontSize:
+				flush()
+			case pendingSpace || gap > wordGapEm*g.FontSize:
+				cur.WriteByte(' ')
+			}
+		}
+		if cur.Len() == 0 {
+			spanX0 = g.X
+		}
+		cur.WriteString(g.S)
+		end = g.X + w
+		pendingSpace = false
+		lastX, lastS = g.X, g.S
+
+		visible++
+		sizes[math.Round(g.FontSize*2)/2]++
+		if isBoldFont(g.Font) {
+			bold++
+		}
+	}
+	flush()
+	if len(line.spans) == 0 {
+		return line, false
+	}
+
+	line.x0 = line.spans[0].x0
+	line.x1 = line.spans[len(line.spans)-1].x1
+	line.size = dominantSize(sizes)
+	line.bold = bold*10 >= visible*9
+	return line, true
+}
+
+func dominantSize(sizes map[float64]int) float64 {
+	best, bestN := 0.0, -1
+	for s, n := range sizes {
+		if n > bestN || (n == bestN && s > best) {
+			best, bestN = s, n
+		}
+	}
+	return best
+}
+
+var (
+	pageNumberPattern = regexp.MustCompile(`(?i)^(page\s*)?\d{1,4}(\s*(of|/)\s*\d{1,4})?$`)
+	digitsPattern     = regexp.MustCompile(`\d+`)
+)
+
+// removeMargins drops running headers and footers: lines near the top or
+// bottom of the page that repeat on many pages, and bare page numbers.
+func removeMargins(pages []pdfPage) {
+	isMargin := func(p *pdfPage, i int) bool {
+		l := p.lines[i]
+		inBand := l.y > p.height*(1-marginBand) || l.y < p.height*marginBand
+		return inBand && (i < 2 || i >= len(p.lines)-2)
+	}
+	key := func(l *pdfLine) string {
+		return digitsPattern.ReplaceAllString(strings.ToLower(strings.Join(strings.Fields(l.text()), " ")), "#")
+	}
+
+	counts := make(map[string]int)
+	withText := 0
+	for pi := range pages {
+		p := &pages[pi]
+		if len(p.lines) > 0 {
+			withText++
+		}
+		seen := make(map[string]bool)
+		for i := range p.lines {
+			if isMargin(p, i) {
+				if k := key(&p.lines[i]); !seen[k] {
+					seen[k] = true
+					counts[k]++
+				}
+			}
+		}
+	}
+
+	for pi := range pages {
+		p := &pages[pi]
+		var kept []pdfLine
+		for i := range p.lines {
+			if isMargin(p, i) {
+				text := strings.TrimSpace(p.lines[i].text())
+				if pageNumberPattern.MatchString(text) {
+					continue
+				}
+				if n := counts[key(&p.lines[i])]; n >= 3 && n*2 >= withText {
+					continue
+				}
+			}
+			kept = append(kept, p.lines[i])
+		}
+		p.lines = kept
+	}
+}
+
+// orderColumns returns the page's lines in reading order. On two-column pages
+// each column is read top to bottom, with full-width lines (titles, figures)
+// acting as separators between column blocks.
+func orderColumns(p *pdfPage) []pdfLine {
+	mid := p.width / 2
+	gutter := p.width * 0.02
+
+	var left, right int
+	for _, l := range p.lines {
+		for _, s := range l.spans {
+			switch {
+			case s.x1 < mid+gutter && s.x1-s.x0 > p.width*0.2:
+				left++
+			case s.x0 > mid-gutter && s.x1-s.x0 > p.width*0.2:
+				right++
+			}
+		}
+	}
+	twoColumn := left >= 5 && right >= 5
+
+	if !twoColumn {
+		out := make([]pdfLine, len(p.lines))
+		copy(out, p.lines)
+		setFlowRight(out)
+		return out
+	}
+
+	var out, leftCol, rightCol []pdfLine
+	flushColumns := func() {
+		setFlowRight(leftCol)
+		setFlowRight(rightCol)
+		out = append(out, leftCol...)
+		out = append(out, rightCol...)
+		leftCol, rightCol = nil, nil
+	}
+	for _, l := range p.lines {
+		var ls, rs []pdfSpan
+		spanning := false
+		for _, s := range l.spans {
+			switch {
+			case s.x1 < mid+gutter:
+				ls = append(ls, s)
+			case s.x0 > mid-gutter:
+				rs = append(rs, s)
+			default:
+				spanning = true
+			}
+		}
+		if spanning {
+			flushColumns()
+			full := []pdfLine{l}
+			setFlowRight(full)
+			out = append(out, full...)
+			continue
+		}
+		if len(ls) > 0 {
+			leftCol = append(leftCol, subLine(l, ls, 1))
+		}
+		if len(rs) > 0 {
+			rightCol = append(rightCol, subLine(l, rs, 2))
+		}
+	}
+	flushColumns()
+	return out
+}
+
+func subLine(l pdfLine, spans []pdfSpan, col int) pdfLine {
+	l.spans = spans
+	l.x0 = spans[0].x0
+	l.x1 = spans[len(spans)-1].x1
+	l.col = col
+	return l
+}
+
+func setFlowRight(lines []pdfLine) {
+	right := 0.0
+	for _, l := range lines {
+		right = math.Max(right, l.x1)
+	}
+	for i := range lines {
+		lines[i].right = right
+	}
+}
+
+// bodySize returns the most common font size, weighted by text length.
+func bodySize(lines []pdfLine) float64 {
+	sizes := make(map[float64]int)
+	for _, l := range lines {
+		sizes[l.size] += utf8.RuneCountInString(l.text())
+	}
+	return dominantSize(sizes)
+}
+
+// headingLevels maps font sizes noticeably larger than the body text to
+// markdown heading levels, largest first, starting at ##.
+func headingLevels(lines []pdfLine, body float64) map[float64]int {
+	var sizes []float64
+	seen := make(map[float64]bool)
+	for _, l := range lines {
+		if l.size >= body*headingRatio && !seen[l.size] && utf8.RuneCountInString(l.text()) <= 200 {
+			seen[l.size] = true
+			sizes = append(sizes, l.size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+
+	levels := make(map[float64]int, len(sizes))
+	for i, s := range sizes {
+		levels[s] = min(2+i, 4)
+	}
+	return levels
+}
+
+var (
+	bulletPattern   = regexp.MustCompile(`^([•●▪■◦○‣∙·–—*-])\s*`)
+	numberedPattern = regexp.MustCompile(`^\(?(\d{1,3}|[a-zA-Z])[.)]\s+`)
+)
+
+// renderLayout turns ordered lines into markdown blocks.
+func renderLayout(lines []pdfLine) []string {
+	body := bodySize(lines)
+	levels := headingLevels(lines, body)
+	boldLevel := 2 + len(levels)
+	if boldLevel > 4 {
+		boldLevel = 4
+	}
+
+	var (
+		blocks []string
+		para   []string
+		item   strings.Builder
+		inItem bool
+		prev   *pdfLine
+	)
+	flushPara := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, joinLines(para))
+			para = nil
+		}
+	}
+	flushItem := func() {
+		if inItem {
+			blocks = appendListItem(blocks, item.String())
+			item.Reset()
+			inItem = false
+		}
+	}
+	flush := func() { flushPara(); flushItem() }
+
+	for i := 0; i < len(lines); i++ {
+		l := &lines[i]
+
+		if rows := tableRun(lines, i); rows > 1 {
+			flush()
+			blocks = append(blocks, renderTable(lines[i:i+rows]))
+			i += rows - 1
+			prev = nil
+			continue
+		}
+
+		text := l.text()
+		if level, ok := levels[l.size]; ok {
+			flush()
+			// headings wrapped over several lines share size and are close together
+			if n := len(blocks); n > 0 && prev != nil && prev.size == l.size && sameFlow(prev, l) &&
+				prev.y-l.y < l.size*paragraphGapEm && strings.HasPrefix(blocks[n-1], strings.Repeat("#", level)+" ") {
+				blocks[n-1] += " " + text
+			} else {
+				blocks = append(blocks, strings.Repeat("#", level)+" "+text)
+			}
+			prev = l
+			continue
+		}
+		if l.bold && l.size <= body && isBoldHeading(text) && (prev == nil || !prev.bold || newParagraph(prev, l)) {
+			flush()
+			blocks = append(blocks, strings.Repeat("#", boldLevel)+" "+text)
+			prev = l
+			continue
+		}
+
+		if m := bulletPattern.FindStringSubmatch(text); m != nil && len(text) > len(m[0]) {
+			flush()
+			item.WriteString("- " + text[len(m[0]):])
+			inItem = true
+			prev = l
+			continue
+		}
+		if numberedPattern.MatchString(text) && !pageNumberPattern.MatchString(text) {
+			flush()
+			item.WriteString(text)
+			inItem = true
+			prev = l
+			continue
+		}
+
+		if inItem {
+			// hanging indent continues the list item
+			if prev != nil && sameFlow(prev, l) && !newParagraph(prev, l) && l.x0 > prev.x0-l.size {
+				item.WriteString("\n" + text)
+				prev = l
+				continue
+			}
+			flushItem()
+		}
+
+		if len(para) > 0 && prev != nil && newParagraph(prev, l) {
+			flushPara()
+		}
+		para = append(para, text)
+		prev = l
+	}
+	flush()
+	return blocks
+}
+
+// appendListItem adds a list item, joining it to a preceding list block so
+// items render as one list.
+func appendListItem(blocks []string, raw string) []string {
+	text := joinLines(strings.Split(raw, "\n"))
+	if n := len(blocks); n > 0 && isListBlock(blocks[n-1]) {
+		blocks[n-1] += "\n" + text
+		return blocks
+	}
+	return append(blocks, text)
+}
+
+func isListBlock(block string) bool {
+	last := block[strings.LastIndexByte(block, '\n')+1:]
+	return strings.HasPrefix(last, "- ") || numberedPattern.MatchString(last)
+}
+
+func sameFlow(a, b *pdfLine) bool {
+	return a.page == b.page && a.col == b.col
+}
+
+// newParagraph reports whether cur starts a new paragraph after prev.
+func newParagraph(prev, cur *pdfLine) bool {
+	if !sameFlow(prev, cur) {
+		// a paragraph continues across a page or column break only mid-sentence
+		return endsSentence(prev.text()) || !startsLower(cur.text())
+	}
+	gap := prev.y - cur.y
+	switch {
+	case gap > paragraphGapEm*math.Max(prev.size, cur.size) || gap < 0:
+		return true
+	case math.Abs(prev.size-cur.size) > 0.5:
+		return true
+	case cur.x0 > prev.x0+cur.size && cur.x0-prev.x0 < 4*cur.size:
+		return true // first-line indent
+	case endsSentence(prev.text()) && prev.x1 < prev.right-(prev.right-prev.x0)*0.15:
+		return true // short last line of a paragraph
+	}
+	return false
+}
+
+func endsSentence(s string) bool {
+	s = strings.TrimRight(s, " \"'”’)")
+	return strings.HasSuffix(s, ".") || strings.HasSuffix(s, "!") || strings.HasSuffix(s, "?") || strings.HasSuffix(s, ":")
+}
+
+func startsLower(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLower(r)
+}
+
+func isBoldHeading(text string) bool {
+	n := utf8.RuneCountInString(text)
+	return n >= 2 && n <= 80 && !strings.HasSuffix(text, ".") && !strings.HasSuffix(text, ",")
+}
+
+// joinLines reflows lines into a paragraph, removing hyphenation at line ends.
+func joinLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			prev := b.String()
+			if strings.HasSuffix(prev, "-") && startsLower(line) && len(prev) > 1 {
+				r, _ := utf8.DecodeLastRuneInString(prev[:len(prev)-1])
+				if unicode.IsLetter(r) {
+					b.Reset()
+					b.WriteString(prev[:len(prev)-1])
+					b.WriteString(line)
+					continue
+				}
+			}
+			b.WriteByte(' ')
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// tableRun returns how many consecutive lines starting at i form a table:
+// lines with the same number (at least two) of horizontally aligned cells.
+func tableRun(lines []pdfLine, i int) int {
+	first := &lines[i]
+	cols := len(first.spans)
+	if cols < 2 {
+		return 0
+	}
+	n := 1
+	for j := i + 1; j < len(lines); j++ {
+		l := &lines[j]
+		if len(l.spans) != cols || !sameFlow(first, l) || !alignedCells(first, l) {
+			break
+		}
+		if lines[j-1].y-l.y > 3*l.size {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+func alignedCells(a, b *pdfLine) bool {
+	for k := range a.spans {
+		sa, sb := a.spans[k], b.spans[k]
+		tol := math.Max(a.size, b.size) * 2
+		overlap := sa.x0 < sb.x1 && sb.x0 < sa.x1
+		if !overlap && math.Abs(sa.x0-sb.x0) > tol && math.Abs(sa.x1-sb.x1) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func renderTable(rows []pdfLine) string {
+	var b strings.Builder
+	for r, row := range rows {
+		b.WriteString("|")
+		for _, cell := range row.spans {
+			b.WriteString(" ")
+			b.WriteString(strings.ReplaceAll(cell.text, "|", "\\|"))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+		if r == 0 {
+			b.WriteString("|")
+			for range row.spans {
+				b.WriteString(" --- |")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
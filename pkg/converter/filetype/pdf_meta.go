@@ -0,0 +1,317 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// DocMeta is document-level metadata read from a file, such as the PDF Info
+// dictionary and XMP packet or the EPUB package metadata.
+type DocMeta struct {
+	Title     string
+	Author    string
+	Subject   string
+	Keywords  string
+	Language  string
+	Creator   string // application that created the original document
+	Producer  string // application that produced the file
+	Created   string // RFC 3339 when the source date could be parsed
+	Modified  string
+	PageCount int
+	Pages     string // selected page ranges, empty when all pages were converted
+}
+
+// Fields returns the non-empty metadata as a flat map for Result.Metadata.
+func (m *DocMeta) Fields() map[string]string {
+	fields := make(map[string]string)
+	set := func(k, v string) {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	set("author", m.Author)
+	set("subject", m.Subject)
+	set("keywords", m.Keywords)
+	set("language", m.Language)
+	set("creator", m.Creator)
+	set("producer", m.Producer)
+	set("created", m.Created)
+	set("modified", m.Modified)
+	if m.PageCount > 0 {
+		fields["page_count"] = strconv.Itoa(m.PageCount)
+	}
+	set("pages", m.Pages)
+	return fields
+}
+
+// ErrInvalidPages is returned for malformed page range selections.
+var ErrInvalidPages = errors.New("invalid page range")
+
+// PageRanges is a set of 1-based page ranges, e.g. parsed from "1-5,10,20-".
+type PageRanges []PageRange
+
+// PageRange is an inclusive range of pages; To is 0 for an open-ended range.
+type PageRange struct {
+	From, To int
+}
+
+// ParsePageRanges parses a comma-separated list of pages and ranges such as
+// "1-5,10" or "20-". An empty string selects all pages and returns nil.
+func ParsePageRanges(s string) (PageRanges, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	var ranges PageRanges
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+		r := PageRange{}
+		var err error
+		if r.From, err = strconv.Atoi(strings.TrimSpace(from)); err != nil || r.From < 1 {
+			return nil, fmt.Errorf("%w %q", ErrInvalidPages, part)
+		}
+		switch {
+		case !isRange:
+			r.To = r.From
+		case strings.TrimSpace(to) == "":
+			r.To = 0
+		default:
+			if r.To, err = strconv.Atoi(strings.TrimSpace(to)); err != nil || r.To < r.From {
+				return nil, fmt.Errorf("%w %q", ErrInvalidPages, part)
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// Contains reports whether page n is selected; empty ranges select every page.
+func (r PageRanges) Contains(n int) bool {
+	if len(r) == 0 {
+		return true
+	}
+	for _, pr := range r {
+		if n >= pr.From && (pr.To == 0 || n <= pr.To) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r PageRanges) String() string {
+	parts := make([]string, len(r))
+	for i, pr := range r {
+		switch {
+		case pr.To == pr.From:
+			parts[i] = strconv.Itoa(pr.From)
+		case pr.To == 0:
+			parts[i] = fmt.Sprintf("%d-", pr.From)
+		default:
+			parts[i] = fmt.Sprintf("%d-%d", pr.From, pr.To)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// readPDFMeta reads the Info dictionary and the catalog's XMP packet. XMP
+// values take precedence; the Info dictionary fills the gaps.
+func readPDFMeta(reader *pdf.Reader) (meta *DocMeta) {
+	meta = &DocMeta{PageCount: reader.NumPage()}
+	// keep whatever was read before a malformed object panicked
+	defer func() { _ = recover() }()
+
+	trailer := reader.Trailer()
+	if stream := trailer.Key("Root").Key("Metadata"); stream.Kind() == pdf.Stream {
+		if data, err := io.ReadAll(io.LimitReader(stream.Reader(), 1<<20)); err == nil {
+			parseXMP(data, meta)
+		}
+	}
+
+	info := trailer.Key("Info")
+	fill := func(dst *string, key string) {
+		if *dst == "" {
+			*dst = strings.TrimSpace(info.Key(key).Text())
+		}
+	}
+	fill(&meta.Title, "Title")
+	fill(&meta.Author, "Author")
+	fill(&meta.Subject, "Subject")
+	fill(&meta.Keywords, "Keywords")
+	fill(&meta.Creator, "Creator")
+	fill(&meta.Producer, "Producer")
+	if meta.Created == "" {
+		meta.Created = pdfDate(info.Key("CreationDate").Text())
+	}
+	if meta.Modified == "" {
+		meta.Modified = pdfDate(info.Key("ModDate").Text())
+	}
+	return meta
+}
+
+// pdfDate converts a PDF date string (D:YYYYMMDDHHmmSSOHH'mm') to RFC 3339,
+// returning the input unchanged when it cannot be parsed.
+func pdfDate(s string) string {
+	s = strings.TrimSpace(s)
+	raw := strings.TrimPrefix(s, "D:")
+	if len(raw) < 4 {
+		return s
+	}
+
+	digits := raw
+	zone := ""
+	if i := strings.IndexAny(raw, "Zz+-"); i >= 0 {
+		digits, zone = raw[:i], raw[i:]
+	}
+	// pad missing month/day/time components with their defaults
+	const defaults = "00000101000000"
+	if len(digits) > len(defaults) {
+		return s
+	}
+	digits += defaults[len(digits):]
+
+	offset := "Z"
+	if zone != "" && zone[0] != 'Z' && zone[0] != 'z' {
+		z := strings.NewReplacer("'", "").Replace(zone[1:])
+		if len(z) == 2 {
+			z += "00"
+		}
+		if len(z) != 4 {
+			return s
+		}
+		offset = zone[:1] + z[:2] + ":" + z[2:]
+	}
+
+	t, err := time.Parse("20060102150405Z07:00", digits+offset)
+	if err != nil {
+		return s
+	}
+	return t.Format(time.RFC3339)
+}
+
+// XMP properties read into DocMeta, keyed by namespace and local name.
+var xmpFields = map[xml.Name]func(*DocMeta) *string{
+	{Space: "http://purl.org/dc/elements/1.1/", Local: "title"}:       func(m *DocMeta) *string { return &m.Title },
+	{Space: "http://purl.org/dc/elements/1.1/", Local: "creator"}:     func(m *DocMeta) *string { return &m.Author },
+	{Space: "http://purl.org/dc/elements/1.1/", Local: "description"}: func(m *DocMeta) *string { return &m.Subject },
+	{Space: "http://ns.adobe.com/pdf/1.3/", Local: "Keywords"}:        func(m *DocMeta) *string { return &m.Keywords },
+	{Space: "http://ns.adobe.com/pdf/1.3/", Local: "Producer"}:        func(m *DocMeta) *string { return &m.Producer },
+	{Space: "http://ns.adobe.com/xap/1.0/", Local: "CreatorTool"}:     func(m *DocMeta) *string { return &m.Creator },
+	{Space: "http://ns.adobe.com/xap/1.0/", Local: "CreateDate"}:      func(m *DocMeta) *string { return &m.Created },
+	{Space: "http://ns.adobe.com/xap/1.0/", Local: "ModifyDate"}:      func(m *DocMeta) *string { return &m.Modified },
+}
+
+const rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+
+// parseXMP reads the properties in xmpFields, written either as elements
+// (with rdf:Alt/Seq/Bag lists) or as attributes of rdf:Description.
+func parseXMP(data []byte, meta *DocMeta) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var (
+		field *string  // property being read, nil outside one
+		depth int      // element depth inside the property
+		items []string // rdf:li values
+		text  strings.Builder
+	)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if field != nil {
+				depth++
+				if t.Name.Space == rdfNS && t.Name.Local == "li" {
+					text.Reset()
+				}
+				continue
+			}
+			if t.Name.Space == rdfNS && t.Name.Local == "Description" {
+				for _, a := range t.Attr {
+					if f, ok := xmpFields[a.Name]; ok && *f(meta) == "" {
+						*f(meta) = strings.TrimSpace(a.Value)
+					}
+				}
+			}
+			if f, ok := xmpFields[t.Name]; ok && *f(meta) == "" {
+				field, depth, items = f(meta), 0, nil
+				text.Reset()
+			}
+		case xml.CharData:
+			if field != nil {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if field == nil {
+				continue
+			}
+			if depth > 0 {
+				depth--
+				if t.Name.Space == rdfNS && t.Name.Local == "li" {
+					if v := strings.TrimSpace(text.String()); v != "" {
+						items = append(items, v)
+					}
+					text.Reset()
+				}
+				continue
+			}
+			value := strings.TrimSpace(text.String())
+			if len(items) > 0 {
+				value = strings.Join(items, ", ")
+				// dc:title is a language alternative; keep the first
+				if field == &meta.Title || field == &meta.Subject {
+					value = items[0]
+				}
+			}
+			*field = value
+			field = nil
+		}
+	}
+}
+
+// pdfOutline renders the document outline (bookmarks) as a nested list.
+func pdfOutline(reader *pdf.Reader) (toc string) {
+	defer func() {
+		if recover() != nil {
+			toc = ""
+		}
+	}()
+
+	const (
+		maxDepth   = 4
+		maxEntries = 500
+	)
+	var (
+		b       strings.Builder
+		entries int
+	)
+	var walk func(parent pdf.Value, depth int)
+	walk = func(parent pdf.Value, depth int) {
+		// Next chains in malformed files can loop; entries bounds the walk
+		for item := parent.Key("First"); item.Kind() == pdf.Dict && entries < maxEntries; item = item.Key("Next") {
+			entries++
+			if title := strings.Join(strings.Fields(item.Key("Title").Text()), " "); title != "" {
+				b.WriteString(strings.Repeat("  ", depth))
+				b.WriteString("- ")
+				b.WriteString(title)
+				b.WriteString("\n")
+			}
+			if depth+1 < maxDepth {
+				walk(item, depth+1)
+			}
+		}
+	}
+	walk(reader.Trailer().Key("Root").Key("Outlines"), 0)
+	return strings.TrimRight(b.String(), "\n")
+}
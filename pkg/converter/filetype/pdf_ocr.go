@@ -0,0 +1,237 @@
+package filetype
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	minScanSide   = 200        // smaller images are logos or decorations, not page scans
+	maxScanPixels = 50_000_000 // larger rasters are skipped rather than decoded
+)
+
+// pdfImage is an embedded image ready to send to the vision model.
+type pdfImage struct {
+	data        []byte
+	contentType string
+}
+
+// pdfOCR recognizes text on pages that consist of scanned images.
+type pdfOCR struct {
+	vision *VisionConfig
+	file   []byte
+	raw    *pdf.Reader // file with DCT filters removed, opened lazily
+	ready  bool
+}
+
+// dctFilterPattern matches the Filter entry of JPEG image streams.
+var dctFilterPattern = regexp.MustCompile(`/Filter\s*(?:/DCTDecode|\[\s*/DCTDecode\s*\])`)
+
+// rawReader returns a reader over the file in which the DCTDecode filter
+// entries are blanked out. The pdf package cannot decode JPEG streams, but
+// without the filter its stream reader returns the JPEG bytes as they are,
+// still resolving indirect lengths and decrypting. Blanking keeps every byte
+// offset, so the cross-reference table stays valid.
+func (o *pdfOCR) rawReader() *pdf.Reader {
+	if !o.ready {
+		o.ready = true
+		blank := dctFilterPattern.ReplaceAllFunc(bytes.Clone(o.file), func(m []byte) []byte {
+			return bytes.Repeat([]byte(" "), len(m))
+		})
+		if r, err := pdf.NewReader(bytes.NewReader(blank), int64(len(blank))); err == nil {
+			o.raw = r
+		}
+	}
+	return o.raw
+}
+
+// recognizePage returns the text recognized on the images of page num, in
+// the order of their resource names.
+func (o *pdfOCR) recognizePage(ctx context.Context, p pdf.Page, num int) string {
+	var parts []string
+	for _, img := range o.pageImages(p, num) {
+		text, err := RecognizeText(ctx, o.vision, img.data, img.contentType)
+		if err != nil {
+			continue
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// pageImages collects the page-sized images drawn on page num, including
+// those nested in form XObjects.
+func (o *pdfOCR) pageImages(p pdf.Page, num int) (images []pdfImage) {
+	defer func() {
+		if recover() != nil {
+			images = nil
+		}
+	}()
+	var raw pdf.Value
+	if r := o.rawReader(); r != nil && num <= r.NumPage() {
+		raw = r.Page(num).Resources()
+	}
+	o.collectImages(p.Resources(), raw, 0, &images)
+	return images
+}
+
+// collectImages walks resources and, in parallel, the same resources read
+// from the unfiltered file.
+func (o *pdfOCR) collectImages(resources, raw pdf.Value, depth int, out *[]pdfImage) {
+	xobjects, rawObjects := resources.Key("XObject"), raw.Key("XObject")
+	for _, name := range xobjects.Keys() {
+		x, rx := xobjects.Key(name), rawObjects.Key(name)
+		switch x.Key("Subtype").Name() {
+		case "Form":
+			if depth < 3 {
+				o.collectImages(x.Key("Resources"), rx.Key("Resources"), depth+1, out)
+			}
+		case "Image":
+			w, h := x.Key("Width").Int64(), x.Key("Height").Int64()
+			if w < minScanSide || h < minScanSide || w*h > maxScanPixels || x.Key("ImageMask").Bool() {
+				continue
+			}
+			if img, ok := imageData(x, rx, w, h); ok {
+				*out = append(*out, img)
+			}
+		}
+	}
+}
+
+func imageData(x, raw pdf.Value, w, h int64) (pdfImage, bool) {
+	filter := x.Key("Filter")
+	if filter.Kind() == pdf.Array {
+		if filter.Len() != 1 {
+			return pdfImage{}, false
+		}
+		filter = filter.Index(0)
+	}
+
+	switch filter.Name() {
+	case "DCTDecode":
+		if data, ok := jpegData(raw); ok {
+			return pdfImage{data: data, contentType: "image/jpeg"}, true
+		}
+	case "", "FlateDecode":
+		if data, ok := rasterToPNG(x, int(w), int(h)); ok {
+			return pdfImage{data: data, contentType: "image/png"}, true
+		}
+	}
+	return pdfImage{}, false
+}
+
+// jpegData reads a JPEG image stream from the unfiltered file.
+func jpegData(raw pdf.Value) (data []byte, ok bool) {
+	defer func() {
+		if recover() != nil {
+			data, ok = nil, false
+		}
+	}()
+	if raw.Kind() != pdf.Stream || !raw.Key("Filter").IsNull() {
+		return nil, false
+	}
+	data, err := io.ReadAll(io.LimitReader(raw.Reader(), maxScanPixels))
+	if err != nil || !bytes.HasPrefix(data, []byte{0xff, 0xd8}) {
+		return nil, false
+	}
+	return data, true
+}
+
+// rasterToPNG decodes an uncompressed or Flate-compressed gray, RGB or CMYK
+// raster and encodes it as PNG.
+func rasterToPNG(x pdf.Value, w, h int) (data []byte, ok bool) {
+	// the pdf package panics on filters and predictors it does not support
+	defer func() {
+		if recover() != nil {
+			data, ok = nil, false
+		}
+	}()
+
+	components := colorComponents(x.Key("ColorSpace"))
+	bpc := int(x.Key("BitsPerComponent").Int64())
+	if components == 0 || (bpc != 8 && !(bpc == 1 && components == 1)) {
+		return nil, false
+	}
+
+	stride := (w*components*bpc + 7) / 8
+	raw, err := io.ReadAll(io.LimitReader(x.Reader(), int64(stride*h)+1))
+	if err != nil || len(raw) < stride*h {
+		return nil, false
+	}
+
+	var img image.Image
+	switch {
+	case bpc == 1:
+		g := image.NewGray(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			row := raw[y*stride:]
+			for px := 0; px < w; px++ {
+				if row[px/8]&(0x80>>(px%8)) != 0 {
+					g.Pix[y*g.Stride+px] = 0xff
+				}
+			}
+		}
+		img = g
+	case components == 1:
+		g := image.NewGray(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			copy(g.Pix[y*g.Stride:y*g.Stride+w], raw[y*stride:])
+		}
+		img = g
+	case components == 3:
+		rgba := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			row := raw[y*stride:]
+			for px := 0; px < w; px++ {
+				rgba.SetRGBA(px, y, color.RGBA{row[px*3], row[px*3+1], row[px*3+2], 0xff})
+			}
+		}
+		img = rgba
+	case components == 4:
+		cmyk := image.NewCMYK(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			copy(cmyk.Pix[y*cmyk.Stride:y*cmyk.Stride+w*4], raw[y*stride:])
+		}
+		img = cmyk
+	default:
+		return nil, false
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+// colorComponents returns the number of color components of a device or
+// ICC-based color space, or 0 for color spaces that are not supported.
+func colorComponents(cs pdf.Value) int {
+	if cs.Kind() == pdf.Array && cs.Len() > 0 {
+		if cs.Index(0).Name() == "ICCBased" {
+			return int(cs.Index(1).Key("N").Int64())
+		}
+		if cs.Len() == 1 {
+			cs = cs.Index(0)
+		}
+	}
+	switch cs.Name() {
+	case "DeviceGray", "CalGray":
+		return 1
+	case "DeviceRGB", "CalRGB":
+		return 3
+	case "DeviceCMYK":
+		return 4
+	}
+	return 0
+}
@@ -0,0 +1,325 @@
+package filetype
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// slide is the content of one presentation slide.
+type slide struct {
+	title  string
+	blocks []string // markdown blocks in reading order
+	notes  string
+}
+
+// renderSlides writes one section per slide under the document title.
+func renderSlides(filename string, slides []slide) string {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+
+	for i, s := range slides {
+		if s.title != "" {
+			md.WriteString(fmt.Sprintf("## Slide %d: %s\n\n", i+1, s.title))
+		} else {
+			md.WriteString(fmt.Sprintf("## Slide %d\n\n", i+1))
+		}
+		for _, b := range s.blocks {
+			md.WriteString(b)
+			md.WriteString("\n\n")
+		}
+		if s.notes != "" {
+			md.WriteString("### Notes\n\n")
+			md.WriteString(s.notes)
+			md.WriteString("\n\n")
+		}
+	}
+	return strings.TrimSpace(md.String())
+}
+
+// ConvertPPTX converts a PowerPoint (.pptx) presentation to markdown with one
+// section per slide: title, bullet hierarchy, tables, image alt text and
+// speaker notes.
+func ConvertPPTX(data []byte, filename string) (string, error) {
+	zr, err := openZip(data)
+	if err != nil {
+		return "", fmt.Errorf("pptx open zip: %w", err)
+	}
+
+	var slides []slide
+	for _, part := range pptxSlideParts(zr) {
+		root, err := zr.xml(part)
+		if err != nil {
+			continue
+		}
+		rels := zr.rels(part)
+		s := pptxSlide(root, rels)
+		for _, r := range rels {
+			if r.kind == "notesSlide" {
+				if notes, err := zr.xml(r.target); err == nil {
+					s.notes = pptxNotes(notes)
+				}
+			}
+		}
+		slides = append(slides, s)
+	}
+	if len(slides) == 0 {
+		return "", fmt.Errorf("pptx: no slides found")
+	}
+
+	if filename == "" {
+		filename = "presentation.pptx"
+	}
+	return renderSlides(filename, slides), nil
+}
+
+// pptxSlideParts returns the slide part names in presentation order, falling
+// back to the numeric order of the slide files.
+func pptxSlideParts(zr *zipArchive) []string {
+	var parts []string
+	if pres, err := zr.xml("ppt/presentation.xml"); err == nil {
+		rels := zr.rels("ppt/presentation.xml")
+		if list := pres.child("sldIdLst"); list != nil {
+			for _, id := range list.all("sldId") {
+				if r, ok := rels[id.attrNS(relNS, "id")]; ok {
+					if _, exists := zr.files[r.target]; exists {
+						parts = append(parts, r.target)
+					}
+				}
+			}
+		}
+	}
+	if len(parts) > 0 {
+		return parts
+	}
+
+	for _, name := range zr.order {
+		if strings.HasPrefix(name, "ppt/slides/slide") && strings.HasSuffix(name, ".xml") {
+			parts = append(parts, name)
+		}
+	}
+	sort.Slice(parts, func(i, j int) bool { return slideNumber(parts[i]) < slideNumber(parts[j]) })
+	return parts
+}
+
+func slideNumber(name string) int {
+	base := strings.TrimSuffix(path.Base(name), ".xml")
+	n, _ := strconv.Atoi(strings.TrimLeft(base, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"))
+	return n
+}
+
+// Placeholder types that carry slide furniture rather than content.
+var pptxSkipPlaceholders = map[string]bool{
+	"sldNum": true, "dt": true, "ftr": true, "hdr": true, "sldImg": true,
+}
+
+func pptxSlide(root *xmlNode, rels map[string]relationship) slide {
+	var s slide
+	tree := root.find("spTree")
+	if tree == nil {
+		return s
+	}
+
+	tree.walk(func(n *xmlNode) bool {
+		switch {
+		case n.is("sp"):
+			phType, isPlaceholder := pptxPlaceholder(n)
+			if pptxSkipPlaceholders[phType] {
+				return false
+			}
+			body := n.child("txBody")
+			if body == nil {
+				return false
+			}
+			if phType == "title" || phType == "ctrTitle" {
+				if t := joinParagraphs(body, " "); t != "" && s.title == "" {
+					s.title = t
+					return false
+				}
+			}
+			// body placeholders are bulleted by default, text boxes are not
+			bulleted := isPlaceholder && (phType == "" || phType == "body" || phType == "obj")
+			s.blocks = append(s.blocks, drawingMLText(body, bulleted)...)
+			return false
+
+		case n.is("tbl"):
+			if t := drawingMLTable(n); t != "" {
+				s.blocks = append(s.blocks, t)
+			}
+			return false
+
+		case n.is("pic"):
+			if img := pptxPicture(n, rels); img != "" {
+				s.blocks = append(s.blocks, img)
+			}
+			return false
+		}
+		return true
+	})
+	return s
+}
+
+// pptxPlaceholder returns the placeholder type of a shape and whether the
+// shape is a placeholder at all; untyped placeholders are body content.
+func pptxPlaceholder(sp *xmlNode) (string, bool) {
+	nv := sp.child("nvSpPr")
+	if nv == nil {
+		return "", false
+	}
+	pr := nv.child("nvPr")
+	if pr == nil {
+		return "", false
+	}
+	ph := pr.child("ph")
+	if ph == nil {
+		return "", false
+	}
+	return ph.attr("type"), true
+}
+
+// pptxPicture renders a picture's alt text as an image referencing the
+// embedded media file name.
+func pptxPicture(pic *xmlNode, rels map[string]relationship) string {
+	var alt string
+	if nv := pic.child("nvPicPr"); nv != nil {
+		if c := nv.child("cNvPr"); c != nil {
+			alt = collapseSpace(c.attr("descr"))
+			if alt == "" {
+				alt = collapseSpace(c.attr("title"))
+			}
+		}
+	}
+	if alt == "" {
+		return ""
+	}
+	src := ""
+	if blip := pic.find("blip"); blip != nil {
+		if r, ok := rels[blip.attrNS(relNS, "embed")]; ok {
+			src = path.Base(r.target)
+		}
+	}
+	return fmt.Sprintf("![%s](%s)", strings.ReplaceAll(alt, "]", "\\]"), src)
+}
+
+// pptxNotes extracts the speaker notes from a notes slide.
+func pptxNotes(root *xmlNode) string {
+	var parts []string
+	root.walk(func(n *xmlNode) bool {
+		if !n.is("sp") {
+			return true
+		}
+		if phType, _ := pptxPlaceholder(n); phType != "body" {
+			return false
+		}
+		if body := n.child("txBody"); body != nil {
+			parts = append(parts, drawingMLText(body, false)...)
+		}
+		return false
+	})
+	return strings.Join(parts, "\n\n")
+}
+
+// drawingMLText renders the paragraphs of a DrawingML text body. Bulleted
+// paragraphs are indented by their outline level and grouped into one list
+// block; other paragraphs become separate blocks.
+func drawingMLText(body *xmlNode, bulleted bool) []string {
+	var (
+		blocks []string
+		list   []string
+		number = map[int]int{}
+	)
+	flushList := func() {
+		if len(list) > 0 {
+			blocks = append(blocks, strings.Join(list, "\n"))
+			list = nil
+			number = map[int]int{}
+		}
+	}
+
+	for _, p := range body.all("p") {
+		text := drawingMLParagraph(p)
+		if text == "" {
+			continue
+		}
+
+		level, kind := 0, ""
+		if bulleted {
+			kind = "bullet"
+		}
+		if pr := p.child("pPr"); pr != nil {
+			level, _ = strconv.Atoi(pr.attr("lvl"))
+			switch {
+			case pr.child("buNone") != nil:
+				kind = ""
+			case pr.child("buAutoNum") != nil:
+				kind = "number"
+			case pr.child("buChar") != nil || pr.child("buBlip") != nil:
+				kind = "bullet"
+			}
+		}
+
+		switch kind {
+		case "bullet":
+			list = append(list, strings.Repeat("  ", level)+"- "+text)
+		case "number":
+			for l := range number {
+				if l > level {
+					delete(number, l)
+				}
+			}
+			number[level]++
+			list = append(list, fmt.Sprintf("%s%d. %s", strings.Repeat("   ", level), number[level], text))
+		default:
+			flushList()
+			blocks = append(blocks, text)
+		}
+	}
+	flushList()
+	return blocks
+}
+
+// drawingMLParagraph returns the text of an a:p element.
+func drawingMLParagraph(p *xmlNode) string {
+	var b strings.Builder
+	for _, c := range p.children {
+		switch {
+		case c.is("r"), c.is("fld"):
+			if t := c.child("t"); t != nil {
+				b.WriteString(t.textContent())
+			}
+		case c.is("br"):
+			b.WriteString(" ")
+		}
+	}
+	return collapseSpace(b.String())
+}
+
+// joinParagraphs returns the text of all paragraphs in a text body.
+func joinParagraphs(body *xmlNode, sep string) string {
+	var parts []string
+	for _, p := range body.all("p") {
+		if t := drawingMLParagraph(p); t != "" {
+			parts = append(parts, t)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// drawingMLTable renders an a:tbl element as a pipe table.
+func drawingMLTable(tbl *xmlNode) string {
+	var rows [][]string
+	for _, tr := range tbl.all("tr") {
+		var row []string
+		for _, tc := range tr.all("tc") {
+			text := ""
+			if body := tc.child("txBody"); body != nil {
+				text = joinParagraphs(body, " ")
+			}
+			row = append(row, text)
+		}
+		rows = append(rows, row)
+	}
+	return markdownTable(rows)
+}
@@ -0,0 +1,481 @@
+package filetype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ConvertRTF converts a Rich Text Format (.rtf) document to markdown.
+// Headings, lists, tables, hyperlinks and bold, italic and strikethrough
+// text are kept.
+func ConvertRTF(data []byte, filename string) (string, error) {
+	if !strings.HasPrefix(string(data[:min(len(data), 5)]), `{\rtf`) {
+		return "", fmt.Errorf("rtf: missing {\\rtf header")
+	}
+	p := &rtfParser{
+		data:     data,
+		cp:       charmap.Windows1252,
+		headings: make(map[int]int),
+		st:       rtfState{uc: 1},
+	}
+	p.parse()
+
+	if filename == "" {
+		filename = "document.rtf"
+	}
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	for _, b := range p.blocks {
+		md.WriteString(b)
+		md.WriteString("\n\n")
+	}
+	return strings.TrimSpace(md.String()), nil
+}
+
+// Destinations whose content is not part of the document text.
+var rtfSkipDests = map[string]bool{
+	"fonttbl": true, "colortbl": true, "info": true, "pict": true, "object": true,
+	"header": true, "headerl": true, "headerr": true, "headerf": true,
+	"footer": true, "footerl": true, "footerr": true, "footerf": true,
+	"footnote": true, "annotation": true, "listtable": true, "listoverridetable": true,
+	"revtbl": true, "rsidtbl": true, "generator": true, "filetbl": true,
+	"shp": true, "shppict": true, "nonshppict": true, "xe": true, "tc": true,
+	"atnid": true, "atnauthor": true, "template": true, "userprops": true,
+}
+
+// Ignorable (\*) destinations that are handled rather than skipped.
+var rtfKnownDests = map[string]bool{"fldinst": true}
+
+// rtfCodepages maps \ansicpg values to single-byte character sets.
+var rtfCodepages = map[int]*charmap.Charmap{
+	437: charmap.CodePage437, 850: charmap.CodePage850, 10000: charmap.Macintosh,
+	1250: charmap.Windows1250, 1251: charmap.Windows1251, 1252: charmap.Windows1252,
+	1253: charmap.Windows1253, 1254: charmap.Windows1254, 1255: charmap.Windows1255,
+	1256: charmap.Windows1256, 1257: charmap.Windows1257, 1258: charmap.Windows1258,
+}
+
+// rtfState is the character state scoped to a group.
+type rtfState struct {
+	format docxFormat
+	skip   bool   // inside an ignored destination
+	dest   string // "stylesheet", "listtext", "fldinst", or "" for body text
+	field  *rtfField
+	uc     int // characters to skip after \u
+}
+
+// rtfField is a field being read; HYPERLINK fields become links.
+type rtfField struct {
+	instr strings.Builder
+	start int // index in segs where the result starts
+}
+
+type rtfParser struct {
+	data []byte
+	pos  int
+	cp   *charmap.Charmap
+
+	st         rtfState
+	stack      []rtfState
+	groupStart bool // no token yet in the current group
+	star       bool // the group is an ignorable \* destination
+	skipChars  int  // fallback characters left after \u
+	high       rune // pending high surrogate from \u
+
+	// stylesheet entry being read
+	headings  map[int]int // paragraph style -> heading level
+	styleNum  int
+	styleLvl  int
+	styleName strings.Builder
+
+	// paragraph state, reset by \pard
+	style   int
+	outline int
+	inTable bool
+	ilvl    int
+
+	segs   []docxSegment
+	marker strings.Builder // list item marker from \listtext
+
+	blocks []string
+	list   []string
+	rows   [][]string
+	row    []string
+	cell   []string
+}
+
+func (p *rtfParser) parse() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch c {
+		case '{':
+			p.pos++
+			p.stack = append(p.stack, p.st)
+			p.groupStart, p.star = true, false
+		case '}':
+			p.pos++
+			p.endGroup()
+		case '\\':
+			p.control()
+		case '\r', '\n':
+			p.pos++
+		default:
+			p.pos++
+			p.groupStart = false
+			if !p.st.skip {
+				p.byteChar(c)
+			}
+		}
+	}
+	p.paragraph()
+	p.flushList()
+	p.flushTable()
+}
+
+func (p *rtfParser) endGroup() {
+	if len(p.stack) == 0 {
+		return
+	}
+	inner := p.st
+	p.st = p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	p.groupStart, p.star, p.skipChars = false, false, 0
+
+	if inner.dest == "stylesheet" && p.st.dest != "stylesheet" {
+		p.styleNum, p.styleLvl = 0, 0
+		p.styleName.Reset()
+	}
+	if f := inner.field; f != nil && f != p.st.field && f.start <= len(p.segs) {
+		field := &docxField{instr: f.instr.String()}
+		p.segs = append(p.segs[:f.start], field.render(p.segs[f.start:])...)
+	}
+}
+
+func (p *rtfParser) control() {
+	p.pos++ // backslash
+	if p.pos >= len(p.data) {
+		return
+	}
+	c := p.data[p.pos]
+	if !isASCIILetter(c) {
+		p.pos++
+		if c == '*' {
+			p.star = true
+			return
+		}
+		p.groupStart = false
+		if p.st.skip {
+			return
+		}
+		switch c {
+		case '\'':
+			if p.pos+2 <= len(p.data) {
+				if b, err := strconv.ParseUint(string(p.data[p.pos:p.pos+2]), 16, 8); err == nil {
+					p.pos += 2
+					p.byteChar(byte(b))
+				}
+			}
+		case '~':
+			p.text(" ")
+		case '_':
+			p.text("-")
+		case '\\', '{', '}':
+			p.text(string(c))
+		case '\r', '\n':
+			p.paragraph()
+		}
+		return
+	}
+
+	start := p.pos
+	for p.pos < len(p.data) && isASCIILetter(p.data[p.pos]) {
+		p.pos++
+	}
+	word := string(p.data[start:p.pos])
+	numStart := p.pos
+	if p.pos < len(p.data) && p.data[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '9' {
+		p.pos++
+	}
+	param, err := strconv.Atoi(string(p.data[numStart:p.pos]))
+	hasParam := err == nil
+	if p.pos < len(p.data) && p.data[p.pos] == ' ' {
+		p.pos++
+	}
+
+	if word == "bin" && hasParam && param > 0 {
+		p.pos = min(p.pos+param, len(p.data))
+		return
+	}
+
+	groupStart, star := p.groupStart, p.star
+	p.groupStart, p.star = false, false
+	if p.st.skip {
+		return
+	}
+	if groupStart {
+		if rtfSkipDests[word] || (star && !rtfKnownDests[word]) {
+			p.st.skip = true
+			return
+		}
+		switch word {
+		case "stylesheet":
+			p.st.dest = "stylesheet"
+			return
+		case "listtext", "pntext":
+			p.st.dest = "listtext"
+			p.marker.Reset()
+			return
+		case "field":
+			p.st.field = &rtfField{start: len(p.segs)}
+			return
+		case "fldinst":
+			p.st.dest = "fldinst"
+			return
+		case "fldrslt":
+			p.st.dest = ""
+			if p.st.field != nil {
+				p.st.field.start = len(p.segs)
+			}
+			return
+		}
+	}
+	if !hasParam {
+		param = 1
+	}
+	p.word(word, param)
+}
+
+// word applies a control word that is not a destination.
+func (p *rtfParser) word(word string, param int) {
+	if p.st.dest == "stylesheet" {
+		switch word {
+		case "s":
+			p.styleNum = param
+		case "cs", "ds", "ts", "tsrowd":
+			p.styleNum = -1 // character, section and table styles
+		case "outlinelevel":
+			p.styleLvl = param + 1
+		}
+		return
+	}
+
+	switch word {
+	case "ansicpg":
+		if cp, ok := rtfCodepages[param]; ok {
+			p.cp = cp
+		}
+	case "uc":
+		p.st.uc = max(param, 0)
+	case "u":
+		if param < 0 {
+			param += 65536
+		}
+		r := rune(param)
+		switch {
+		case utf16.IsSurrogate(r) && r < 0xDC00:
+			p.high = r
+		case utf16.IsSurrogate(r):
+			if p.high != 0 {
+				p.text(string(utf16.DecodeRune(p.high, r)))
+			}
+			p.high = 0
+		default:
+			p.text(string(r))
+		}
+		p.skipChars = p.st.uc
+
+	case "par", "sect", "page":
+		p.paragraph()
+	case "line", "tab", "emspace", "enspace", "qmspace":
+		p.text(" ")
+	case "cell", "nestcell":
+		p.endCell()
+	case "row":
+		p.endRow()
+	case "pard":
+		p.style, p.outline, p.inTable, p.ilvl = 0, 0, false, 0
+	case "s":
+		p.style = param
+	case "outlinelevel":
+		p.outline = param + 1
+	case "intbl":
+		p.inTable = true
+	case "ilvl":
+		p.ilvl = param
+
+	case "plain":
+		p.st.format = docxFormat{}
+	case "b":
+		p.st.format.bold = param != 0
+	case "i":
+		p.st.format.italic = param != 0
+	case "strike", "striked":
+		p.st.format.strike = param != 0
+
+	case "emdash":
+		p.text("—")
+	case "endash":
+		p.text("–")
+	case "bullet":
+		p.text("•")
+	case "lquote":
+		p.text("‘")
+	case "rquote":
+		p.text("’")
+	case "ldblquote":
+		p.text("“")
+	case "rdblquote":
+		p.text("”")
+	}
+}
+
+// byteChar decodes a byte of text in the document code page.
+func (p *rtfParser) byteChar(b byte) {
+	if p.skipChars > 0 {
+		p.skipChars--
+		return
+	}
+	if b < 0x80 {
+		p.text(string(rune(b)))
+		return
+	}
+	p.text(string(p.cp.DecodeByte(b)))
+}
+
+// text adds text to the current destination.
+func (p *rtfParser) text(s string) {
+	switch p.st.dest {
+	case "stylesheet":
+		for _, r := range s {
+			if r == ';' {
+				p.endStyle()
+				continue
+			}
+			p.styleName.WriteRune(r)
+		}
+	case "listtext":
+		p.marker.WriteString(s)
+	case "fldinst":
+		if p.st.field != nil {
+			p.st.field.instr.WriteString(s)
+		}
+	default:
+		p.segs = append(p.segs, docxSegment{text: s, format: p.st.format})
+	}
+}
+
+// endStyle records the heading level of the stylesheet entry just read.
+func (p *rtfParser) endStyle() {
+	name := strings.ToLower(strings.TrimSpace(p.styleName.String()))
+	level := p.styleLvl
+	switch {
+	case level > 0:
+	case name == "title":
+		level = 1
+	case name == "subtitle":
+		level = 2
+	default:
+		fmt.Sscanf(name, "heading %d", &level)
+	}
+	if p.styleNum >= 0 && level >= 1 && level <= 9 {
+		p.headings[p.styleNum] = level
+	}
+	p.styleNum, p.styleLvl = 0, 0
+	p.styleName.Reset()
+}
+
+// takeText returns and clears the text of the current paragraph.
+func (p *rtfParser) takeText() string {
+	text := collapseSpace(renderSegments(p.segs))
+	p.segs = p.segs[:0]
+	return text
+}
+
+func (p *rtfParser) paragraph() {
+	text := p.takeText()
+	marker := strings.TrimSpace(p.marker.String())
+	p.marker.Reset()
+	if p.inTable {
+		if text != "" {
+			p.cell = append(p.cell, text)
+		}
+		return
+	}
+	p.flushTable()
+	if text == "" {
+		return
+	}
+
+	if marker != "" {
+		indent := strings.Repeat("  ", max(min(p.ilvl, 8), 0))
+		digits := strings.TrimRightFunc(marker, func(r rune) bool { return r == '.' || r == ')' })
+		if n, err := strconv.Atoi(digits); err == nil {
+			p.list = append(p.list, fmt.Sprintf("%s%d. %s", indent, n, text))
+		} else {
+			p.list = append(p.list, indent+"- "+text)
+		}
+		return
+	}
+	p.flushList()
+
+	level := p.headings[p.style]
+	if level == 0 {
+		level = p.outline
+	}
+	if level > 0 && level <= 9 {
+		text = strings.Repeat("#", min(level, 6)) + " " + text
+	}
+	p.blocks = append(p.blocks, text)
+}
+
+func (p *rtfParser) endCell() {
+	p.flushList()
+	if text := p.takeText(); text != "" {
+		p.cell = append(p.cell, text)
+	}
+	p.marker.Reset()
+	p.row = append(p.row, strings.Join(p.cell, " "))
+	p.cell = nil
+}
+
+func (p *rtfParser) endRow() {
+	if len(p.cell) > 0 || len(p.segs) > 0 {
+		p.endCell()
+	}
+	if len(p.row) > 0 {
+		p.rows = append(p.rows, p.row)
+	}
+	p.row = nil
+}
+
+func (p *rtfParser) flushList() {
+	if len(p.list) > 0 {
+		p.blocks = append(p.blocks, strings.Join(p.list, "\n"))
+		p.list = nil
+	}
+}
+
+func (p *rtfParser) flushTable() {
+	if len(p.row) > 0 {
+		p.rows = append(p.rows, p.row)
+		p.row = nil
+	}
+	if len(p.rows) == 0 {
+		return
+	}
+	p.flushList()
+	if t := markdownTable(p.rows); t != "" {
+		p.blocks = append(p.blocks, t)
+	}
+	p.rows = nil
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,283 @@
+package filetype
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SheetOptions configures spreadsheet conversion.
+type SheetOptions struct {
+	Sheets        []string  // sheet names or 1-based indexes; empty selects all visible sheets
+	Range         CellRange // cells to convert in every selected sheet; the zero value selects all
+	MaxRows       int       // data rows per sheet; 0 means no limit
+	IncludeHidden bool      // keep hidden sheets, rows and columns
+	Formulas      bool      // show formulas alongside computed values
+}
+
+// ParseSheets splits a comma-separated list of sheet names or indexes.
+func ParseSheets(s string) []string {
+	var sheets []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			sheets = append(sheets, part)
+		}
+	}
+	return sheets
+}
+
+// ErrInvalidRange is returned for malformed cell ranges.
+var ErrInvalidRange = errors.New("invalid cell range")
+
+// CellRange is an inclusive block of cells with 1-based coordinates. A zero
+// bound leaves that side open, so "B:D" selects whole columns and "5:20"
+// whole rows.
+type CellRange struct {
+	FromCol, FromRow, ToCol, ToRow int
+}
+
+// ParseCellRange parses a range in A1 notation such as "A1:F100", "B:D" or
+// "5:20". An empty string selects all cells.
+func ParseCellRange(s string) (CellRange, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "" {
+		return CellRange{}, nil
+	}
+	from, to, isRange := strings.Cut(s, ":")
+	if !isRange {
+		to = from
+	}
+	var r CellRange
+	var ok1, ok2 bool
+	r.FromCol, r.FromRow, ok1 = parseCellRef(from)
+	r.ToCol, r.ToRow, ok2 = parseCellRef(to)
+	if !ok1 || !ok2 ||
+		(r.FromCol == 0) != (r.ToCol == 0) || (r.FromRow == 0) != (r.ToRow == 0) ||
+		r.FromCol > r.ToCol || r.FromRow > r.ToRow {
+		return CellRange{}, fmt.Errorf("%w %q", ErrInvalidRange, s)
+	}
+	return r, nil
+}
+
+// parseCellRef parses "B7", "B" or "7" into a column and row; a missing part
+// is 0.
+func parseCellRef(ref string) (col, row int, ok bool) {
+	i := 0
+	for i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z' {
+		col = col*26 + int(ref[i]-'A'+1)
+		if col > 16384 { // XFD, the last Excel column
+			return 0, 0, false
+		}
+		i++
+	}
+	if i < len(ref) {
+		n, err := strconv.Atoi(ref[i:])
+		if err != nil || n < 1 || n > 1<<20 {
+			return 0, 0, false
+		}
+		row = n
+	}
+	return col, row, ref != ""
+}
+
+// containsRow and containsCol report whether a 0-based row or column lies
+// within the range.
+func (r CellRange) containsRow(row int) bool {
+	return r.FromRow == 0 || row+1 >= r.FromRow && row+1 <= r.ToRow
+}
+
+func (r CellRange) containsCol(col int) bool {
+	return r.FromCol == 0 || col+1 >= r.FromCol && col+1 <= r.ToCol
+}
+
+// sheet is a worksheet read into memory, indexed by 0-based row and column.
+type sheet struct {
+	name       string
+	rows       [][]string
+	hiddenRows map[int]bool
+	hiddenCols map[int]bool
+	formula    func(row, col int) string // nil when formulas are unavailable
+	more       bool                      // rows past the last one were not read
+}
+
+// selectSheets returns the indexes of the sheets to convert. Explicitly
+// selected sheets are converted even when hidden.
+func selectSheets(names []string, hidden []bool, opts *SheetOptions) ([]int, error) {
+	if len(opts.Sheets) == 0 {
+		var all []int
+		for i := range names {
+			if opts.IncludeHidden || !hidden[i] {
+				all = append(all, i)
+			}
+		}
+		return all, nil
+	}
+
+	var picked []int
+	seen := make(map[int]bool)
+	for _, want := range opts.Sheets {
+		idx := -1
+		for i, name := range names {
+			if strings.EqualFold(name, want) {
+				idx = i
+				break
+			}
+		}
+		if n, err := strconv.Atoi(want); idx < 0 && err == nil && n >= 1 && n <= len(names) {
+			idx = n - 1
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("no sheet %q (have %s)", want, strings.Join(names, ", "))
+		}
+		if !seen[idx] {
+			seen[idx] = true
+			picked = append(picked, idx)
+		}
+	}
+	return picked, nil
+}
+
+// renderSheets writes the sheets as markdown tables under the document title,
+// with a heading per sheet when the workbook has several.
+func renderSheets(filename string, sheets []*sheet, headings bool, opts *SheetOptions) string {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	for _, s := range sheets {
+		body := s.render(opts)
+		if body == "" {
+			continue
+		}
+		if headings {
+			md.WriteString(fmt.Sprintf("## %s\n\n", s.name))
+		}
+		md.WriteString(body)
+		md.WriteString("\n\n")
+	}
+	return strings.TrimSpace(md.String())
+}
+
+// maxHeaderScan is how many leading rows are searched for the header row.
+const maxHeaderScan = 10
+
+// render converts the selected cells of a sheet. Empty rows and columns are
+// dropped, and non-empty rows above the detected header row, such as report
+// titles, are written as text before the table.
+func (s *sheet) render(opts *SheetOptions) string {
+	rng := opts.Range
+
+	width := 0
+	for r, row := range s.rows {
+		if rng.containsRow(r) {
+			width = max(width, len(row))
+		}
+	}
+	value := func(r, c int) string {
+		if c < len(s.rows[r]) {
+			return strings.TrimSpace(s.rows[r][c])
+		}
+		return ""
+	}
+
+	var cols []int
+	for c := 0; c < width; c++ {
+		if rng.containsCol(c) && (opts.IncludeHidden || !s.hiddenCols[c]) {
+			cols = append(cols, c)
+		}
+	}
+
+	var rows []int
+	for r := range s.rows {
+		if !rng.containsRow(r) || (!opts.IncludeHidden && s.hiddenRows[r]) {
+			continue
+		}
+		for _, c := range cols {
+			if value(r, c) != "" {
+				rows = append(rows, r)
+				break
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+
+	used := cols[:0]
+	for _, c := range cols {
+		for _, r := range rows {
+			if value(r, c) != "" {
+				used = append(used, c)
+				break
+			}
+		}
+	}
+	cols = used
+
+	cell := func(r, c int) string {
+		v := value(r, c)
+		if opts.Formulas && s.formula != nil {
+			if f := s.formula(r, c); f != "" {
+				if v == "" {
+					return "=" + f
+				}
+				return v + " (=" + f + ")"
+			}
+		}
+		return v
+	}
+
+	// the header is the first row with distinct values in at least half the
+	// columns; a title merged across the table repeats one value
+	header := 0
+	need := max(min(2, len(cols)), (len(cols)+1)/2)
+	for i, r := range rows[:min(len(rows), maxHeaderScan)] {
+		distinct := make(map[string]bool)
+		for _, c := range cols {
+			if v := value(r, c); v != "" {
+				distinct[v] = true
+			}
+		}
+		if len(distinct) >= need {
+			header = i
+			break
+		}
+	}
+
+	var md strings.Builder
+	for _, r := range rows[:header] {
+		var parts []string
+		for _, c := range cols {
+			// merged titles repeat across the row
+			if v := cell(r, c); v != "" && !slices.Contains(parts, v) {
+				parts = append(parts, v)
+			}
+		}
+		md.WriteString(strings.Join(parts, " "))
+		md.WriteString("\n\n")
+	}
+
+	data := rows[header+1:]
+	omitted := 0
+	if opts.MaxRows > 0 && len(data) > opts.MaxRows {
+		omitted = len(data) - opts.MaxRows
+		data = data[:opts.MaxRows]
+	}
+
+	table := make([][]string, 0, len(data)+1)
+	for _, r := range append([]int{rows[header]}, data...) {
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = cell(r, c)
+		}
+		table = append(table, row)
+	}
+	md.WriteString(markdownTable(table))
+	switch {
+	case s.more:
+		md.WriteString("\n\n_More rows omitted_")
+	case omitted > 0:
+		md.WriteString(fmt.Sprintf("\n\n_%d more rows omitted_", omitted))
+	}
+	return md.String()
+}
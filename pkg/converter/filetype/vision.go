@@ -16,6 +16,7 @@ import (
 type VisionConfig struct {
 	AccountID string
 	APIToken  string
+	BaseURL   string // API base URL; defaults to https://api.cloudflare.com/client/v4
 }
 
 // IsConfigured returns true if the vision provider is configured.
@@ -60,8 +61,24 @@ type cfAgreeRequest struct {
 	Prompt string `json:"prompt"`
 }
 
+const (
+	describePrompt = "Describe this image in detail. Focus on the main content, colors, layout, and any text visible in the image. Output a concise description suitable for a markdown document."
+	ocrPrompt      = "Transcribe all text in this scanned document page exactly as written, in reading order. Keep headings, paragraphs, lists and tables, formatted as Markdown. Output only the transcribed text, without commentary. If the page contains no text, output nothing."
+)
+
 // DescribeImage sends the image to Cloudflare Workers AI vision and returns a text description.
 func DescribeImage(ctx context.Context, cfg *VisionConfig, data []byte, contentType string) (string, error) {
+	return runVision(ctx, cfg, data, contentType, describePrompt)
+}
+
+// RecognizeText sends a scanned page image to Cloudflare Workers AI vision and
+// returns the text on it as markdown.
+func RecognizeText(ctx context.Context, cfg *VisionConfig, data []byte, contentType string) (string, error) {
+	return runVision(ctx, cfg, data, contentType, ocrPrompt)
+}
+
+// runVision sends the image with a prompt to the vision model.
+func runVision(ctx context.Context, cfg *VisionConfig, data []byte, contentType, prompt string) (string, error) {
 	if !cfg.IsConfigured() {
 		return "", fmt.Errorf("vision not configured")
 	}
@@ -83,7 +100,7 @@ func DescribeImage(ctx context.Context, cfg *VisionConfig, data []byte, contentT
 					},
 					{
 						Type: "text",
-						Text: "Describe this image in detail. Focus on the main content, colors, layout, and any text visible in the image. Output a concise description suitable for a markdown document.",
+						Text: prompt,
 					},
 				},
 			},
@@ -95,7 +112,11 @@ func DescribeImage(ctx context.Context, cfg *VisionConfig, data []byte, contentT
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	apiURL := fmt.Sprintf("https://api.cloudflare.com/client/v4/accounts/%s/ai/run/@cf/meta/llama-3.2-11b-vision-instruct", cfg.AccountID)
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.cloudflare.com/client/v4"
+	}
+	apiURL := fmt.Sprintf("%s/accounts/%s/ai/run/@cf/meta/llama-3.2-11b-vision-instruct", strings.TrimRight(baseURL, "/"), cfg.AccountID)
 
 	desc, err := callVisionAPI(ctx, cfg, apiURL, body)
 	if err != nil && isModelAgreementError(err) {
@@ -10,8 +10,10 @@ import (
 	"github.com/extrame/xls"
 )
 
-// ConvertXLS converts legacy .xls (BIFF) bytes to markdown tables.
-func ConvertXLS(data []byte, filename string) (string, error) {
+// ConvertXLS converts legacy .xls (BIFF) bytes to markdown tables. The BIFF
+// reader does not expose hidden state, merged cells or formulas, so those
+// options only apply to XLSX.
+func ConvertXLS(data []byte, filename string, opts *SheetOptions) (string, error) {
 	// extrame/xls requires a file on disk
 	tmp, err := os.CreateTemp("", "url2md-*.xls")
 	if err != nil {
@@ -29,72 +31,57 @@ func ConvertXLS(data []byte, filename string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("xls open: %w", err)
 	}
+	if opts == nil {
+		opts = &SheetOptions{}
+	}
 
 	if filename == "" {
 		filename = "spreadsheet.xls"
 	}
 
-	var md strings.Builder
-	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
-
 	numSheets := wb.NumSheets()
-	for i := 0; i < numSheets; i++ {
-		sheet := wb.GetSheet(i)
-		if sheet == nil {
-			continue
-		}
-
-		maxRow := int(sheet.MaxRow)
-		if maxRow == 0 {
-			continue
-		}
-
-		if numSheets > 1 {
-			md.WriteString(fmt.Sprintf("## %s\n\n", sheet.Name))
-		}
-
-		// Determine max columns from all rows
-		maxCols := 0
-		for r := 0; r <= maxRow; r++ {
-			row := sheet.Row(r)
-			if row == nil {
-				continue
-			}
-			cols := row.LastCol()
-			if cols > maxCols {
-				maxCols = cols
-			}
+	names := make([]string, numSheets)
+	for i := range numSheets {
+		if ws := wb.GetSheet(i); ws != nil {
+			names[i] = ws.Name
 		}
+	}
+	selected, err := selectSheets(names, make([]bool, numSheets), opts)
+	if err != nil {
+		return "", fmt.Errorf("xls: %w", err)
+	}
 
-		if maxCols == 0 {
+	var sheets []*sheet
+	for _, i := range selected {
+		ws := wb.GetSheet(i)
+		if ws == nil {
 			continue
 		}
-
-		for r := 0; r <= maxRow; r++ {
-			row := sheet.Row(r)
-			md.WriteString("|")
-			for c := 0; c < maxCols; c++ {
-				cell := ""
-				if row != nil {
-					cell = xlsCellValue(strings.TrimSpace(row.Col(c)))
-					cell = strings.ReplaceAll(cell, "|", "\\|")
+		s := &sheet{name: ws.Name}
+		for r := 0; r <= int(ws.MaxRow); r++ {
+			var cells []string
+			if row := xlsRow(ws, r); row != nil {
+				for c := 0; c < row.LastCol(); c++ {
+					cells = append(cells, xlsCellValue(strings.TrimSpace(row.Col(c))))
 				}
-				md.WriteString(" " + cell + " |")
-			}
-			md.WriteString("\n")
-
-			if r == 0 {
-				md.WriteString("|")
-				for c := 0; c < maxCols; c++ {
-					md.WriteString(" --- |")
-				}
-				md.WriteString("\n")
 			}
+			s.rows = append(s.rows, cells)
 		}
-		md.WriteString("\n")
+		sheets = append(sheets, s)
 	}
 
-	return strings.TrimSpace(md.String()), nil
+	return renderSheets(filename, sheets, numSheets > 1, opts), nil
+}
+
+// xlsRow returns row r, or nil when the sheet has no such row; the library
+// panics on missing rows.
+func xlsRow(ws *xls.WorkSheet, r int) (row *xls.Row) {
+	defer func() {
+		if recover() != nil {
+			row = nil
+		}
+	}()
+	return ws.Row(r)
 }
 
 // xlsCellValue fixes a known issue in extrame/xls where numeric cells with
@@ -8,65 +8,162 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
-// ConvertXLSX converts XLSX bytes to markdown tables (one per sheet).
-func ConvertXLSX(data []byte, filename string) (string, error) {
+// ConvertXLSX converts XLSX bytes to markdown tables (one per sheet). Merged
+// cells are expanded and hidden sheets, rows and columns are skipped unless
+// opts says otherwise.
+func ConvertXLSX(data []byte, filename string, opts *SheetOptions) (string, error) {
 	f, err := excelize.OpenReader(bytes.NewReader(data))
 	if err != nil {
 		return "", fmt.Errorf("xlsx open: %w", err)
 	}
 	defer f.Close()
+	if opts == nil {
+		opts = &SheetOptions{}
+	}
 
 	if filename == "" {
 		filename = "spreadsheet.xlsx"
 	}
 
-	var md strings.Builder
-	md.WriteString(fmt.Sprintf("# %s\n\n", filename))
+	names := f.GetSheetList()
+	hidden := make([]bool, len(names))
+	for i, name := range names {
+		visible, err := f.GetSheetVisible(name)
+		hidden[i] = err == nil && !visible
+	}
+	selected, err := selectSheets(names, hidden, opts)
+	if err != nil {
+		return "", fmt.Errorf("xlsx: %w", err)
+	}
 
-	sheets := f.GetSheetList()
-	for _, sheet := range sheets {
-		rows, err := f.GetRows(sheet)
-		if err != nil || len(rows) == 0 {
+	var sheets []*sheet
+	for _, i := range selected {
+		s, err := xlsxSheet(f, names[i], opts)
+		if err != nil {
 			continue
 		}
+		sheets = append(sheets, s)
+	}
 
-		if len(sheets) > 1 {
-			md.WriteString(fmt.Sprintf("## %s\n\n", sheet))
-		}
+	return renderSheets(filename, sheets, len(names) > 1, opts), nil
+}
+
+func xlsxSheet(f *excelize.File, name string, opts *SheetOptions) (*sheet, error) {
+	s, err := readXLSXRows(f, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	rows := s.rows
 
-		// determine max columns
-		maxCols := 0
-		for _, row := range rows {
-			if len(row) > maxCols {
-				maxCols = len(row)
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+
+	// merged areas repeat their value in every cell, within the used grid
+	if merged, err := f.GetMergeCells(name); err == nil {
+		for _, m := range merged {
+			c1, r1, err1 := excelize.CellNameToCoordinates(m.GetStartAxis())
+			c2, r2, err2 := excelize.CellNameToCoordinates(m.GetEndAxis())
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			for r := r1 - 1; r < min(r2, len(rows)); r++ {
+				for c := c1 - 1; c < min(c2, width); c++ {
+					for len(s.rows[r]) <= c {
+						s.rows[r] = append(s.rows[r], "")
+					}
+					s.rows[r][c] = m.GetCellValue()
+				}
 			}
 		}
+	}
 
-		if maxCols == 0 {
-			continue
+	if !opts.IncludeHidden {
+		for c := 0; c < width; c++ {
+			colHidden(f, s, c)
 		}
+	}
 
-		for i, row := range rows {
-			md.WriteString("|")
-			for j := 0; j < maxCols; j++ {
-				cell := ""
-				if j < len(row) {
-					cell = strings.ReplaceAll(strings.TrimSpace(row[j]), "|", "\\|")
-				}
-				md.WriteString(" " + cell + " |")
+	if opts.Formulas {
+		s.formula = func(row, col int) string {
+			cell, err := excelize.CoordinatesToCellName(col+1, row+1)
+			if err != nil {
+				return ""
 			}
-			md.WriteString("\n")
+			formula, _ := f.GetCellFormula(name, cell)
+			return formula
+		}
+	}
+	return s, nil
+}
 
-			if i == 0 {
-				md.WriteString("|")
-				for j := 0; j < maxCols; j++ {
-					md.WriteString(" --- |")
-				}
-				md.WriteString("\n")
+// readXLSXRows streams the rows of a sheet instead of loading it whole. It
+// stops after the last row of the range and, with MaxRows, once enough rows
+// with content are read to fill the table; the sheet then reports more rows.
+func readXLSXRows(f *excelize.File, name string, opts *SheetOptions) (*sheet, error) {
+	it, err := f.Rows(name)
+	if err != nil {
+		return nil, err
+	}
+	defer it.Close()
+
+	s := &sheet{name: name, hiddenRows: make(map[int]bool), hiddenCols: make(map[int]bool)}
+	rng := opts.Range
+	limit := 0 // rows with content to read; 0 reads them all
+	if opts.MaxRows > 0 {
+		// the header may follow up to maxHeaderScan-1 title rows, and one
+		// row past the limit shows that rows were omitted
+		limit = maxHeaderScan + opts.MaxRows + 1
+	}
+
+	var rows [][]string
+	filled, read := 0, 0
+	for r := 0; it.Next(); r++ {
+		if rng.ToRow > 0 && r+1 > rng.ToRow {
+			break
+		}
+		if limit > 0 && filled == limit {
+			s.more = true
+			break
+		}
+		row, err := it.Columns()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+		if len(row) > 0 {
+			read = r + 1
+		}
+		if !opts.IncludeHidden && it.GetRowOpts().Hidden {
+			s.hiddenRows[r] = true
+			continue
+		}
+		if !rng.containsRow(r) {
+			continue
+		}
+		for c, v := range row {
+			if strings.TrimSpace(v) != "" && rng.containsCol(c) && (opts.IncludeHidden || !colHidden(f, s, c)) {
+				filled++
+				break
 			}
 		}
-		md.WriteString("\n")
 	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	s.rows = rows[:read] // drop trailing empty rows
+	return s, nil
+}
 
-	return strings.TrimSpace(md.String()), nil
+// colHidden reports whether a 0-based column is hidden, caching the answer
+// in s.hiddenCols.
+func colHidden(f *excelize.File, s *sheet, c int) bool {
+	if hidden, ok := s.hiddenCols[c]; ok {
+		return hidden
+	}
+	col, _ := excelize.ColumnNumberToName(c + 1)
+	visible, err := f.GetColVisible(s.name, col)
+	s.hiddenCols[c] = err == nil && !visible
+	return s.hiddenCols[c]
 }
@@ -0,0 +1,132 @@
+package filetype
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// xmlNode is a parsed XML element. Character data is kept as child nodes with
+// an empty name so mixed content stays in document order.
+type xmlNode struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     string // character data, for text nodes only
+}
+
+// parseXMLTree parses data into a tree rooted at the document element.
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity // XHTML content documents use named entities
+
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name, attrs: t.Attr}
+			top.children = append(top.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			top.children = append(top.children, &xmlNode{text: string(t)})
+		}
+	}
+
+	for _, c := range root.children {
+		if c.name.Local != "" {
+			return c, nil
+		}
+	}
+	return nil, xml.UnmarshalError("no root element")
+}
+
+func (n *xmlNode) isText() bool { return n.name.Local == "" }
+
+// is reports whether the element has the given local name.
+func (n *xmlNode) is(local string) bool { return n.name.Local == local }
+
+// attr returns the value of the attribute with the given local name.
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// attrNS returns the value of the attribute with the given namespace URI and
+// local name.
+func (n *xmlNode) attrNS(space, local string) string {
+	for _, a := range n.attrs {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// child returns the first direct child element with the given local name.
+func (n *xmlNode) child(local string) *xmlNode {
+	for _, c := range n.children {
+		if c.is(local) {
+			return c
+		}
+	}
+	return nil
+}
+
+// all returns the direct child elements with the given local name.
+func (n *xmlNode) all(local string) []*xmlNode {
+	var out []*xmlNode
+	for _, c := range n.children {
+		if c.is(local) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// find returns the first descendant element with the given local name.
+func (n *xmlNode) find(local string) *xmlNode {
+	var found *xmlNode
+	n.walk(func(c *xmlNode) bool {
+		if found == nil && c != n && c.is(local) {
+			found = c
+		}
+		return found == nil
+	})
+	return found
+}
+
+// walk calls fn for n and its descendants in document order; returning false
+// skips the node's children.
+func (n *xmlNode) walk(fn func(*xmlNode) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.children {
+		c.walk(fn)
+	}
+}
+
+// textContent returns the concatenated character data of n's descendants.
+func (n *xmlNode) textContent() string {
+	var b strings.Builder
+	n.walk(func(c *xmlNode) bool {
+		b.WriteString(c.text)
+		return true
+	})
+	return b.String()
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/chromedp/chromedp"
-	md "github.com/JohannesKaufmann/html-to-markdown/v2"
 	"github.com/go-shiori/go-readability"
 )
 
@@ -20,10 +19,11 @@ func (l *BrowserLayer) Convert(ctx context.Context, rawURL string, opts *Options
 	allocCtx, cancel := chromedp.NewContext(ctx)
 	defer cancel()
 
-	var html string
+	var html, location string
 	err := chromedp.Run(allocCtx,
 		chromedp.Navigate(rawURL),
 		chromedp.WaitReady("body"),
+		chromedp.Location(&location),
 		chromedp.OuterHTML("html", &html),
 	)
 	if err != nil {
@@ -32,7 +32,12 @@ func (l *BrowserLayer) Convert(ctx context.Context, rawURL string, opts *Options
 
 	cleaned := CleanHTML(html)
 
-	parsedURL, err := url.Parse(rawURL)
+	// resolve links against the page's final location after redirects
+	pageURL := rawURL
+	if location != "" {
+		pageURL = location
+	}
+	parsedURL, err := url.Parse(pageURL)
 	if err != nil {
 		return "", "", fmt.Errorf("parse url: %w", err)
 	}
@@ -42,14 +47,10 @@ func (l *BrowserLayer) Convert(ctx context.Context, rawURL string, opts *Options
 		return "", "", fmt.Errorf("readability: %w", err)
 	}
 
-	markdown, err := md.ConvertString(article.Content)
+	markdown, err := htmlToMarkdown(article.Content, pageURL, opts)
 	if err != nil {
 		return "", "", fmt.Errorf("html-to-markdown: %w", err)
 	}
 
-	if !opts.RetainImages {
-		markdown = stripImages(markdown)
-	}
-
-	return strings.TrimSpace(markdown), html, nil
+	return markdown, html, nil
 }
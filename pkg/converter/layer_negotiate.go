@@ -23,14 +23,19 @@ func (l *NegotiateLayer) Convert(ctx context.Context, url string, opts *Options)
 	if opts.UserAgent != "" {
 		req.Header.Set("User-Agent", opts.UserAgent)
 	}
+	setConditional(ctx, req)
 
-	client := &http.Client{Timeout: opts.Timeout}
+	client := opts.httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return "", "", fmt.Errorf("fetch: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		recordResponse(ctx, resp)
+		return "", "", errNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
 		return "", "", fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
@@ -45,6 +50,8 @@ func (l *NegotiateLayer) Convert(ctx context.Context, url string, opts *Options)
 		return "", "", fmt.Errorf("read body: %w", err)
 	}
 
+	recordResponse(ctx, resp)
+
 	md := string(body)
 	return md, "", nil // no raw HTML in negotiate path
 }
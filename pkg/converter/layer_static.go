@@ -6,16 +6,17 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 
-	md "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
 	"github.com/elonfeng/url2md/pkg/converter/filetype"
 	"github.com/go-shiori/go-readability"
 )
 
 // StaticLayer fetches content via standard HTTP. For HTML pages, it extracts
 // with go-readability and converts with html-to-markdown. For other file types
-// (PDF, DOCX, XLSX, CSV, images), it uses specialized parsers.
+// (PDF, DOCX, XLSX, EPUB, CSV, images, archives), it uses specialized parsers.
 type StaticLayer struct{}
 
 func (l *StaticLayer) Name() string { return "static" }
@@ -26,7 +27,6 @@ func (l *StaticLayer) Convert(ctx context.Context, rawURL string, opts *Options)
 		return "", "", err
 	}
 
-	ft := filetype.Detect(rawURL, resp, data)
 	ct := ""
 	if resp != nil {
 		ct = resp.Header.Get("Content-Type")
@@ -37,29 +37,128 @@ func (l *StaticLayer) Convert(ctx context.Context, rawURL string, opts *Options)
 	if resp != nil && resp.Request != nil && resp.Request.URL != nil {
 		fnURL = resp.Request.URL.String()
 	}
-	filename := filetype.FilenameFromURL(fnURL)
 
-	switch ft {
+	return convertDocument(ctx, &document{
+		data:        data,
+		fileType:    filetype.Detect(rawURL, resp, data),
+		filename:    filetype.FilenameFromURL(fnURL),
+		url:         rawURL,
+		pageURL:     fnURL,
+		contentType: ct,
+	}, opts)
+}
+
+// document is fetched or local file content ready for conversion.
+type document struct {
+	data        []byte
+	fileType    filetype.Type
+	filename    string // base name shown as the title of file conversions
+	url         string // address of the content, used as the image source
+	pageURL     string // base for resolving relative links in HTML and EPUB
+	contentType string
+}
+
+// convertDocument runs the converter for the document's type, falling back
+// to readability and html-to-markdown for HTML and unknown content.
+func convertDocument(ctx context.Context, doc *document, opts *Options) (string, string, error) {
+	data, filename := doc.data, doc.filename
+	switch doc.fileType {
 	case filetype.TypePDF:
-		markdown, err := filetype.ConvertPDF(data, filename)
+		pages, err := filetype.ParsePageRanges(opts.Pages)
+		if err != nil {
+			return "", "", err
+		}
+		markdown, meta, err := filetype.ConvertPDF(ctx, data, filename, &filetype.PDFOptions{Vision: opts.Vision, Pages: pages})
+		if meta != nil {
+			recordDocMeta(ctx, meta)
+		}
 		return markdown, "", err
 
 	case filetype.TypeDOCX:
-		markdown, err := filetype.ConvertDOCX(data, filename)
+		markdown, err := filetype.ConvertDOCX(data, filename, &filetype.DOCXOptions{RetainImages: opts.RetainImages})
+		return markdown, "", err
+
+	case filetype.TypeDOC:
+		markdown, err := filetype.ConvertDOC(data, filename)
+		return markdown, "", err
+
+	case filetype.TypeRTF:
+		markdown, err := filetype.ConvertRTF(data, filename)
 		return markdown, "", err
 
 	case filetype.TypeXLSX:
-		markdown, err := filetype.ConvertXLSX(data, filename)
+		sheetOpts, err := opts.sheetOptions()
+		if err != nil {
+			return "", "", err
+		}
+		markdown, err := filetype.ConvertXLSX(data, filename, sheetOpts)
 		return markdown, "", err
 
 	case filetype.TypeXLS:
-		markdown, err := filetype.ConvertXLS(data, filename)
+		sheetOpts, err := opts.sheetOptions()
+		if err != nil {
+			return "", "", err
+		}
+		markdown, err := filetype.ConvertXLS(data, filename, sheetOpts)
+		return markdown, "", err
+
+	case filetype.TypeODS:
+		sheetOpts, err := opts.sheetOptions()
+		if err != nil {
+			return "", "", err
+		}
+		markdown, err := filetype.ConvertODS(data, filename, sheetOpts)
 		return markdown, "", err
 
 	case filetype.TypeODT:
 		markdown, err := filetype.ConvertODT(data, filename)
 		return markdown, "", err
 
+	case filetype.TypePPTX:
+		markdown, err := filetype.ConvertPPTX(data, filename)
+		return markdown, "", err
+
+	case filetype.TypeODP:
+		markdown, err := filetype.ConvertODP(data, filename)
+		return markdown, "", err
+
+	case filetype.TypeEPUB:
+		// chapters go through the same html-to-markdown rules as web pages
+		markdown, meta, err := filetype.ConvertEPUB(data, filename, func(html string) (string, error) {
+			return htmlToMarkdown(html, doc.pageURL, opts)
+		})
+		if meta != nil {
+			recordDocMeta(ctx, meta)
+		}
+		return markdown, "", err
+
+	case filetype.TypeIPYNB:
+		nbOpts := &filetype.NotebookOptions{RetainImages: opts.RetainImages, DropOutputs: opts.DropOutputs}
+		markdown, err := filetype.ConvertIPYNB(data, filename, nbOpts, func(html string) (string, error) {
+			// HTML outputs are mostly data frames
+			return htmlToMarkdown(html, doc.pageURL, opts, table.NewTablePlugin())
+		})
+		return markdown, "", err
+
+	case filetype.TypeZIP, filetype.TypeTAR, filetype.TypeGZIP:
+		// entries go through the same converters; their document metadata
+		// must not become the archive's, so they get no fetch state
+		entryCtx := withFetchState(ctx, nil)
+		markdown, err := filetype.ConvertArchive(data, doc.fileType, filename, func(name string, t filetype.Type, data []byte) (string, error) {
+			md, _, err := convertDocument(entryCtx, &document{
+				data:     data,
+				fileType: t,
+				filename: path.Base(name),
+				url:      name,
+			}, opts)
+			return md, err
+		})
+		return markdown, "", err
+
+	case filetype.TypeCode:
+		markdown, err := filetype.ConvertCode(data, filename, doc.contentType, &filetype.CodeOptions{DocComments: opts.DocComments})
+		return markdown, "", err
+
 	case filetype.TypeCSV:
 		markdown, err := filetype.ConvertCSV(data, filename)
 		return markdown, "", err
@@ -81,23 +180,23 @@ func (l *StaticLayer) Convert(ctx context.Context, rawURL string, opts *Options)
 		return markdown, "", err
 
 	case filetype.TypeSVG:
-		markdown, err := filetype.ConvertImage(ctx, data, filename, rawURL, "image/svg+xml", opts.Vision)
+		markdown, err := filetype.ConvertImage(ctx, data, filename, doc.url, "image/svg+xml", opts.Vision)
 		return markdown, "", err
 
 	case filetype.TypePNG, filetype.TypeJPEG, filetype.TypeGIF, filetype.TypeWEBP:
-		markdown, err := filetype.ConvertImage(ctx, data, filename, rawURL, ct, opts.Vision)
+		markdown, err := filetype.ConvertImage(ctx, data, filename, doc.url, doc.contentType, opts.Vision)
 		return markdown, "", err
 	}
 
-	// default: HTML
-	return l.convertHTML(data, rawURL, opts)
+	// default: HTML, with links resolved against the final URL
+	return convertHTML(data, doc.pageURL, opts)
 }
 
-func (l *StaticLayer) convertHTML(data []byte, rawURL string, opts *Options) (string, string, error) {
+func convertHTML(data []byte, pageURL string, opts *Options) (string, string, error) {
 	html := string(data)
 	cleaned := CleanHTML(html)
 
-	parsedURL, err := url.Parse(rawURL)
+	parsedURL, err := url.Parse(pageURL)
 	if err != nil {
 		return "", "", fmt.Errorf("parse url: %w", err)
 	}
@@ -107,16 +206,12 @@ func (l *StaticLayer) convertHTML(data []byte, rawURL string, opts *Options) (st
 		return "", "", fmt.Errorf("readability: %w", err)
 	}
 
-	markdown, err := md.ConvertString(article.Content)
+	markdown, err := htmlToMarkdown(article.Content, pageURL, opts)
 	if err != nil {
 		return "", "", fmt.Errorf("html-to-markdown: %w", err)
 	}
 
-	if !opts.RetainImages {
-		markdown = stripImages(markdown)
-	}
-
-	return strings.TrimSpace(markdown), html, nil
+	return markdown, html, nil
 }
 
 func (l *StaticLayer) fetchRaw(ctx context.Context, rawURL string, opts *Options) ([]byte, *http.Response, error) {
@@ -129,14 +224,19 @@ func (l *StaticLayer) fetchRaw(ctx context.Context, rawURL string, opts *Options
 	if opts.UserAgent != "" {
 		req.Header.Set("User-Agent", opts.UserAgent)
 	}
+	setConditional(ctx, req)
 
-	client := &http.Client{Timeout: opts.Timeout}
+	client := opts.httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("fetch: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified {
+		recordResponse(ctx, resp)
+		return nil, resp, errNotModified
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, resp, fmt.Errorf("HTTP %d", resp.StatusCode)
 	}
@@ -145,6 +245,7 @@ func (l *StaticLayer) fetchRaw(ctx context.Context, rawURL string, opts *Options
 	if err != nil {
 		return nil, resp, fmt.Errorf("read body: %w", err)
 	}
+	recordResponse(ctx, resp)
 
 	return body, resp, nil
 }
@@ -0,0 +1,257 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// buildOLE writes a version 3 compound file holding the given top-level
+// streams. Streams are padded past the mini stream cutoff so they all live in
+// regular sectors.
+func buildOLE(t *testing.T, streams [][2][]byte) []byte {
+	t.Helper()
+	const (
+		endOfChain = 0xFFFFFFFE
+		noStream   = 0xFFFFFFFF
+	)
+	if len(streams) > 3 {
+		t.Fatal("buildOLE: at most 3 streams")
+	}
+	le := binary.LittleEndian
+
+	fat := []uint32{0xFFFFFFFD, endOfChain} // the FAT and directory sectors
+	var body []byte
+	dir := make([]byte, 512)
+	entry := func(i int, name string, kind byte, child, right, start uint32, size int) {
+		e := dir[i*128 : (i+1)*128]
+		u := utf16.Encode([]rune(name))
+		for j, c := range u {
+			le.PutUint16(e[j*2:], c)
+		}
+		le.PutUint16(e[64:], uint16(len(u)*2+2))
+		e[66], e[67] = kind, 1
+		le.PutUint32(e[68:], noStream)
+		le.PutUint32(e[72:], right)
+		le.PutUint32(e[76:], child)
+		le.PutUint32(e[116:], start)
+		le.PutUint32(e[120:], uint32(size))
+	}
+	entry(0, "Root Entry", 5, 1, noStream, endOfChain, 0)
+	for i, s := range streams {
+		data := s[1]
+		size := max(4096, (len(data)+511)/512*512)
+		data = append(data, make([]byte, size-len(data))...)
+		start := uint32(len(fat))
+		for j := range size / 512 {
+			next := start + uint32(j) + 1
+			if j == size/512-1 {
+				next = endOfChain
+			}
+			fat = append(fat, next)
+		}
+		right := uint32(noStream)
+		if i+1 < len(streams) {
+			right = uint32(i + 2)
+		}
+		entry(i+1, string(s[0]), 2, noStream, right, start, size)
+		body = append(body, data...)
+	}
+	for i := len(streams) + 1; i < 4; i++ {
+		le.PutUint32(dir[i*128+68:], noStream)
+		le.PutUint32(dir[i*128+72:], noStream)
+		le.PutUint32(dir[i*128+76:], noStream)
+	}
+	if len(fat) > 128 {
+		t.Fatal("buildOLE: streams too large")
+	}
+
+	header := make([]byte, 512)
+	copy(header, "\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1")
+	le.PutUint16(header[24:], 0x3E)
+	le.PutUint16(header[26:], 3)
+	le.PutUint16(header[28:], 0xFFFE)
+	le.PutUint16(header[30:], 9)
+	le.PutUint16(header[32:], 6)
+	le.PutUint32(header[44:], 1) // FAT sectors
+	le.PutUint32(header[48:], 1) // first directory sector
+	le.PutUint32(header[56:], 4096)
+	le.PutUint32(header[60:], endOfChain)
+	le.PutUint32(header[68:], endOfChain)
+	for i := range 109 {
+		le.PutUint32(header[76+i*4:], noStream)
+	}
+	le.PutUint32(header[76:], 0)
+
+	fatSector := make([]byte, 512)
+	for i := range 128 {
+		v := uint32(noStream)
+		if i < len(fat) {
+			v = fat[i]
+		}
+		le.PutUint32(fatSector[i*4:], v)
+	}
+
+	var out bytes.Buffer
+	out.Write(header)
+	out.Write(fatSector)
+	out.Write(dir)
+	out.Write(body)
+	return out.Bytes()
+}
+
+// buildTestDOC writes a Word 97 document whose paragraphs each carry the
+// given style index and paragraph sprms.
+func buildTestDOC(t *testing.T, paras []struct {
+	text  string
+	istd  uint16
+	sprms []byte
+}) []byte {
+	t.Helper()
+	le := binary.LittleEndian
+	const textFC, fkpPage = 1024, 4
+
+	// WordDocument: FIB, UTF-16 text at textFC, one paragraph FKP page
+	word := make([]byte, (fkpPage+1)*512)
+	le.PutUint16(word[0:], 0xA5EC)
+	le.PutUint16(word[2:], 0xC1)
+	le.PutUint16(word[10:], 0x0200) // 1Table
+	le.PutUint16(word[32:], 14)     // csw
+	le.PutUint16(word[62:], 22)     // cslw
+	le.PutUint16(word[152:], 93)    // cbRgFcLcb
+	fcLcb := func(i int, fc, lcb int) {
+		le.PutUint32(word[154+i*8:], uint32(fc))
+		le.PutUint32(word[154+i*8+4:], uint32(lcb))
+	}
+
+	var text []uint16
+	for _, p := range paras {
+		text = append(text, utf16.Encode([]rune(p.text))...)
+	}
+	le.PutUint32(word[76:], uint32(len(text))) // ccpText
+	for i, c := range text {
+		le.PutUint16(word[textFC+i*2:], c)
+	}
+
+	page := word[fkpPage*512 : (fkpPage+1)*512]
+	crun := len(paras)
+	fc := textFC
+	papxEnd := 511
+	for i, p := range paras {
+		le.PutUint32(page[i*4:], uint32(fc))
+		fc += len(utf16.Encode([]rune(p.text))) * 2
+		grpprl := binary.LittleEndian.AppendUint16(nil, p.istd)
+		grpprl = append(grpprl, p.sprms...)
+		var papx []byte
+		if len(grpprl)%2 == 1 {
+			papx = append([]byte{byte((len(grpprl) + 1) / 2)}, grpprl...)
+		} else {
+			papx = append([]byte{0, byte(len(grpprl) / 2)}, grpprl...)
+		}
+		papxEnd = (papxEnd - len(papx)) &^ 1
+		copy(page[papxEnd:], papx)
+		page[(crun+1)*4+i*13] = byte(papxEnd / 2)
+	}
+	le.PutUint32(page[crun*4:], uint32(fc))
+	page[511] = byte(crun)
+
+	// 1Table: style sheet, PlcBtePapx and piece table
+	var table []byte
+	stshi := make([]byte, 18)
+	le.PutUint16(stshi[0:], 2)  // cstd
+	le.PutUint16(stshi[2:], 10) // cbSTDBaseInFile
+	table = le.AppendUint16(table, uint16(len(stshi)))
+	table = append(table, stshi...)
+	for sti, name := range []string{"Normal", "heading 1"} {
+		std := make([]byte, 10)
+		le.PutUint16(std, uint16(sti))
+		std = le.AppendUint16(std, uint16(len(name)))
+		for _, c := range utf16.Encode([]rune(name)) {
+			std = le.AppendUint16(std, c)
+		}
+		std = le.AppendUint16(std, 0)
+		table = le.AppendUint16(table, uint16(len(std)))
+		table = append(table, std...)
+	}
+	fcLcb(1, 0, len(table))
+
+	plcStart := len(table)
+	table = le.AppendUint32(table, textFC)
+	table = le.AppendUint32(table, uint32(fc))
+	table = le.AppendUint32(table, fkpPage)
+	fcLcb(13, plcStart, len(table)-plcStart)
+
+	clxStart := len(table)
+	table = append(table, 2)
+	table = le.AppendUint32(table, 16)
+	table = le.AppendUint32(table, 0)
+	table = le.AppendUint32(table, uint32(len(text)))
+	table = append(table, 0, 0)
+	table = le.AppendUint32(table, textFC)
+	table = append(table, 0, 0)
+	fcLcb(33, clxStart, len(table)-clxStart)
+
+	return buildOLE(t, [][2][]byte{{[]byte("WordDocument"), word}, {[]byte("1Table"), table}})
+}
+
+func TestConverter_DOC(t *testing.T) {
+	inTable := []byte{0x16, 0x24, 1}
+	rowEnd := []byte{0x16, 0x24, 1, 0x17, 0x24, 1}
+	data := buildTestDOC(t, []struct {
+		text  string
+		istd  uint16
+		sprms []byte
+	}{
+		{"Field report\r", 1, nil},
+		{"Ferns\ttolerate shade.\r", 0, nil},
+		{"Site\a", 0, inTable},
+		{"Count\a", 0, inTable},
+		{"\a", 0, rowEnd},
+		{"North\a", 0, inTable},
+		{"12\a", 0, inTable},
+		{"\a", 0, rowEnd},
+		{"See \x13 HYPERLINK \"https://example.org\" \x14the society\x15 for more.\r", 0, nil},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"# Field report",
+		"Ferns tolerate shade.",
+		"| Site | Count |\n| --- | --- |\n| North | 12 |",
+		"See the society for more.",
+	})
+}
+
+func TestConverter_RTF(t *testing.T) {
+	data := []byte(`{\rtf1\ansi\ansicpg1252\deff0{\fonttbl{\f0 Times;}{\f1\fcharset2 Symbol;}}
+{\stylesheet{\s0 Normal;}{\s1\outlinelevel0 heading 1;}{\s2 heading 2;}{\*\cs10 Default Paragraph Font;}}
+{\info{\title Hidden title}}{\header\pard Page header\par}
+\pard\plain\s1 Annual \'93Report\'94\par
+\pard Ferns are {\b hardy} and {\i quite common}, caf\u233?s too.\par
+\pard\s2 Details\par
+\pard See {\field{\*\fldinst{ HYPERLINK "https://example.org/ferns" }}{\fldrslt{\ul the society}}} for more.\par
+{\listtext\f1 \'b7\tab}\pard\ls1 Collect\par
+{\listtext 1.\tab}\pard\ls2 Press\par
+\trowd\cellx1000\cellx2000\pard\intbl Site\cell Count\cell\row
+\trowd\cellx1000\cellx2000\pard\intbl North\cell 12\par more\cell\row
+\pard After{\*\bkmkstart end}{\pict\pngblip 89504e47}\par
+}`)
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"# Annual “Report”",
+		"Ferns are **hardy** and _quite common_, cafés too.",
+		"## Details",
+		"See [the society](https://example.org/ferns) for more.",
+		"- Collect\n1. Press",
+		"| Site | Count |\n| --- | --- |\n| North | 12 more |",
+		"After",
+	})
+	for _, hidden := range []string{"Hidden title", "Page header", "Default Paragraph Font", "89504e47"} {
+		if strings.Contains(md, hidden) {
+			t.Errorf("unexpected %q in output:\n%s", hidden, md)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	mdconv "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"golang.org/x/net/html"
+)
+
+// Link modes for Options.LinkMode.
+const (
+	LinkInline    = "inline"    // [text](url)
+	LinkReference = "reference" // [text][1] with "[1]: url" collected at the bottom
+	LinkText      = "text"      // link text only
+)
+
+// linkMode resolves the effective link mode; RetainLinks=false forces text.
+func (o *Options) linkMode() string {
+	if !o.RetainLinks {
+		return LinkText
+	}
+	switch o.LinkMode {
+	case LinkReference, LinkText:
+		return o.LinkMode
+	}
+	return LinkInline
+}
+
+// htmlToMarkdown converts extracted article HTML to markdown, rendering links
+// according to opts and resolving relative URLs against pageURL, which should
+// be the final URL after redirects. Extra plugins extend the base CommonMark
+// rules.
+func htmlToMarkdown(content, pageURL string, opts *Options, plugins ...mdconv.Plugin) (string, error) {
+	conv := mdconv.NewConverter(
+		mdconv.WithPlugins(append([]mdconv.Plugin{
+			base.NewBasePlugin(),
+			commonmark.NewCommonmarkPlugin(),
+		}, plugins...)...),
+	)
+
+	var refs []string
+	switch opts.linkMode() {
+	case LinkText:
+		conv.Register.RendererFor("a", mdconv.TagTypeInline, func(ctx mdconv.Context, w mdconv.Writer, n *html.Node) mdconv.RenderStatus {
+			ctx.RenderChildNodes(ctx, w, n)
+			return mdconv.RenderSuccess
+		}, mdconv.PriorityEarly)
+
+	case LinkReference:
+		index := make(map[string]int)
+		conv.Register.RendererFor("a", mdconv.TagTypeInline, func(ctx mdconv.Context, w mdconv.Writer, n *html.Node) mdconv.RenderStatus {
+			href := ctx.AssembleAbsoluteURL(ctx, "a", strings.TrimSpace(attr(n, "href")))
+			if href == "" || strings.HasPrefix(href, "#") {
+				return mdconv.RenderTryNext
+			}
+
+			var buf bytes.Buffer
+			ctx.RenderChildNodes(ctx, &buf, n)
+			text := strings.Join(strings.Fields(buf.String()), " ")
+			if text == "" {
+				return mdconv.RenderTryNext
+			}
+
+			i, ok := index[href]
+			if !ok {
+				refs = append(refs, href)
+				i = len(refs)
+				index[href] = i
+			}
+			if raw := buf.Bytes(); len(raw) > 0 && (raw[0] == ' ' || raw[0] == '\n') {
+				w.WriteString(" ")
+			}
+			fmt.Fprintf(w, "[%s][%d]", text, i)
+			if raw := buf.Bytes(); len(raw) > 0 && (raw[len(raw)-1] == ' ' || raw[len(raw)-1] == '\n') {
+				w.WriteString(" ")
+			}
+			return mdconv.RenderSuccess
+		}, mdconv.PriorityEarly)
+	}
+
+	markdown, err := conv.ConvertString(content, mdconv.WithDomain(pageURL))
+	if err != nil {
+		return "", err
+	}
+
+	if !opts.RetainImages {
+		markdown = stripImages(markdown)
+	}
+	markdown = strings.TrimSpace(markdown)
+
+	if len(refs) > 0 {
+		var b strings.Builder
+		b.WriteString(markdown)
+		b.WriteString("\n\n")
+		for i, href := range refs {
+			if strings.ContainsAny(href, " \t") {
+				href = "<" + href + ">"
+			}
+			fmt.Fprintf(&b, "[%d]: %s\n", i+1, href)
+		}
+		markdown = strings.TrimRight(b.String(), "\n")
+	}
+	return markdown, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package converter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const linkHTML = `<p>Read the <a href="/docs/intro">intro</a>, then the
+<a href="guide.html" title="Guide">guide</a> and the <a href="/docs/intro">intro again</a>.</p>`
+
+func TestHTMLToMarkdown_LinkModes(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		want   []string
+		reject []string
+	}{
+		{
+			name: "inline",
+			opts: Options{RetainLinks: true},
+			want: []string{"[intro](https://example.com/docs/intro)", `[guide](https://example.com/a/guide.html "Guide")`},
+		},
+		{
+			name: "reference",
+			opts: Options{RetainLinks: true, LinkMode: LinkReference},
+			want: []string{
+				"[intro][1]", "[guide][2]", "[intro again][1]",
+				"[1]: https://example.com/docs/intro\n[2]: https://example.com/a/guide.html",
+			},
+			reject: []string{"]("},
+		},
+		{
+			name:   "text",
+			opts:   Options{RetainLinks: true, LinkMode: LinkText},
+			want:   []string{"Read the intro, then the", "guide and the intro again."},
+			reject: []string{"[", "https://"},
+		},
+		{
+			name:   "retain links disabled",
+			opts:   Options{RetainLinks: false, LinkMode: LinkReference},
+			want:   []string{"Read the intro"},
+			reject: []string{"["},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlToMarkdown(linkHTML, "https://example.com/a/page", &tt.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("expected %q in:\n%s", w, got)
+				}
+			}
+			for _, r := range tt.reject {
+				if strings.Contains(got, r) {
+					t.Errorf("did not expect %q in:\n%s", r, got)
+				}
+			}
+		})
+	}
+}
+
+func TestConverter_LinksResolveAgainstRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new/article", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new/article", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Moved</title></head><body><article>
+		<p>This article moved. See the <a href="related">related page</a> for more details on the subject.</p>
+		<p>Second paragraph with enough words for the readability extraction to keep it around.</p>
+		<p>Third paragraph of substantial content to make sure the extraction succeeds.</p>
+		</article></body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+
+	result, err := New().Convert(context.Background(), srv.URL+"/old", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := srv.URL + "/new/related"
+	if !strings.Contains(result.Markdown, "[related page]("+want+")") {
+		t.Errorf("expected link resolved against final URL %s, got:\n%s", want, result.Markdown)
+	}
+	if len(result.Links) != 1 || result.Links[0] != want {
+		t.Errorf("unexpected links: %v", result.Links)
+	}
+}
+
+func TestConverter_UnknownLinkMode(t *testing.T) {
+	opts := DefaultOptions()
+	opts.LinkMode = "footnote"
+	if _, err := New().Convert(context.Background(), "https://example.com/a", opts); err == nil || !strings.Contains(err.Error(), "unknown link mode") {
+		t.Errorf("expected unknown link mode error from Convert, got %v", err)
+	}
+	if _, err := ConvertBytes(context.Background(), []byte("text"), "a.txt", "", opts); err == nil || !strings.Contains(err.Error(), "unknown link mode") {
+		t.Errorf("expected unknown link mode error from ConvertBytes, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package converter
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/elonfeng/url2md/pkg/converter/filetype"
+)
+
+// ConvertBytes converts document data that is already in memory, such as a
+// local file or stdin, with the same type detection and converters the static
+// layer applies to downloads. name is a file name, path or URL and may be
+// empty; its extension takes priority over contentType and magic bytes when
+// detecting the type, and a URL or absolute path also resolves relative links.
+// Network options (Method, Cache, RespectRobots, Timeout) do not apply.
+func ConvertBytes(ctx context.Context, data []byte, name, contentType string, opts *Options) (*Result, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	tok, err := prepare(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &document{
+		data:        data,
+		fileType:    filetype.DetectFile(name, contentType, data),
+		contentType: contentType,
+	}
+	if u, err := url.Parse(name); err == nil && len(u.Scheme) > 1 { // not a Windows drive letter
+		doc.filename = filetype.FilenameFromURL(name)
+		doc.url, doc.pageURL = name, name
+	} else if name != "" {
+		doc.filename = filepath.Base(name)
+		if filepath.IsAbs(name) {
+			fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(name)}).String()
+			doc.url, doc.pageURL = fileURL, fileURL
+		}
+	}
+
+	start := time.Now()
+	state := &fetchState{finalURL: doc.pageURL}
+	md, rawHTML, err := convertDocument(withFetchState(ctx, state), doc, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	result := buildResult(name, md, rawHTML, state, tok, opts)
+	result.Method = "static"
+	result.ConvertTime = time.Since(start)
+	return result, nil
+}
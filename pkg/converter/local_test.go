@@ -0,0 +1,73 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/elonfeng/url2md/internal/token"
+)
+
+func TestConvertBytes(t *testing.T) {
+	ctx := context.Background()
+	csv := []byte("name,qty\nfern,3\n")
+
+	t.Run("extension", func(t *testing.T) {
+		result, err := ConvertBytes(ctx, csv, "/data/plants.csv", "", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectInOrder(t, result.Markdown, []string{"# plants.csv", "| name | qty |", "| fern | 3 |"})
+		if result.URL != "/data/plants.csv" || result.Method != "static" {
+			t.Errorf("URL = %q, Method = %q", result.URL, result.Method)
+		}
+	})
+
+	t.Run("content type", func(t *testing.T) {
+		result, err := ConvertBytes(ctx, csv, "", "text/csv", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result.Markdown, "| fern | 3 |") {
+			t.Errorf("expected a table, got:\n%s", result.Markdown)
+		}
+	})
+
+	t.Run("magic bytes", func(t *testing.T) {
+		result, err := ConvertBytes(ctx, buildTestXLSX(t), "", "", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !strings.Contains(result.Markdown, "## Summary") {
+			t.Errorf("expected spreadsheet sheets, got:\n%s", result.Markdown)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		html := `<html><head><title>Fern Guide</title></head><body><article>
+<p>Ferns are among the oldest plants and grow in shady, damp places all over the world.
+See <a href="care.html">the care notes</a> and <a href="https://example.com/ferns">the society</a>.</p>
+</article></body></html>`
+		result, err := ConvertBytes(ctx, []byte(html), "/docs/ferns.html", "", nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Title != "Fern Guide" {
+			t.Errorf("Title = %q, want %q", result.Title, "Fern Guide")
+		}
+		if !strings.Contains(result.Markdown, "[the care notes](file:///docs/care.html)") {
+			t.Errorf("expected link resolved against the file, got:\n%s", result.Markdown)
+		}
+		if len(result.Links) != 1 || result.Links[0] != "https://example.com/ferns" {
+			t.Errorf("Links = %v", result.Links)
+		}
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		_, err := ConvertBytes(ctx, csv, "plants.csv", "", &Options{Tokenizer: "nope"})
+		if !errors.Is(err, token.ErrUnknownTokenizer) {
+			t.Errorf("err = %v, want ErrUnknownTokenizer", err)
+		}
+	})
+}
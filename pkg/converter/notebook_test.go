@@ -0,0 +1,74 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+const testNotebook = `{
+ "nbformat": 4,
+ "nbformat_minor": 5,
+ "metadata": {
+  "kernelspec": {"name": "python3", "language": "python", "display_name": "Python 3"},
+  "language_info": {"name": "python"}
+ },
+ "cells": [
+  {"cell_type": "markdown", "metadata": {}, "source": ["# Fern survey\n", "\n", "Counts by **site**.\n", "![map](attachment:map.png)"],
+   "attachments": {"map.png": {"image/png": "iVBORw0KGgo="}}},
+  {"cell_type": "code", "execution_count": 1, "metadata": {}, "source": "import pandas as pd\nprint(\"loading\")\ndf = pd.read_csv(\"ferns.csv\")\ndf",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["loading\n"]},
+    {"output_type": "stream", "name": "stdout", "text": "done\n"},
+    {"output_type": "execute_result", "execution_count": 1, "metadata": {},
+     "data": {"text/plain": ["  site  count\n", "0 north 12"], "text/html": "<table><thead><tr><th>site</th><th>count</th></tr></thead><tbody><tr><td>north</td><td>12</td></tr></tbody></table>"}}
+   ]},
+  {"cell_type": "code", "execution_count": 2, "metadata": {}, "source": ["df.plot()"],
+   "outputs": [
+    {"output_type": "display_data", "metadata": {}, "data": {"text/plain": ["<Figure size 640x480 with 1 Axes>"], "image/png": "iVBORw0KGgo=\n"}}
+   ]},
+  {"cell_type": "code", "execution_count": 3, "metadata": {}, "source": "1/0",
+   "outputs": [
+    {"output_type": "error", "ename": "ZeroDivisionError", "evalue": "division by zero",
+     "traceback": ["\u001b[0;31mZeroDivisionError\u001b[0m: division by zero"]}
+   ]}
+ ]
+}`
+
+func TestConverter_IPYNB(t *testing.T) {
+	md := convertServed(t, []byte(testNotebook), nil)
+	expectInOrder(t, md, []string{
+		"# Fern survey\n\nCounts by **site**.",
+		"```python\nimport pandas as pd\nprint(\"loading\")",
+		"```text\nloading\ndone\n```",
+		"| site  | count |",
+		"| north | 12    |",
+		"```python\ndf.plot()\n```",
+		"```python\n1/0\n```",
+		"```text\nZeroDivisionError: division by zero\n```",
+	})
+	for _, unwanted := range []string{"# download", "attachment:", "<Figure", "base64", "\x1b["} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, md)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.RetainImages = true
+	md = convertServed(t, []byte(testNotebook), opts)
+	expectInOrder(t, md, []string{
+		"![map](data:image/png;base64,iVBORw0KGgo=)",
+		"```python\ndf.plot()\n```\n\n![output](data:image/png;base64,iVBORw0KGgo=)",
+	})
+
+	opts = DefaultOptions()
+	opts.Method = "static"
+	opts.DropOutputs = true
+	md = convertServed(t, []byte(testNotebook), opts)
+	if strings.Contains(md, "loading\ndone") || strings.Contains(md, "north") || strings.Contains(md, "ZeroDivisionError") {
+		t.Errorf("expected outputs dropped, got:\n%s", md)
+	}
+	if !strings.Contains(md, "```python\n1/0\n```") {
+		t.Errorf("expected code kept, got:\n%s", md)
+	}
+}
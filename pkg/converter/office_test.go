@@ -0,0 +1,482 @@
+package converter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elonfeng/url2md/pkg/converter/filetype"
+	"github.com/xuri/excelize/v2"
+)
+
+// buildZip writes the files, in order, into a ZIP archive.
+func buildZip(t *testing.T, files [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		w, err := zw.Create(f[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(f[1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// convertServed serves data as an extensionless binary download so detection
+// has to rely on the archive contents. A nil opts uses the static defaults.
+func convertServed(t *testing.T, data []byte, opts *Options) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	if opts == nil {
+		opts = DefaultOptions()
+		opts.Method = "static"
+	}
+	result, err := New().Convert(context.Background(), srv.URL+"/download", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result.Markdown
+}
+
+const (
+	pptxNS = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	relsNS = `xmlns="http://schemas.openxmlformats.org/package/2006/relationships"`
+)
+
+func TestConverter_PPTX(t *testing.T) {
+	slide1 := `<p:sld ` + pptxNS + `><p:cSld><p:spTree>
+<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>
+<p:txBody><a:p><a:r><a:t>Quarterly Review</a:t></a:r></a:p></p:txBody></p:sp>
+<p:sp><p:nvSpPr><p:cNvPr id="3" name="Body"/><p:cNvSpPr/><p:nvPr><p:ph idx="1"/></p:nvPr></p:nvSpPr>
+<p:txBody><a:p><a:r><a:t>Revenue up</a:t></a:r></a:p><a:p><a:pPr lvl="1"/><a:r><a:t>Mostly </a:t></a:r><a:r><a:t>EMEA</a:t></a:r></a:p></p:txBody></p:sp>
+<p:sp><p:nvSpPr><p:cNvPr id="4" name="Num"/><p:cNvSpPr/><p:nvPr><p:ph type="sldNum"/></p:nvPr></p:nvSpPr>
+<p:txBody><a:p><a:r><a:t>1</a:t></a:r></a:p></p:txBody></p:sp>
+<p:pic><p:nvPicPr><p:cNvPr id="5" name="Picture" descr="Revenue chart"/><p:cNvPicPr/><p:nvPr/></p:nvPicPr>
+<p:blipFill><a:blip r:embed="rId2"/></p:blipFill></p:pic>
+</p:spTree></p:cSld></p:sld>`
+	slide2 := `<p:sld ` + pptxNS + `><p:cSld><p:spTree>
+<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>
+<p:txBody><a:p><a:r><a:t>Numbers</a:t></a:r></a:p></p:txBody></p:sp>
+<p:graphicFrame><a:graphic><a:graphicData><a:tbl>
+<a:tr><a:tc><a:txBody><a:p><a:r><a:t>Region</a:t></a:r></a:p></a:txBody></a:tc><a:tc><a:txBody><a:p><a:r><a:t>Sales</a:t></a:r></a:p></a:txBody></a:tc></a:tr>
+<a:tr><a:tc><a:txBody><a:p><a:r><a:t>EMEA</a:t></a:r></a:p></a:txBody></a:tc><a:tc><a:txBody><a:p><a:r><a:t>42</a:t></a:r></a:p></a:txBody></a:tc></a:tr>
+</a:tbl></a:graphicData></a:graphic></p:graphicFrame>
+</p:spTree></p:cSld></p:sld>`
+	notes := `<p:notes ` + pptxNS + `><p:cSld><p:spTree>
+<p:sp><p:nvSpPr><p:cNvPr id="2" name="Image"/><p:cNvSpPr/><p:nvPr><p:ph type="sldImg"/></p:nvPr></p:nvSpPr></p:sp>
+<p:sp><p:nvSpPr><p:cNvPr id="3" name="Notes"/><p:cNvSpPr/><p:nvPr><p:ph type="body" idx="1"/></p:nvPr></p:nvSpPr>
+<p:txBody><a:p><a:r><a:t>Mention the EMEA deal.</a:t></a:r></a:p></p:txBody></p:sp>
+</p:spTree></p:cSld></p:notes>`
+
+	// slide files are listed out of order; presentation.xml decides the order
+	data := buildZip(t, [][2]string{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`},
+		{"ppt/presentation.xml", `<p:presentation ` + pptxNS + `><p:sldIdLst><p:sldId id="256" r:id="rId7"/><p:sldId id="257" r:id="rId8"/></p:sldIdLst></p:presentation>`},
+		{"ppt/_rels/presentation.xml.rels", `<Relationships ` + relsNS + `>
+<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide10.xml"/>
+<Relationship Id="rId8" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/slide" Target="slides/slide2.xml"/>
+</Relationships>`},
+		{"ppt/slides/slide2.xml", slide2},
+		{"ppt/slides/slide10.xml", slide1},
+		{"ppt/slides/_rels/slide10.xml.rels", `<Relationships ` + relsNS + `>
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/notesSlide" Target="../notesSlides/notesSlide1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="../media/image1.png"/>
+</Relationships>`},
+		{"ppt/notesSlides/notesSlide1.xml", notes},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"## Slide 1: Quarterly Review",
+		"- Revenue up\n  - Mostly EMEA",
+		"![Revenue chart](image1.png)",
+		"### Notes\n\nMention the EMEA deal.",
+		"## Slide 2: Numbers",
+		"| Region | Sales |\n| --- | --- |\n| EMEA | 42 |",
+	})
+	if strings.Contains(md, "\n1\n") {
+		t.Errorf("slide number placeholder should be dropped:\n%s", md)
+	}
+}
+
+const odpNS = `xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:draw="urn:oasis:names:tc:opendocument:xmlns:drawing:1.0" xmlns:presentation="urn:oasis:names:tc:opendocument:xmlns:presentation:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:svg="urn:oasis:names:tc:opendocument:xmlns:svg-compatible:1.0" xmlns:xlink="http://www.w3.org/1999/xlink"`
+
+func TestConverter_ODP(t *testing.T) {
+	content := `<office:document-content ` + odpNS + `><office:automatic-styles>
+<text:list-style style:name="L2" xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"><text:list-level-style-number text:level="1"/></text:list-style>
+</office:automatic-styles><office:body><office:presentation>
+<draw:page draw:name="page1">
+<draw:frame presentation:class="title"><draw:text-box><text:p>Roadmap</text:p></draw:text-box></draw:frame>
+<draw:frame presentation:class="outline"><draw:text-box>
+<text:list><text:list-item><text:p>Ship v2</text:p><text:list><text:list-item><text:p>Beta in <text:span>May</text:span></text:p></text:list-item></text:list></text:list-item></text:list>
+<text:list text:style-name="L2"><text:list-item><text:p>Plan</text:p></text:list-item><text:list-item><text:p>Build</text:p></text:list-item></text:list>
+</draw:text-box></draw:frame>
+<draw:frame presentation:class="page-number"><draw:text-box><text:p>1</text:p></draw:text-box></draw:frame>
+<draw:frame><draw:image xlink:href="Pictures/diagram.png"/><svg:title>Architecture diagram</svg:title></draw:frame>
+<presentation:notes><draw:page-thumbnail presentation:class="page"/>
+<draw:frame presentation:class="notes"><draw:text-box><text:p>Keep it short.</text:p></draw:text-box></draw:frame></presentation:notes>
+</draw:page>
+<draw:page draw:name="page2">
+<draw:frame><table:table><table:table-row><table:table-cell><text:p>Q1</text:p></table:table-cell><table:table-cell><text:p>Q2</text:p></table:table-cell></table:table-row>
+<table:table-row><table:table-cell table:number-columns-repeated="2"><text:p>TBD</text:p></table:table-cell></table:table-row></table:table></draw:frame>
+</draw:page>
+</office:presentation></office:body></office:document-content>`
+
+	data := buildZip(t, [][2]string{
+		{"mimetype", "application/vnd.oasis.opendocument.presentation"},
+		{"content.xml", content},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"## Slide 1: Roadmap",
+		"- Ship v2\n  - Beta in May",
+		"1. Plan\n2. Build",
+		"![Architecture diagram](diagram.png)",
+		"### Notes\n\nKeep it short.",
+		"## Slide 2",
+		"| Q1 | Q2 |\n| --- | --- |\n| TBD | TBD |",
+	})
+}
+
+const docxNS = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"`
+
+func TestConverter_DOCX(t *testing.T) {
+	para := func(style, numID, ilvl, runs string) string {
+		pr := ""
+		if style != "" {
+			pr += `<w:pStyle w:val="` + style + `"/>`
+		}
+		if numID != "" {
+			pr += `<w:numPr><w:ilvl w:val="` + ilvl + `"/><w:numId w:val="` + numID + `"/></w:numPr>`
+		}
+		return `<w:p><w:pPr>` + pr + `</w:pPr>` + runs + `</w:p>`
+	}
+	run := func(text string) string { return `<w:r><w:t xml:space="preserve">` + text + `</w:t></w:r>` }
+
+	document := `<w:document ` + docxNS + `><w:body>` +
+		para("Title", "", "", run("Annual Report")) +
+		para("Heading4", "", "", run("Scope")) +
+		para("", "", "", run("See ")+`<w:hyperlink r:id="rId5">`+run("our site")+`</w:hyperlink>`+run(" and ")+
+			`<w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText> HYPERLINK "https://example.org/faq" </w:instrText></w:r>`+
+			`<w:r><w:fldChar w:fldCharType="separate"/></w:r>`+run("the FAQ")+`<w:r><w:fldChar w:fldCharType="end"/></w:r>`+
+			run(".")+`<w:r><w:footnoteReference w:id="2"/></w:r>`) +
+		para("", "", "", `<w:r><w:rPr><w:b/></w:rPr><w:t xml:space="preserve">Bold </w:t></w:r><w:r><w:rPr><w:b/></w:rPr><w:t>text</w:t></w:r>`+
+			`<w:r><w:rPr><w:i w:val="0"/></w:rPr><w:t xml:space="preserve"> plain</w:t></w:r><w:r><w:commentReference w:id="0"/></w:r>`) +
+		para("", "", "", run("Still unaudited.")+`<w:r><w:footnoteReference w:id="2"/></w:r>`) +
+		para("", "1", "0", run("Apples")) +
+		para("", "1", "1", run("Green")) +
+		para("", "2", "0", run("First")) +
+		para("", "2", "1", run("Detail")) +
+		para("", "2", "0", run("Second")) +
+		`<w:tbl><w:tr><w:tc><w:p>` + run("Region") + `</w:p></w:tc><w:tc><w:p>` + run("Sales") + `</w:p></w:tc></w:tr>` +
+		`<w:tr><w:tc><w:p>` + run("EMEA") + `</w:p></w:tc><w:tc><w:p>` + run("42") + `</w:p></w:tc></w:tr></w:tbl>` +
+		para("Heading6", "", "", run("Figures")) +
+		`<w:p><w:r><w:drawing><wp:inline><wp:docPr id="1" name="Picture 1" descr="Sales chart"/><a:graphic><a:graphicData><pic:pic><pic:blipFill><a:blip r:embed="rId6"/></pic:blipFill></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>` +
+		`<w:p><w:r><w:drawing><wp:inline><wp:docPr id="2" name="Picture 2" descr="Map of regions"/><a:graphic><a:graphicData><pic:pic><pic:blipFill><a:blip r:embed="rId7"/></pic:blipFill></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>` +
+		`<w:sectPr/></w:body></w:document>`
+
+	styles := `<w:styles ` + docxNS + `>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/></w:style>
+<w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:pPr><w:outlineLvl w:val="3"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/></w:style>
+</w:styles>`
+	numbering := `<w:numbering ` + docxNS + `>
+<w:abstractNum w:abstractNumId="10"><w:lvl w:ilvl="0"><w:numFmt w:val="bullet"/></w:lvl><w:lvl w:ilvl="1"><w:numFmt w:val="bullet"/></w:lvl></w:abstractNum>
+<w:abstractNum w:abstractNumId="11"><w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="decimal"/></w:lvl><w:lvl w:ilvl="1"><w:start w:val="1"/><w:numFmt w:val="lowerLetter"/></w:lvl></w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="10"/></w:num>
+<w:num w:numId="2"><w:abstractNumId w:val="11"/></w:num>
+</w:numbering>`
+	footnotes := `<w:footnotes ` + docxNS + `>
+<w:footnote w:type="separator" w:id="-1"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>
+<w:footnote w:id="2"><w:p><w:r><w:footnoteRef/></w:r>` + run(" Figures are unaudited.") + `</w:p></w:footnote>
+</w:footnotes>`
+	comments := `<w:comments ` + docxNS + `><w:comment w:id="0" w:author="Ada"><w:p>` + run("Check wording.") + `</w:p></w:comment></w:comments>`
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+	data := buildZip(t, [][2]string{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`},
+		{"word/document.xml", document},
+		{"word/_rels/document.xml.rels", `<Relationships ` + relsNS + `>
+<Relationship Id="rId5" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/" TargetMode="External"/>
+<Relationship Id="rId6" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image1.png"/>
+<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image2.emf"/>
+</Relationships>`},
+		{"word/styles.xml", styles},
+		{"word/numbering.xml", numbering},
+		{"word/footnotes.xml", footnotes},
+		{"word/comments.xml", comments},
+		{"word/media/image1.png", png},
+		{"word/media/image2.emf", "\x01\x00\x00\x00 EMF"},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"# Annual Report\n\n#### Scope",
+		"See [our site](https://example.com/) and [the FAQ](https://example.org/faq).[^1]",
+		"**Bold text** plain[^c0]",
+		"Still unaudited.[^1]",
+		"- Apples\n  - Green",
+		"1. First\n   1. Detail\n2. Second",
+		"| Region | Sales |\n| --- | --- |\n| EMEA | 42 |",
+		"###### Figures",
+		"[^1]: Figures are unaudited.\n[^c0]: Ada: Check wording.",
+	})
+	if n := strings.Count(md, "\n# ") + strings.Count(md[:2], "# "); n != 1 {
+		t.Errorf("expected the title as the only H1, got %d:\n%s", n, md)
+	}
+	if strings.Count(md, "[^1]:") != 1 {
+		t.Errorf("footnote referenced twice should be defined once:\n%s", md)
+	}
+	if strings.Contains(md, "![") {
+		t.Errorf("images should be dropped without RetainImages:\n%s", md)
+	}
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.RetainImages = true
+	md = convertServed(t, data, opts)
+	if !strings.Contains(md, "![Sales chart](data:image/png;base64,") {
+		t.Errorf("expected embedded image with RetainImages:\n%s", md)
+	}
+	if !strings.Contains(md, "\n\nMap of regions\n\n") || strings.Contains(md, "media/") {
+		t.Errorf("expected alt text for an image that cannot be inlined:\n%s", md)
+	}
+}
+
+func TestConverter_DOCXWithoutStyles(t *testing.T) {
+	// styles referenced by paragraphs but missing from the package
+	document := `<w:document ` + docxNS + `><w:body>` +
+		`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Intro</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:pStyle w:val="BodyText"/></w:pPr><w:r><w:t>Plain body text.</w:t></w:r></w:p>` +
+		`</w:body></w:document>`
+	data := buildZip(t, [][2]string{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`},
+		{"word/document.xml", document},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{"## Intro", "Plain body text."})
+	if strings.Contains(md, "# Plain body text.") {
+		t.Errorf("body text rendered as a heading:\n%s", md)
+	}
+}
+
+func buildTestXLSX(t *testing.T) []byte {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+
+	f.SetSheetName("Sheet1", "Summary")
+	f.SetCellValue("Summary", "A1", "Quarterly sales")
+	f.MergeCell("Summary", "A1", "D1")
+	rows := [][]any{
+		{"Region", "Q1", "Q2", "Total"},
+		{"EMEA", 10, 20, 30},
+		{"APAC", 5, 5, 10},
+		{"Scratch", 0, 0, 0},
+		{"AMER", 7, 8, 15},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		f.SetSheetRow("Summary", cell, &row)
+	}
+	f.SetCellFormula("Summary", "D3", "B3+C3")
+	f.SetRowVisible("Summary", 5, false)
+
+	f.NewSheet("Internal")
+	f.SetCellValue("Internal", "A1", "secret")
+	f.SetSheetVisible("Internal", false)
+
+	f.NewSheet("Notes")
+	f.SetSheetRow("Notes", "A1", &[]any{"Key", "Value"})
+	f.SetSheetRow("Notes", "A2", &[]any{"owner", "finance"})
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestConverter_XLSX(t *testing.T) {
+	data := buildTestXLSX(t)
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"## Summary\n\nQuarterly sales\n\n| Region | Q1 | Q2 | Total |\n| --- | --- | --- | --- |\n| EMEA | 10 | 20 | 30 |\n| APAC | 5 | 5 | 10 |\n| AMER | 7 | 8 | 15 |",
+		"## Notes",
+	})
+	if strings.Contains(md, "Scratch") || strings.Contains(md, "secret") {
+		t.Errorf("hidden rows and sheets should be skipped:\n%s", md)
+	}
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.Sheets = "summary"
+	opts.CellRange = "A2:B6"
+	opts.MaxRows = 2
+	opts.IncludeHidden = true
+	md = convertServed(t, data, opts)
+	expectInOrder(t, md, []string{
+		"## Summary\n\n| Region | Q1 |\n| --- | --- |\n| EMEA | 10 |\n| APAC | 5 |\n\n_2 more rows omitted_",
+	})
+	if strings.Contains(md, "Notes") || strings.Contains(md, "Q2") {
+		t.Errorf("only the selected sheet and range should be converted:\n%s", md)
+	}
+
+	opts = DefaultOptions()
+	opts.Method = "static"
+	opts.Sheets = "1"
+	opts.Formulas = true
+	md = convertServed(t, data, opts)
+	if !strings.Contains(md, "| EMEA | 10 | 20 | 30 (=B3+C3) |") {
+		t.Errorf("expected formula next to its value:\n%s", md)
+	}
+}
+
+func TestConverter_XLSXMaxRowsStopsReading(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetRow("Sheet1", "A1", &[]any{"Plot", "Ferns"})
+	for i := 2; i <= 500; i++ {
+		cell, _ := excelize.CoordinatesToCellName(1, i)
+		f.SetSheetRow("Sheet1", cell, &[]any{fmt.Sprintf("P%d", i-1), i})
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.MaxRows = 3
+	md := convertServed(t, buf.Bytes(), opts)
+	expectInOrder(t, md, []string{"| Plot | Ferns |\n| --- | --- |\n| P1 | 2 |\n| P2 | 3 |\n| P3 | 4 |\n\n_More rows omitted_"})
+
+	opts.MaxRows = 0
+	opts.CellRange = "A1:B4"
+	md = convertServed(t, buf.Bytes(), opts)
+	if !strings.HasSuffix(strings.TrimSpace(md), "| P3 | 4 |") {
+		t.Errorf("expected the table to end at the last row of the range:\n%s", md)
+	}
+}
+
+func TestConverter_InvalidCellRange(t *testing.T) {
+	opts := DefaultOptions()
+	opts.CellRange = "B2:A1"
+	_, err := New().Convert(context.Background(), "https://example.com/book.xlsx", opts)
+	if !errors.Is(err, filetype.ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %v", err)
+	}
+}
+
+func TestConverter_ODS(t *testing.T) {
+	content := `<office:document-content ` + odpNS + `>
+<office:automatic-styles>
+<style:style xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" style:name="ta2" style:family="table"><style:table-properties table:display="false"/></style:style>
+</office:automatic-styles>
+<office:body><office:spreadsheet>
+<table:table table:name="Summary">
+<table:table-column table:number-columns-repeated="4"/><table:table-column table:visibility="collapse"/><table:table-column table:number-columns-repeated="1019"/>
+<table:table-row><table:table-cell table:number-columns-spanned="4"><text:p>Quarterly sales</text:p></table:table-cell><table:covered-table-cell table:number-columns-repeated="3"/><table:table-cell table:number-columns-repeated="1020"/></table:table-row>
+<table:table-row><table:table-cell><text:p>Region</text:p></table:table-cell><table:table-cell><text:p>Q1</text:p></table:table-cell><table:table-cell><text:p>Q2</text:p></table:table-cell><table:table-cell><text:p>Total</text:p></table:table-cell><table:table-cell><text:p>internal</text:p></table:table-cell></table:table-row>
+<table:table-row><table:table-cell><text:p>EMEA</text:p></table:table-cell><table:table-cell office:value-type="float" office:value="10"><text:p>10</text:p></table:table-cell><table:table-cell><text:p>20</text:p></table:table-cell><table:table-cell table:formula="of:=[.B3]+[.C3]"><text:p>30</text:p></table:table-cell></table:table-row>
+<table:table-row table:visibility="collapse"><table:table-cell><text:p>secret</text:p></table:table-cell></table:table-row>
+<table:table-row><table:table-cell><text:p>APAC</text:p></table:table-cell><table:table-cell table:number-columns-repeated="2"><text:p>5</text:p></table:table-cell><table:table-cell><text:p>10</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table>
+<table:table table:name="Scratch" table:style-name="ta2"><table:table-row><table:table-cell><text:p>hidden sheet</text:p></table:table-cell></table:table-row></table:table>
+<table:table table:name="Notes"><table:table-row><table:table-cell><text:p>Source</text:p></table:table-cell><table:table-cell><text:p>Date</text:p></table:table-cell></table:table-row>
+<table:table-row><table:table-cell><text:p>Ledger</text:p></table:table-cell><table:table-cell><text:p>2024-03-31</text:p></table:table-cell></table:table-row></table:table>
+</office:spreadsheet></office:body></office:document-content>`
+	data := buildZip(t, [][2]string{
+		{"mimetype", "application/vnd.oasis.opendocument.spreadsheet"},
+		{"content.xml", content},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"## Summary\n\nQuarterly sales\n\n| Region | Q1 | Q2 | Total |\n| --- | --- | --- | --- |\n| EMEA | 10 | 20 | 30 |\n| APAC | 5 | 5 | 10 |",
+		"## Notes\n\n| Source | Date |\n| --- | --- |\n| Ledger | 2024-03-31 |",
+	})
+	if strings.Contains(md, "secret") || strings.Contains(md, "internal") || strings.Contains(md, "hidden sheet") {
+		t.Errorf("hidden rows, columns and sheets should be skipped:\n%s", md)
+	}
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.Sheets = "Summary"
+	opts.Formulas = true
+	md = convertServed(t, data, opts)
+	if !strings.Contains(md, "| EMEA | 10 | 20 | 30 (=B3+C3) |") {
+		t.Errorf("expected formula next to its value:\n%s", md)
+	}
+}
+
+func TestConverter_ODT(t *testing.T) {
+	const odtNS = odpNS + ` xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" xmlns:fo="urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0"`
+	data := buildZip(t, [][2]string{
+		{"mimetype", "application/vnd.oasis.opendocument.text"},
+		{"styles.xml", `<office:document-styles ` + odtNS + `><office:styles>
+<style:style style:name="Strong_20_Emphasis" style:display-name="Strong Emphasis" style:family="text"><style:text-properties fo:font-weight="bold"/></style:style>
+<style:style style:name="Table_20_Heading" style:family="paragraph"><style:text-properties fo:font-weight="bold"/></style:style>
+</office:styles></office:document-styles>`},
+		{"content.xml", `<office:document-content ` + odtNS + `>
+<office:automatic-styles>
+<style:style style:name="T1" style:family="text"><style:text-properties fo:font-style="italic"/></style:style>
+<style:style style:name="T2" style:family="text" style:parent-style-name="Strong_20_Emphasis"/>
+<style:style style:name="P1" style:family="paragraph" style:parent-style-name="Standard"><style:text-properties fo:font-weight="bold"/></style:style>
+<style:style style:name="P2" style:family="paragraph" style:parent-style-name="Table_20_Heading"/>
+<text:list-style style:name="L1"><text:list-level-style-number text:level="1"/></text:list-style>
+<text:list-style style:name="L2"><text:list-level-style-bullet text:level="1"/></text:list-style>
+</office:automatic-styles>
+<office:body><office:text>
+<text:sequence-decls><text:sequence-decl text:name="Table"/></text:sequence-decls>
+<text:h text:outline-level="1">Field report</text:h>
+<text:p>Ferns are <text:span text:style-name="T2">hardy</text:span> and <text:span text:style-name="T1">quite common</text:span>.<text:note><text:note-citation>1</text:note-citation><text:note-body><text:p>hidden note</text:p></text:note-body></text:note></text:p>
+<text:p text:style-name="P1">Read this first.</text:p>
+<text:section text:name="Findings">
+<text:h text:outline-level="2">Findings</text:h>
+<text:p>See <text:a xlink:type="simple" xlink:href="https://example.org/ferns">the society</text:a> for more.</text:p>
+<text:list text:style-name="L1">
+<text:list-item><text:p>Collect</text:p><text:list text:style-name="L2"><text:list-item><text:p>fronds</text:p></text:list-item><text:list-item><text:p>spores</text:p></text:list-item></text:list></text:list-item>
+<text:list-item><text:p>Press</text:p></text:list-item>
+</text:list>
+</text:section>
+<table:table table:name="Sites"><table:table-column table:number-columns-repeated="2"/>
+<table:table-header-rows><table:table-row><table:table-cell><text:p text:style-name="P2">Site</text:p></table:table-cell><table:table-cell><text:p text:style-name="P2">Count</text:p></table:table-cell></table:table-row></table:table-header-rows>
+<table:table-row><table:table-cell><text:p>North</text:p></table:table-cell><table:table-cell><text:p>12</text:p></table:table-cell></table:table-row>
+</table:table>
+</office:text></office:body></office:document-content>`},
+	})
+
+	md := convertServed(t, data, nil)
+	expectInOrder(t, md, []string{
+		"# Field report",
+		"Ferns are **hardy** and _quite common_.",
+		"**Read this first.**",
+		"## Findings\n\nSee [the society](https://example.org/ferns) for more.",
+		"1. Collect\n  - fronds\n  - spores\n2. Press",
+		"| Site | Count |\n| --- | --- |\n| North | 12 |",
+	})
+	if strings.Contains(md, "hidden note") {
+		t.Errorf("notes should not be inlined:\n%s", md)
+	}
+}
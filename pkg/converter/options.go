@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/elonfeng/url2md/pkg/converter/filetype"
@@ -9,13 +10,29 @@ import (
 // Options configures the conversion behavior.
 type Options struct {
 	RetainImages  bool
-	RetainLinks   bool
-	Frontmatter   bool // prepend YAML frontmatter (title, description, image)
+	RetainLinks   bool   // false renders links as plain text
+	LinkMode      string // "inline" (default) | "reference" | "text"
+	Frontmatter   bool   // prepend YAML frontmatter (title, description, image)
 	Timeout       time.Duration
 	EnableBrowser bool
 	UserAgent     string
 	Method        string // "auto" | "negotiate" | "static" | "browser"
 	Vision        *filetype.VisionConfig
+	RespectRobots bool          // refuse URLs disallowed by robots.txt and honor Crawl-delay
+	Chunk         *ChunkOptions // split the output into token-bounded chunks when set
+	Tokenizer     string        // "heuristic" (default) | "cl100k_base" | "o200k_base"
+	MaxTokens     int           // trim the output to this many tokens when > 0
+	Pages         string        // PDF page ranges to convert, e.g. "1-5,10"; empty converts all pages
+	Sheets        string        // spreadsheet sheets to convert by name or 1-based index, e.g. "Summary,3"
+	CellRange     string        // spreadsheet cells to convert, e.g. "A1:F100", "B:D" or "5:20"
+	MaxRows       int           // spreadsheet data rows per sheet when > 0
+	IncludeHidden bool          // keep hidden sheets, rows and columns
+	Formulas      bool          // show spreadsheet formulas alongside computed values
+	DropOutputs   bool          // leave out Jupyter notebook cell outputs
+	DocComments   bool          // render a source file's leading doc comment as prose
+	Cache         Cache         // reuse results, revalidating with ETag/Last-Modified
+	CacheTTL      time.Duration // serve cached results younger than this without revalidating
+	HTTPClient    *http.Client  // shared client for HTTP layers; nil creates one per request using Timeout
 }
 
 // DefaultOptions returns sensible defaults for conversion.
@@ -31,15 +48,44 @@ func DefaultOptions() *Options {
 	}
 }
 
+// httpClient returns the shared client or a new one bounded by Timeout.
+func (o *Options) httpClient() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+	return &http.Client{Timeout: o.Timeout}
+}
+
+// sheetOptions builds the spreadsheet options, validating the cell range.
+func (o *Options) sheetOptions() (*filetype.SheetOptions, error) {
+	rng, err := filetype.ParseCellRange(o.CellRange)
+	if err != nil {
+		return nil, err
+	}
+	return &filetype.SheetOptions{
+		Sheets:        filetype.ParseSheets(o.Sheets),
+		Range:         rng,
+		MaxRows:       o.MaxRows,
+		IncludeHidden: o.IncludeHidden,
+		Formulas:      o.Formulas,
+	}, nil
+}
+
 // Result holds the conversion output and associated metadata.
 type Result struct {
-	URL         string
-	Markdown    string
-	Title       string
-	Description string
-	TokenCount  int
-	Method      string
-	Metadata    map[string]string
-	FetchTime   time.Duration
-	ConvertTime time.Duration
+	URL                string
+	Markdown           string
+	Title              string
+	Description        string
+	TokenCount         int
+	Tokenizer          string // tokenizer that produced TokenCount
+	Truncated          bool   // content was dropped to fit Options.MaxTokens
+	OriginalTokenCount int    // TokenCount before truncation
+	Method             string
+	Metadata           map[string]string
+	Links              []string // absolute links found in the page, deduplicated
+	Chunks             []Chunk  // populated when Options.Chunk is set
+	Cached             bool     // served from Options.Cache
+	FetchTime          time.Duration
+	ConvertTime        time.Duration
 }
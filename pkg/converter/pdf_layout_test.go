@@ -0,0 +1,170 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// pdfText is one line of text placed on a page: /F1 is Helvetica and /F2 is
+// Helvetica-Bold, both 500 units per glyph.
+type pdfText struct {
+	font string
+	size float64
+	x, y float64
+	text string
+}
+
+func body(x, y float64, text string) pdfText { return pdfText{"F1", 11, x, y, text} }
+
+// buildLayoutPDF writes a letter-size PDF with the given lines on each page.
+func buildLayoutPDF(pages [][]pdfText) []byte {
+	var objs []string
+	add := func(s string) int {
+		objs = append(objs, s)
+		return len(objs)
+	}
+
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	regular := add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [%s] >>", widths))
+	bold := add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /FirstChar 32 /LastChar 126 /Widths [%s] >>", widths))
+	pagesID := len(objs) + 2*len(pages) + 1
+
+	var kids []string
+	for _, lines := range pages {
+		var content strings.Builder
+		for _, l := range lines {
+			fmt.Fprintf(&content, "BT /%s %g Tf %g %g Td (%s) Tj ET\n", l.font, l.size, l.x, l.y, l.text)
+		}
+		data := content.String()
+		stream := add(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data))
+		page := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Contents %d 0 R /Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> >>", pagesID, stream, regular, bold))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	add(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 612 792] >>", strings.Join(kids, " "), len(kids)))
+	catalog := add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, catalog, xref)
+	return b.Bytes()
+}
+
+// convertPDF serves data as a PDF and converts it with the static layer. A
+// nil opts uses the defaults.
+func convertPDF(t *testing.T, data []byte, opts *Options) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	if opts == nil {
+		opts = DefaultOptions()
+		opts.Method = "static"
+	}
+	result, err := New().Convert(context.Background(), srv.URL+"/document.pdf", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return result.Markdown
+}
+
+func expectInOrder(t *testing.T, got string, want []string) {
+	t.Helper()
+	pos := 0
+	for _, w := range want {
+		i := strings.Index(got[pos:], w)
+		if i < 0 {
+			t.Errorf("expected %q after offset %d in:\n%s", w, pos, got)
+			return
+		}
+		pos += i + len(w)
+	}
+}
+
+func TestPDFLayout_Headings(t *testing.T) {
+	md := convertPDF(t, buildLayoutPDF([][]pdfText{{
+		{"F1", 20, 72, 700, "Field Guide"},
+		body(72, 670, "Ferns grow in shaded and damp places across the northern woods."),
+		{"F1", 15, 72, 630, "Habitat"},
+		body(72, 600, "Most species prefer acidic soil under a closed canopy of trees."),
+		{"F2", 11, 72, 560, "Soil notes"},
+		body(72, 540, "Leaf litter keeps the ground moist through the dry summer months."),
+	}}), nil)
+	expectInOrder(t, md, []string{"## Field Guide", "### Habitat", "#### Soil notes", "Leaf litter keeps"})
+}
+
+func TestPDFLayout_Dehyphenation(t *testing.T) {
+	md := convertPDF(t, buildLayoutPDF([][]pdfText{{
+		body(72, 700, "The survey counted every fern in the experi-"),
+		body(72, 687, "mental plots over three seasons."),
+	}}), nil)
+	expectInOrder(t, md, []string{"The survey counted every fern in the experimental plots over three seasons."})
+}
+
+func TestPDFLayout_Lists(t *testing.T) {
+	md := convertPDF(t, buildLayoutPDF([][]pdfText{{
+		body(72, 700, "Equipment for the survey:"),
+		body(72, 680, "- Field notebook"),
+		body(72, 667, "- Hand lens"),
+		body(72, 640, "1. Mark the plot"),
+		body(72, 627, "2. Count the fronds"),
+	}}), nil)
+	expectInOrder(t, md, []string{"- Field notebook\n- Hand lens", "1. Mark the plot\n2. Count the fronds"})
+}
+
+func TestPDFLayout_Table(t *testing.T) {
+	md := convertPDF(t, buildLayoutPDF([][]pdfText{{
+		body(72, 700, "Counts by region are listed below."),
+		body(72, 670, "Region"), body(300, 670, "Count"),
+		body(72, 656, "North"), body(300, 656, "12"),
+		body(72, 642, "South"), body(300, 642, "7"),
+	}}), nil)
+	expectInOrder(t, md, []string{"| Region | Count |\n| --- | --- |\n| North | 12 |\n| South | 7 |"})
+}
+
+func TestPDFLayout_TwoColumns(t *testing.T) {
+	var page []pdfText
+	for i := range 6 {
+		y := 700 - float64(i)*13
+		page = append(page,
+			body(72, y, fmt.Sprintf("left column line %d of the essay", i+1)),
+			body(320, y, fmt.Sprintf("right column line %d of the essay", i+1)))
+	}
+	md := convertPDF(t, buildLayoutPDF([][]pdfText{page}), nil)
+	expectInOrder(t, md, []string{"left column line 1", "left column line 6", "right column line 1", "right column line 6"})
+}
+
+func TestPDFLayout_HeadersAndFooters(t *testing.T) {
+	var pages [][]pdfText
+	for i := range 3 {
+		pages = append(pages, []pdfText{
+			body(72, 770, "Fern Society Bulletin"),
+			body(72, 600, fmt.Sprintf("Findings from plot %d were recorded in full.", i+1)),
+			body(280, 30, fmt.Sprintf("Page %d of 3", i+1)),
+		})
+	}
+	md := convertPDF(t, buildLayoutPDF(pages), nil)
+	expectInOrder(t, md, []string{"plot 1", "plot 2", "plot 3"})
+	for _, unwanted := range []string{"Fern Society Bulletin", "Page 2 of 3"} {
+		if strings.Contains(md, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, md)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package converter
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elonfeng/url2md/pkg/converter/filetype"
+)
+
+// buildScannedPDF writes a PDF with one full-page image per page and no text.
+// Each image is a stream dictionary (without /Length) and its data; the
+// length is stored in a separate object as scanners often do.
+func buildScannedPDF(images [][2]string) []byte {
+	var objs []string
+	add := func(s string) int {
+		objs = append(objs, s)
+		return len(objs)
+	}
+	pagesID := 4*len(images) + 1
+
+	var kids []string
+	for _, img := range images {
+		length := add(fmt.Sprint(len(img[1])))
+		xobj := add(fmt.Sprintf("<< %s /Length %d 0 R >>\nstream\n%s\nendstream", img[0], length, img[1]))
+		draw := "q 612 0 0 792 0 0 cm /Im1 Do Q"
+		content := add(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(draw), draw))
+		page := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Contents %d 0 R /Resources << /XObject << /Im1 %d 0 R >> >> >>", pagesID, content, xobj))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	add(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 612 792] >>", strings.Join(kids, " "), len(kids)))
+	catalog := add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, catalog, xref)
+	return b.Bytes()
+}
+
+func grayJPEG(t *testing.T, level uint8) [2]string {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 240, 320))
+	for i := range img.Pix {
+		img.Pix[i] = level
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return [2]string{"/Type /XObject /Subtype /Image /Width 240 /Height 320 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /DCTDecode", buf.String()}
+}
+
+func grayRaster(t *testing.T, level uint8) [2]string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(bytes.Repeat([]byte{level}, 240*320))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return [2]string{"/Type /XObject /Subtype /Image /Width 240 /Height 320 /ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode", buf.String()}
+}
+
+// visionStub answers OCR requests with the gray level of the image it was
+// sent, so tests can tell which image each page produced.
+func visionStub() (*httptest.Server, *[]string) {
+	var (
+		mu    sync.Mutex
+		types []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content []struct {
+					ImageURL *struct {
+						URL string `json:"url"`
+					} `json:"image_url"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dataURL := req.Messages[0].Content[0].ImageURL.URL
+		meta, b64, _ := strings.Cut(strings.TrimPrefix(dataURL, "data:"), ",")
+		data, _ := base64.StdEncoding.DecodeString(b64)
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		types = append(types, strings.TrimSuffix(meta, ";base64"))
+		mu.Unlock()
+
+		gray := color.GrayModel.Convert(img.At(10, 10)).(color.Gray).Y
+		text := fmt.Sprintf("Scanned text at gray %d", (int(gray)+5)/10*10)
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "result": map[string]string{"response": text}})
+	}))
+	return srv, &types
+}
+
+func TestConverter_PDFOCR(t *testing.T) {
+	vision, types := visionStub()
+	defer vision.Close()
+
+	// the two JPEG scans share dimensions and encoded size
+	data := buildScannedPDF([][2]string{grayJPEG(t, 40), grayJPEG(t, 200), grayRaster(t, 120)})
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.Vision = &filetype.VisionConfig{AccountID: "acct", APIToken: "token", BaseURL: vision.URL}
+	md := convertPDF(t, data, opts)
+	expectInOrder(t, md, []string{
+		"Scanned text at gray 40",
+		"Scanned text at gray 200",
+		"Scanned text at gray 120",
+	})
+	if want := "image/jpeg,image/jpeg,image/png"; strings.Join(*types, ",") != want {
+		t.Errorf("vision received %v, want %s", *types, want)
+	}
+}
@@ -0,0 +1,127 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/elonfeng/url2md/pkg/converter/filetype"
+)
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:CreateDate="2024-03-01T10:00:00Z">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Annual Report 2024</rdf:li></rdf:Alt></dc:title>
+<dc:creator><rdf:Seq><rdf:li>Jane Doe</rdf:li><rdf:li>John Roe</rdf:li></rdf:Seq></dc:creator>
+</rdf:Description></rdf:RDF></x:xmpmeta>`
+
+// buildTestPDF writes a minimal PDF with one line of text per page, an Info
+// dictionary, an XMP packet and a two-level outline.
+func buildTestPDF(pageTexts []string) []byte {
+	var objs []string
+	add := func(s string) int {
+		objs = append(objs, s)
+		return len(objs)
+	}
+	stream := func(dict, data string) string {
+		return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+	}
+
+	widths := strings.TrimSpace(strings.Repeat("500 ", 95))
+	font := add(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /FirstChar 32 /LastChar 126 /Widths [%s] >>", widths))
+	pagesID := len(objs) + 2*len(pageTexts) + 1
+
+	var kids []string
+	for _, text := range pageTexts {
+		content := add(stream("", fmt.Sprintf("BT /F1 11 Tf 72 600 Td (%s) Tj ET", text)))
+		page := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> >> >>", pagesID, content, font))
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	add(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d /MediaBox [0 0 612 792] >>", strings.Join(kids, " "), len(kids)))
+
+	xmp := add(stream("/Type /Metadata /Subtype /XML", testXMP))
+	outlines := len(objs) + 1
+	add(fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R >>", outlines+1, outlines+2))
+	add(fmt.Sprintf("<< /Title (Overview) /Parent %d 0 R /Next %d 0 R /First %d 0 R /Last %d 0 R >>", outlines, outlines+2, outlines+3, outlines+3))
+	add(fmt.Sprintf("<< /Title (Financials) /Parent %d 0 R /Prev %d 0 R >>", outlines, outlines+1))
+	add(fmt.Sprintf("<< /Title (Highlights) /Parent %d 0 R >>", outlines+1))
+	catalog := add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R /Metadata %d 0 R /Outlines %d 0 R >>", pagesID, xmp, outlines))
+	info := add("<< /Title (Info Title) /Author (Info Author) /Subject (Yearly results) /CreationDate (D:20240301100000Z) >>")
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, catalog, info, xref)
+	return b.Bytes()
+}
+
+func TestConverter_PDFMetadataOutlineAndPages(t *testing.T) {
+	data := buildTestPDF([]string{"First page text.", "Second page text.", "Third page text."})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.Pages = "2"
+
+	result, err := New().Convert(context.Background(), srv.URL+"/report.pdf", opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// XMP takes precedence over the Info dictionary
+	if result.Title != "Annual Report 2024" {
+		t.Errorf("unexpected title %q", result.Title)
+	}
+	if result.Description != "Yearly results" {
+		t.Errorf("unexpected description %q", result.Description)
+	}
+	want := map[string]string{
+		"author":     "Jane Doe, John Roe",
+		"created":    "2024-03-01T10:00:00Z",
+		"page_count": "3",
+		"pages":      "2",
+	}
+	for k, v := range want {
+		if result.Metadata[k] != v {
+			t.Errorf("metadata[%s] = %q, want %q", k, result.Metadata[k], v)
+		}
+	}
+
+	md := result.Markdown
+	for _, s := range []string{"# Annual Report 2024", "## Contents\n\n- Overview\n  - Highlights\n- Financials", "Second page text."} {
+		if !strings.Contains(md, s) {
+			t.Errorf("expected %q in:\n%s", s, md)
+		}
+	}
+	for _, s := range []string{"First page text.", "Third page text."} {
+		if strings.Contains(md, s) {
+			t.Errorf("did not expect %q from an unselected page in:\n%s", s, md)
+		}
+	}
+}
+
+func TestConverter_InvalidPages(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Pages = "3-1"
+	_, err := New().Convert(context.Background(), "https://example.com/report.pdf", opts)
+	if !errors.Is(err, filetype.ErrInvalidPages) {
+		t.Errorf("expected ErrInvalidPages, got %v", err)
+	}
+}
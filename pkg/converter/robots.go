@@ -0,0 +1,266 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDisallowedByRobots is returned when robots.txt forbids fetching a URL.
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+const (
+	robotsTTL      = time.Hour
+	robotsRetryTTL = time.Minute // unreachable robots.txt files are retried sooner
+	maxCrawlDelay  = time.Minute
+	robotsMaxBytes = 512 << 10
+)
+
+// robotsRules is the parsed robots.txt group that applies to our user agent.
+type robotsRules struct {
+	rules []robotsRule
+	delay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// parseRobots parses robots.txt and keeps the rules of the group that best
+// matches userAgent: the group naming its product token (case-insensitively,
+// per RFC 9309), else "*".
+func parseRobots(data []byte, userAgent string) *robotsRules {
+	product := strings.ToLower(userAgent)
+	if i := strings.IndexAny(product, "/ "); i >= 0 {
+		product = product[:i]
+	}
+
+	type group struct {
+		agents []string
+		rules  []robotsRule
+		delay  time.Duration
+	}
+	var (
+		groups  []*group
+		current *group
+		inRules bool
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// consecutive user-agent lines share one group
+			if current == nil || inRules {
+				current = &group{}
+				groups = append(groups, current)
+				inRules = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if value == "" {
+				continue // empty Disallow allows everything
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value, re: robotsPattern(value)})
+		case "crawl-delay":
+			if current == nil {
+				continue
+			}
+			inRules = true
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+				current.delay = time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+
+	// pick the most specific matching group; merge groups naming the same agent
+	best := -1
+	result := &robotsRules{}
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			score := -1
+			switch {
+			case agent == "*":
+				score = 0
+			case product != "" && strings.EqualFold(product, agent):
+				score = len(agent)
+			}
+			if score < 0 {
+				continue
+			}
+			if score > best {
+				best = score
+				result = &robotsRules{}
+			}
+			if score == best {
+				result.rules = append(result.rules, g.rules...)
+				if g.delay > result.delay {
+					result.delay = g.delay
+				}
+			}
+			break
+		}
+	}
+	return result
+}
+
+// allowed reports whether path (including any query) may be fetched. The
+// longest matching rule wins; Allow wins ties.
+func (r *robotsRules) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	matchLen := -1
+	allow := true
+	for _, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		n := len(rule.pattern)
+		if n > matchLen || (n == matchLen && rule.allow) {
+			matchLen = n
+			allow = rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsPattern compiles a robots.txt path pattern, which supports "*"
+// wildcards and a trailing "$" end anchor.
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsCache fetches robots.txt once per origin and paces requests to honor
+// Crawl-delay.
+type robotsCache struct {
+	mu    sync.Mutex
+	hosts map[string]*robotsHost
+}
+
+type robotsHost struct {
+	rules   *robotsRules
+	fetched time.Time
+	ttl     time.Duration
+	next    time.Time // earliest time the next request may start
+}
+
+var defaultRobots = &robotsCache{hosts: make(map[string]*robotsHost)}
+
+// check returns ErrDisallowedByRobots if rawURL is disallowed for
+// opts.UserAgent, and otherwise blocks until the origin's Crawl-delay allows
+// another request. A missing robots.txt (4xx) allows everything; an
+// unreachable one (5xx or network error) disallows everything (RFC 9309).
+func (c *robotsCache) check(ctx context.Context, rawURL string, opts *Options) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	origin := u.Scheme + "://" + u.Host
+	key := origin + "|" + opts.UserAgent
+
+	c.mu.Lock()
+	h, ok := c.hosts[key]
+	c.mu.Unlock()
+	if !ok || time.Since(h.fetched) > h.ttl {
+		rules, ttl := c.fetch(ctx, origin, opts)
+		if err := ctx.Err(); err != nil {
+			return err // don't cache a fetch cut short by the caller
+		}
+		c.mu.Lock()
+		h = &robotsHost{rules: rules, fetched: time.Now(), ttl: ttl}
+		c.hosts[key] = h
+		c.mu.Unlock()
+	}
+
+	if !h.rules.allowed(u.RequestURI()) {
+		return fmt.Errorf("%w: %s", ErrDisallowedByRobots, rawURL)
+	}
+
+	delay := h.rules.delay
+	if delay <= 0 {
+		return nil
+	}
+	if delay > maxCrawlDelay {
+		delay = maxCrawlDelay
+	}
+
+	c.mu.Lock()
+	start := time.Now()
+	if h.next.After(start) {
+		start = h.next
+	}
+	h.next = start.Add(delay)
+	c.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// fetch downloads and parses the origin's robots.txt and returns how long
+// the rules may be cached.
+func (c *robotsCache) fetch(ctx context.Context, origin string, opts *Options) (*robotsRules, time.Duration) {
+	data, resp, err := (&StaticLayer{}).fetchRaw(ctx, origin+"/robots.txt", opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return &robotsRules{}, robotsTTL
+		}
+		return disallowAll(), robotsRetryTTL
+	}
+	if len(data) > robotsMaxBytes {
+		data = data[:robotsMaxBytes]
+	}
+	return parseRobots(data, opts.UserAgent), robotsTTL
+}
+
+// disallowAll returns rules that forbid every path.
+func disallowAll() *robotsRules {
+	return &robotsRules{rules: []robotsRule{{pattern: "/", re: robotsPattern("/")}}}
+}
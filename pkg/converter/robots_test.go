@@ -0,0 +1,125 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRobots_GroupSelection(t *testing.T) {
+	robots := `
+# comment
+User-agent: *
+Disallow: /private/
+Crawl-delay: 5
+
+User-agent: bot
+Disallow: /
+
+User-agent: URL2MD
+User-agent: otherbot
+Disallow: /drafts/
+Allow: /drafts/public$
+Disallow: /*.pdf$
+Crawl-delay: 0.5
+`
+	rules := parseRobots([]byte(robots), "url2md/1.0")
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/private/page", true}, // "*" group does not apply when a specific group matches
+		{"/drafts/post", false},
+		{"/drafts/public", true},
+		{"/drafts/public/more", false},
+		{"/files/report.pdf", false},
+		{"/files/report.pdf?x=1", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allowed(tt.path); got != tt.allowed {
+			t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.allowed)
+		}
+	}
+	if rules.delay != 500*time.Millisecond {
+		t.Errorf("expected crawl delay 500ms, got %v", rules.delay)
+	}
+
+	fallback := parseRobots([]byte(robots), "somebot/2.0")
+	if fallback.allowed("/private/page") {
+		t.Error("expected * group to disallow /private/ for unknown agents")
+	}
+	if fallback.delay != 5*time.Second {
+		t.Errorf("expected crawl delay 5s, got %v", fallback.delay)
+	}
+}
+
+func TestParseRobots_EmptyDisallowAllowsAll(t *testing.T) {
+	rules := parseRobots([]byte("User-agent: *\nDisallow:\n"), "url2md/1.0")
+	if !rules.allowed("/anything") {
+		t.Error("expected empty Disallow to allow everything")
+	}
+}
+
+func TestConverter_RespectRobots(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			fmt.Fprint(w, "User-agent: *\nDisallow: /secret\n")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Page</title></head><body><article>
+		<p>Content with enough text for readability to detect it as a real article body.</p>
+		<p>Second paragraph of content to help the readability algorithm.</p></article></body></html>`)
+	}))
+	defer srv.Close()
+
+	c := New()
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.RespectRobots = true
+
+	_, err := c.Convert(context.Background(), srv.URL+"/secret/page", opts)
+	if !errors.Is(err, ErrDisallowedByRobots) {
+		t.Fatalf("expected ErrDisallowedByRobots, got %v", err)
+	}
+
+	if _, err := c.Convert(context.Background(), srv.URL+"/public", opts); err != nil {
+		t.Fatalf("unexpected error for allowed URL: %v", err)
+	}
+}
+
+func TestConverter_RobotsUnavailable(t *testing.T) {
+	serve := func(robotsStatus int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				w.WriteHeader(robotsStatus)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, "Plain page.")
+		}))
+	}
+	opts := DefaultOptions()
+	opts.Method = "static"
+	opts.RespectRobots = true
+
+	// a failing robots.txt endpoint disallows the whole site
+	unavailable := serve(http.StatusServiceUnavailable)
+	defer unavailable.Close()
+	if _, err := New().Convert(context.Background(), unavailable.URL+"/page.txt", opts); !errors.Is(err, ErrDisallowedByRobots) {
+		t.Errorf("expected ErrDisallowedByRobots for a 503 robots.txt, got %v", err)
+	}
+
+	// a missing robots.txt allows everything
+	missing := serve(http.StatusNotFound)
+	defer missing.Close()
+	if _, err := New().Convert(context.Background(), missing.URL+"/page.txt", opts); err != nil {
+		t.Errorf("unexpected error for a 404 robots.txt: %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	maxSitemapDepth   = 3        // nesting of sitemap indexes
+	maxSitemapEntries = 50000    // per the sitemaps.org protocol
+	maxSitemapBytes   = 50 << 20 // uncompressed size limit
+)
+
+// SitemapEntry is a single page URL listed in a sitemap.
+type SitemapEntry struct {
+	URL     string
+	LastMod time.Time // zero if the sitemap omits <lastmod>
+}
+
+type sitemapXML struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// FetchSitemap downloads a sitemap and returns the page URLs it lists.
+// Sitemap indexes are followed recursively, gzip-compressed sitemaps are
+// decompressed, and plain-text sitemaps (one URL per line) are accepted.
+func FetchSitemap(ctx context.Context, sitemapURL string, opts *Options) ([]SitemapEntry, error) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+	var entries []SitemapEntry
+	seen := make(map[string]bool)
+	if err := fetchSitemap(ctx, sitemapURL, opts, 0, seen, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func fetchSitemap(ctx context.Context, sitemapURL string, opts *Options, depth int, seen map[string]bool, entries *[]SitemapEntry) error {
+	if seen[sitemapURL] {
+		return nil
+	}
+	seen[sitemapURL] = true
+
+	data, _, err := (&StaticLayer{}).fetchRaw(ctx, sitemapURL, opts)
+	if err != nil {
+		return fmt.Errorf("sitemap %s: %w", sitemapURL, err)
+	}
+	data, err = gunzipSitemap(data)
+	if err != nil {
+		return fmt.Errorf("sitemap %s: %w", sitemapURL, err)
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		*entries = append(*entries, parseTextSitemap(trimmed)...)
+		return nil
+	}
+
+	var doc sitemapXML
+	if err := xml.Unmarshal(trimmed, &doc); err != nil {
+		return fmt.Errorf("sitemap %s: parse xml: %w", sitemapURL, err)
+	}
+
+	for _, u := range doc.URLs {
+		if len(*entries) >= maxSitemapEntries {
+			return nil
+		}
+		loc := strings.TrimSpace(u.Loc)
+		if loc == "" {
+			continue
+		}
+		*entries = append(*entries, SitemapEntry{URL: loc, LastMod: parseLastMod(u.LastMod)})
+	}
+
+	if len(doc.Sitemaps) > 0 && depth >= maxSitemapDepth {
+		return fmt.Errorf("sitemap %s: index nested deeper than %d levels", sitemapURL, maxSitemapDepth)
+	}
+	for _, sm := range doc.Sitemaps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		loc := strings.TrimSpace(sm.Loc)
+		if loc == "" {
+			continue
+		}
+		if err := fetchSitemap(ctx, loc, opts, depth+1, seen, entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gunzipSitemap decompresses data if it carries the gzip magic bytes.
+func gunzipSitemap(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0x1f || data[1] != 0x8b {
+		return data, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	defer zr.Close()
+
+	out, err := io.ReadAll(io.LimitReader(zr, maxSitemapBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	if len(out) > maxSitemapBytes {
+		return nil, fmt.Errorf("gzip: sitemap exceeds %d bytes", maxSitemapBytes)
+	}
+	return out, nil
+}
+
+func parseTextSitemap(data []byte) []SitemapEntry {
+	var entries []SitemapEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() && len(entries) < maxSitemapEntries {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			entries = append(entries, SitemapEntry{URL: line})
+		}
+	}
+	return entries
+}
+
+// lastModLayouts are the W3C Datetime forms allowed in <lastmod>.
+var lastModLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+func parseLastMod(s string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
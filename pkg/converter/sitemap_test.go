@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetchSitemap_IndexAndGzip(t *testing.T) {
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>%[1]s/pages.xml</loc></sitemap>
+  <sitemap><loc>%[1]s/blog.xml.gz</loc></sitemap>
+</sitemapindex>`, srvURL)
+		case "/pages.xml":
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>%[1]s/a</loc><lastmod>2024-05-01</lastmod></url>
+  <url><loc> %[1]s/b </loc><lastmod>2024-06-01T10:00:00+00:00</lastmod></url>
+</urlset>`, srvURL)
+		case "/blog.xml.gz":
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			fmt.Fprintf(zw, `<urlset><url><loc>%s/blog/1</loc></url></urlset>`, srvURL)
+			zw.Close()
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(buf.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	srvURL = srv.URL
+
+	entries, err := FetchSitemap(context.Background(), srv.URL+"/sitemap.xml", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[1].URL != srv.URL+"/b" {
+		t.Errorf("expected trimmed loc, got %q", entries[1].URL)
+	}
+	if want := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC); !entries[0].LastMod.Equal(want) {
+		t.Errorf("expected lastmod %v, got %v", want, entries[0].LastMod)
+	}
+	if want := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC); !entries[1].LastMod.Equal(want) {
+		t.Errorf("expected lastmod %v, got %v", want, entries[1].LastMod)
+	}
+	if entries[2].URL != srv.URL+"/blog/1" || !entries[2].LastMod.IsZero() {
+		t.Errorf("unexpected gzipped entry: %+v", entries[2])
+	}
+}
+
+func TestFetchSitemap_PlainText(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "https://example.com/one\n\nnot a url\nhttps://example.com/two\n")
+	}))
+	defer srv.Close()
+
+	entries, err := FetchSitemap(context.Background(), srv.URL+"/sitemap.txt", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("expected 2 entries, got %+v", entries)
+	}
+}
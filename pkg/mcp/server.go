@@ -0,0 +1,305 @@
+// Package mcp exposes url2md as a Model Context Protocol server over stdio
+// or streamable HTTP.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/elonfeng/url2md/pkg/converter"
+)
+
+// ProtocolVersion is the newest MCP revision this server implements.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the revisions accepted during initialization.
+var supportedVersions = map[string]bool{
+	"2024-11-05": true,
+	"2025-03-26": true,
+	"2025-06-18": true,
+}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+const maxMessageBytes = 10 << 20
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server answers MCP requests using a converter.Converter.
+type Server struct {
+	conv    converter.Converter
+	base    converter.Options
+	version string
+
+	mu       sync.Mutex
+	inflight map[string]context.CancelFunc // running requests by JSON id
+}
+
+// New creates a Server. base supplies the defaults (timeout, user agent,
+// vision, cache) that tool arguments are applied on top of; nil uses
+// converter.DefaultOptions.
+func New(conv converter.Converter, base *converter.Options, version string) *Server {
+	if base == nil {
+		base = converter.DefaultOptions()
+	}
+	return &Server{conv: conv, base: *base, version: version, inflight: make(map[string]context.CancelFunc)}
+}
+
+// ServeStdio reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is exhausted or ctx is cancelled. Requests are
+// handled concurrently so a slow crawl does not block other calls.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxMessageBytes)
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	write := func(v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write(append(data, '\n'))
+	}
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		msg := append([]byte(nil), line...)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if out := s.handleMessage(ctx, msg); out != nil {
+				write(out)
+			}
+		}()
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	wg.Wait()
+	return scanner.Err()
+}
+
+// Handler returns an http.Handler implementing the streamable HTTP transport.
+// Responses are returned as plain JSON; server-initiated streams are not used.
+// Requests from browser pages on other hosts are rejected to prevent DNS
+// rebinding, so only local pages and non-browser clients can use the tools.
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !localOrigin(origin) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		out := s.handleMessage(r.Context(), bytes.TrimSpace(body))
+		if out == nil {
+			w.WriteHeader(http.StatusAccepted) // notifications and responses
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	})
+}
+
+// localOrigin reports whether an Origin header names a loopback host.
+func localOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// handleMessage processes a single message or a batch and returns the reply,
+// or nil when nothing needs to be sent back.
+func (s *Server) handleMessage(ctx context.Context, msg []byte) any {
+	if len(msg) > 0 && msg[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(msg, &batch); err != nil || len(batch) == 0 {
+			return errorResponse(nil, codeParseError, "invalid batch")
+		}
+		var replies []*response
+		for _, m := range batch {
+			if r := s.handleOne(ctx, m); r != nil {
+				replies = append(replies, r)
+			}
+		}
+		if len(replies) == 0 {
+			return nil
+		}
+		return replies
+	}
+	if r := s.handleOne(ctx, msg); r != nil {
+		return r
+	}
+	return nil
+}
+
+func (s *Server) handleOne(ctx context.Context, msg []byte) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return errorResponse(nil, codeParseError, "parse error: "+err.Error())
+	}
+	if req.JSONRPC != "2.0" {
+		return errorResponse(req.ID, codeInvalidRequest, "jsonrpc must be \"2.0\"")
+	}
+	if req.Method == "" {
+		return nil // a response from the client; we never send requests
+	}
+	isNotification := len(req.ID) == 0
+	if isNotification {
+		s.dispatch(ctx, &req)
+		return nil
+	}
+
+	// notifications/cancelled names the request by id
+	reqCtx, cancel := context.WithCancel(ctx)
+	id := string(req.ID)
+	s.mu.Lock()
+	s.inflight[id] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.inflight, id)
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	result, rerr := s.dispatch(reqCtx, &req)
+	if reqCtx.Err() != nil && ctx.Err() == nil {
+		return nil // cancelled by the client, which expects no response
+	}
+	if rerr != nil {
+		return &response{JSONRPC: "2.0", ID: req.ID, Error: rerr}
+	}
+	return &response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+func (s *Server) dispatch(ctx context.Context, req *request) (any, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if supportedVersions[params.ProtocolVersion] {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities": map[string]any{
+				"tools": map[string]any{"listChanged": false},
+			},
+			"serverInfo": map[string]any{"name": "url2md", "version": s.version},
+			"instructions": "Fetch web pages and documents and return clean Markdown. " +
+				"Use convert_url for one page, convert_batch for a list of URLs and crawl_site to follow links within a site.",
+		}, nil
+
+	case "ping":
+		return map[string]any{}, nil
+
+	case "notifications/initialized":
+		return nil, nil
+
+	case "notifications/cancelled":
+		var params struct {
+			RequestID json.RawMessage `json:"requestId"`
+		}
+		json.Unmarshal(req.Params, &params)
+		s.mu.Lock()
+		cancel := s.inflight[string(params.RequestID)]
+		s.mu.Unlock()
+		if cancel != nil {
+			cancel()
+		}
+		return nil, nil
+
+	case "tools/list":
+		return map[string]any{"tools": toolList}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		tool, ok := toolHandlers[params.Name]
+		if !ok {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		var args toolArgs
+		if len(params.Arguments) > 0 {
+			if err := json.Unmarshal(params.Arguments, &args); err != nil {
+				return nil, &rpcError{Code: codeInvalidParams, Message: "invalid arguments: " + err.Error()}
+			}
+		}
+		if err := converter.ValidateOptions(args.options(s.base)); err != nil {
+			return errorResult("invalid options: %v", err), nil
+		}
+		return tool(s, ctx, &args), nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+func errorResponse(id json.RawMessage, code int, msg string) *response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: msg}}
+}
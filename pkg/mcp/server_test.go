@@ -0,0 +1,256 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/elonfeng/url2md/pkg/converter"
+)
+
+func newTarget(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprintf(w, "# Page %s\n\nHello from the test server.", r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testOptions() *converter.Options {
+	opts := converter.DefaultOptions()
+	opts.Method = "negotiate"
+	return opts
+}
+
+// call sends messages over the stdio transport and returns the responses keyed by id.
+func call(t *testing.T, s *Server, messages ...string) map[string]response {
+	t.Helper()
+	var out bytes.Buffer
+	in := strings.NewReader(strings.Join(messages, "\n") + "\n")
+	if err := s.ServeStdio(context.Background(), in, &out); err != nil {
+		t.Fatalf("ServeStdio: %v", err)
+	}
+
+	replies := map[string]response{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var r response
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		replies[string(r.ID)] = r
+	}
+	return replies
+}
+
+func resultOf(t *testing.T, r response, v any) {
+	t.Helper()
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %+v", r.Error)
+	}
+	data, _ := json.Marshal(r.Result)
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+}
+
+func TestStdio_InitializeAndListTools(t *testing.T) {
+	s := New(converter.New(), testOptions(), "test")
+	replies := call(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"bogus"}`,
+		`not json`,
+	)
+
+	if len(replies) != 4 {
+		t.Fatalf("expected 4 replies (notification gets none), got %d: %v", len(replies), replies)
+	}
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}
+	resultOf(t, replies["1"], &init)
+	if init.ProtocolVersion != "2025-03-26" || init.ServerInfo.Name != "url2md" {
+		t.Errorf("unexpected initialize result: %+v", init)
+	}
+
+	var list struct {
+		Tools []tool `json:"tools"`
+	}
+	resultOf(t, replies["2"], &list)
+	var names []string
+	for _, tl := range list.Tools {
+		names = append(names, tl.Name)
+		if _, ok := tl.InputSchema["properties"].(map[string]any)["max_tokens"]; !ok {
+			t.Errorf("%s: expected options in input schema", tl.Name)
+		}
+	}
+	if strings.Join(names, ",") != "convert_url,convert_batch,crawl_site" {
+		t.Errorf("unexpected tools: %v", names)
+	}
+
+	if replies["3"].Error == nil || replies["3"].Error.Code != codeMethodNotFound {
+		t.Errorf("expected method not found, got %+v", replies["3"])
+	}
+	if replies["null"].Error == nil || replies["null"].Error.Code != codeParseError {
+		t.Errorf("expected parse error, got %+v", replies["null"])
+	}
+}
+
+func TestStdio_ToolCalls(t *testing.T) {
+	target := newTarget(t)
+	s := New(converter.New(), testOptions(), "test")
+
+	replies := call(t, s,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"convert_url","arguments":{"url":"%s/a","frontmatter":false}}}`, target.URL),
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"convert_batch","arguments":{"urls":["%[1]s/a","%[1]s/missing"]}}}`, target.URL),
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"convert_url","arguments":{"url":"%s/missing"}}}`, target.URL),
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"nope","arguments":{}}}`,
+		fmt.Sprintf(`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"convert_url","arguments":{"url":"%s/a","pages":"3-1"}}}`, target.URL),
+	)
+
+	var single toolResult
+	resultOf(t, replies["1"], &single)
+	if single.IsError || len(single.Content) != 1 || !strings.Contains(single.Content[0].Text, "# Page /a") {
+		t.Errorf("unexpected convert_url result: %+v", single)
+	}
+
+	var batch toolResult
+	resultOf(t, replies["2"], &batch)
+	if batch.IsError || len(batch.Content) != 2 {
+		t.Fatalf("unexpected convert_batch result: %+v", batch)
+	}
+	if !strings.Contains(batch.Content[0].Text, "# Page /a") || !strings.HasPrefix(batch.Content[1].Text, "Error converting") {
+		t.Errorf("expected results in input order, got %+v", batch.Content)
+	}
+
+	var failed toolResult
+	resultOf(t, replies["3"], &failed)
+	if !failed.IsError {
+		t.Error("expected isError for failed conversion")
+	}
+
+	if replies["4"].Error == nil || replies["4"].Error.Code != codeInvalidParams {
+		t.Errorf("expected invalid params for unknown tool, got %+v", replies["4"])
+	}
+
+	var invalid toolResult
+	resultOf(t, replies["5"], &invalid)
+	if !invalid.IsError || !strings.HasPrefix(invalid.Content[0].Text, "invalid options") {
+		t.Errorf("expected isError for invalid options, got %+v", invalid)
+	}
+}
+
+func TestHTTPHandler(t *testing.T) {
+	target := newTarget(t)
+	handler := New(converter.New(), testOptions(), "test").Handler()
+
+	body := fmt.Sprintf(`{"jsonrpc":"2.0","id":"x","method":"tools/call","params":{"name":"crawl_site","arguments":{"url":"%s/","max_pages":1}}}`, target.URL)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var r response
+	json.NewDecoder(w.Body).Decode(&r)
+	var res toolResult
+	resultOf(t, r, &res)
+	if len(res.Content) != 1 || !strings.Contains(res.Content[0].Text, "Hello from the test server.") {
+		t.Errorf("unexpected crawl result: %+v", res)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected 202 for notification, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", w.Code)
+	}
+}
+
+func TestHTTPHandler_Origin(t *testing.T) {
+	handler := New(converter.New(), testOptions(), "test").Handler()
+	body := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	tests := []struct {
+		origin string
+		code   int
+	}{
+		{"", http.StatusOK},
+		{"http://localhost:3000", http.StatusOK},
+		{"http://127.0.0.1:8090", http.StatusOK},
+		{"http://[::1]", http.StatusOK},
+		{"https://evil.example", http.StatusForbidden},
+		{"http://localhost.evil.example", http.StatusForbidden},
+		{"null", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.code {
+			t.Errorf("origin %q: expected %d, got %d", tt.origin, tt.code, w.Code)
+		}
+	}
+}
+
+func TestHTTPHandler_Cancelled(t *testing.T) {
+	started, aborted := make(chan struct{}), make(chan struct{})
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer target.Close()
+	handler := New(converter.New(), testOptions(), "test").Handler()
+
+	done := make(chan int)
+	go func() {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"convert_url","arguments":{"url":"%s/slow"}}}`, target.URL)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+		done <- w.Code
+	}()
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user aborted"}}`)))
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected 202 for notification, got %d", w.Code)
+	}
+
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the cancelled request kept fetching")
+	}
+	if code := <-done; code != http.StatusAccepted {
+		t.Errorf("expected no response for a cancelled request, got %d", code)
+	}
+}
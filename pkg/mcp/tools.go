@@ -0,0 +1,356 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/elonfeng/url2md/pkg/converter"
+)
+
+const (
+	defaultBatchConcurrency = 4
+	maxBatchConcurrency     = 16
+	maxBatchURLs            = 100
+	defaultCrawlPages       = 20
+	maxCrawlPages           = 200
+)
+
+// toolArgs is the union of the arguments accepted by all tools.
+type toolArgs struct {
+	URL           string   `json:"url"`
+	URLs          []string `json:"urls"`
+	Method        string   `json:"method"`
+	RetainImages  *bool    `json:"retain_images"`
+	RetainLinks   *bool    `json:"retain_links"`
+	LinkMode      string   `json:"link_mode"`
+	Frontmatter   *bool    `json:"frontmatter"`
+	EnableBrowser *bool    `json:"enable_browser"`
+	Tokenizer     string   `json:"tokenizer"`
+	MaxTokens     int      `json:"max_tokens"`
+	Pages         string   `json:"pages"`
+	Sheets        string   `json:"sheets"`
+	Range         string   `json:"range"`
+	MaxRows       int      `json:"max_rows"`
+	IncludeHidden *bool    `json:"include_hidden"`
+	Formulas      *bool    `json:"formulas"`
+	DropOutputs   *bool    `json:"drop_outputs"`
+	DocComments   *bool    `json:"doc_comments"`
+	ChunkTokens   int      `json:"chunk_tokens"`
+	ChunkOverlap  int      `json:"chunk_overlap"`
+	RespectRobots *bool    `json:"respect_robots"`
+	Concurrency   int      `json:"concurrency"`
+	MaxDepth      *int     `json:"max_depth"`
+	MaxPages      int      `json:"max_pages"`
+	Include       []string `json:"include"`
+	Exclude       []string `json:"exclude"`
+}
+
+// options applies the arguments on top of the server's base options.
+func (a *toolArgs) options(base converter.Options) *converter.Options {
+	opts := base
+	if a.Method != "" {
+		opts.Method = a.Method
+	}
+	if a.RetainImages != nil {
+		opts.RetainImages = *a.RetainImages
+	}
+	if a.RetainLinks != nil {
+		opts.RetainLinks = *a.RetainLinks
+	}
+	if a.LinkMode != "" {
+		opts.LinkMode = a.LinkMode
+	}
+	if a.Frontmatter != nil {
+		opts.Frontmatter = *a.Frontmatter
+	}
+	if a.EnableBrowser != nil {
+		opts.EnableBrowser = *a.EnableBrowser
+	}
+	if a.Tokenizer != "" {
+		opts.Tokenizer = a.Tokenizer
+	}
+	if a.MaxTokens > 0 {
+		opts.MaxTokens = a.MaxTokens
+	}
+	if a.Pages != "" {
+		opts.Pages = a.Pages
+	}
+	if a.Sheets != "" {
+		opts.Sheets = a.Sheets
+	}
+	if a.Range != "" {
+		opts.CellRange = a.Range
+	}
+	if a.MaxRows > 0 {
+		opts.MaxRows = a.MaxRows
+	}
+	if a.IncludeHidden != nil {
+		opts.IncludeHidden = *a.IncludeHidden
+	}
+	if a.Formulas != nil {
+		opts.Formulas = *a.Formulas
+	}
+	if a.DropOutputs != nil {
+		opts.DropOutputs = *a.DropOutputs
+	}
+	if a.DocComments != nil {
+		opts.DocComments = *a.DocComments
+	}
+	if a.ChunkTokens > 0 {
+		opts.Chunk = &converter.ChunkOptions{MaxTokens: a.ChunkTokens, Overlap: a.ChunkOverlap}
+	}
+	if a.RespectRobots != nil {
+		opts.RespectRobots = *a.RespectRobots
+	}
+	return &opts
+}
+
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type toolResult struct {
+	Content []content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+func textResult(text string) *toolResult {
+	return &toolResult{Content: []content{{Type: "text", Text: text}}}
+}
+
+func errorResult(format string, args ...any) *toolResult {
+	r := textResult(fmt.Sprintf(format, args...))
+	r.IsError = true
+	return r
+}
+
+type toolHandler func(s *Server, ctx context.Context, args *toolArgs) *toolResult
+
+var toolHandlers = map[string]toolHandler{
+	"convert_url":   (*Server).convertURL,
+	"convert_batch": (*Server).convertBatch,
+	"crawl_site":    (*Server).crawlSite,
+}
+
+func (s *Server) convertURL(ctx context.Context, args *toolArgs) *toolResult {
+	if args.URL == "" {
+		return errorResult("url is required")
+	}
+	result, err := s.conv.Convert(ctx, normalizeURL(args.URL), args.options(s.base))
+	if err != nil {
+		return errorResult("convert %s: %v", args.URL, err)
+	}
+	return textResult(formatResult(result))
+}
+
+func (s *Server) convertBatch(ctx context.Context, args *toolArgs) *toolResult {
+	if len(args.URLs) == 0 {
+		return errorResult("urls is required")
+	}
+	if len(args.URLs) > maxBatchURLs {
+		return errorResult("at most %d urls per call", maxBatchURLs)
+	}
+	concurrency := args.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	concurrency = min(concurrency, maxBatchConcurrency)
+
+	opts := args.options(s.base)
+	items := make([]content, len(args.URLs))
+	failed := 0
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+	for i, u := range args.URLs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			o := *opts
+			result, err := s.conv.Convert(ctx, normalizeURL(u), &o)
+			text := ""
+			if err != nil {
+				text = fmt.Sprintf("Error converting %s: %v", u, err)
+				mu.Lock()
+				failed++
+				mu.Unlock()
+			} else {
+				text = formatResult(result)
+			}
+			items[i] = content{Type: "text", Text: text}
+		}()
+	}
+	wg.Wait()
+
+	return &toolResult{Content: items, IsError: failed == len(args.URLs)}
+}
+
+func (s *Server) crawlSite(ctx context.Context, args *toolArgs) *toolResult {
+	if args.URL == "" {
+		return errorResult("url is required")
+	}
+	crawl := converter.DefaultCrawlOptions()
+	if args.MaxDepth != nil {
+		crawl.MaxDepth = *args.MaxDepth
+	}
+	crawl.MaxPages = defaultCrawlPages
+	if args.MaxPages > 0 {
+		crawl.MaxPages = min(args.MaxPages, maxCrawlPages)
+	}
+	if args.Concurrency > 0 {
+		crawl.Concurrency = min(args.Concurrency, maxBatchConcurrency)
+	}
+	crawl.Include = args.Include
+	crawl.Exclude = args.Exclude
+
+	var items []content
+	crawler := converter.NewCrawler(s.conv, args.options(s.base), crawl)
+	err := crawler.Crawl(ctx, normalizeURL(args.URL), func(r *converter.CrawlResult) {
+		if r.Err != nil {
+			items = append(items, content{Type: "text", Text: fmt.Sprintf("Error converting %s: %v", r.URL, r.Err)})
+			return
+		}
+		items = append(items, content{Type: "text", Text: formatResult(r.Result)})
+	})
+	if err != nil {
+		return errorResult("crawl %s: %v", args.URL, err)
+	}
+	if len(items) == 0 {
+		return errorResult("no pages converted")
+	}
+	return &toolResult{Content: items}
+}
+
+// formatResult renders a result for the model: the source URL followed by
+// the markdown, or the chunks as JSON when chunking was requested.
+func formatResult(r *converter.Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Source: %s\n", r.URL)
+	if r.Truncated {
+		fmt.Fprintf(&b, "Truncated: %d of %d tokens\n", r.TokenCount, r.OriginalTokenCount)
+	}
+	b.WriteString("\n")
+	if len(r.Chunks) > 0 {
+		data, _ := json.MarshalIndent(r.Chunks, "", "  ")
+		b.Write(data)
+		return b.String()
+	}
+	b.WriteString(r.Markdown)
+	return b.String()
+}
+
+func normalizeURL(u string) string {
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return "https://" + u
+	}
+	return u
+}
+
+// Tool definitions returned by tools/list.
+
+type tool struct {
+	Name        string         `json:"name"`
+	Title       string         `json:"title,omitempty"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+	Annotations map[string]any `json:"annotations,omitempty"`
+}
+
+func prop(typ, desc string) map[string]any {
+	return map[string]any{"type": typ, "description": desc}
+}
+
+func stringList(desc string) map[string]any {
+	return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": desc}
+}
+
+// optionProps are the converter.Options fields exposed on every tool.
+func optionProps() map[string]any {
+	return map[string]any{
+		"method": map[string]any{
+			"type":        "string",
+			"enum":        []string{"auto", "negotiate", "static", "browser"},
+			"description": "Conversion method; auto tries content negotiation, then a static fetch",
+		},
+		"retain_images": prop("boolean", "Keep images in the Markdown"),
+		"retain_links":  prop("boolean", "Keep links in the Markdown (default true)"),
+		"link_mode": map[string]any{
+			"type":        "string",
+			"enum":        []string{"inline", "reference", "text"},
+			"description": "How links are rendered: inline [text](url), reference [text][1] with URLs listed at the end, or text only",
+		},
+		"frontmatter":    prop("boolean", "Prepend YAML frontmatter with title, description and image (default true)"),
+		"enable_browser": prop("boolean", "Fall back to headless Chrome for JavaScript-rendered pages"),
+		"tokenizer": map[string]any{
+			"type":        "string",
+			"enum":        []string{"heuristic", "cl100k_base", "o200k_base"},
+			"description": "Tokenizer used for token counts, budgets and chunking",
+		},
+		"max_tokens":     prop("integer", "Trim each page to at most this many tokens, dropping low-value sections first"),
+		"pages":          prop("string", "PDF pages to convert, e.g. 1-5,10 or 20- (default all)"),
+		"sheets":         prop("string", "Spreadsheet sheets to convert by name or 1-based index, comma-separated (default all visible)"),
+		"range":          prop("string", "Spreadsheet cells to convert, e.g. A1:F100, B:D or 5:20"),
+		"max_rows":       prop("integer", "Spreadsheet data rows per sheet; the rest are summarized as omitted"),
+		"include_hidden": prop("boolean", "Keep hidden spreadsheet sheets, rows and columns"),
+		"formulas":       prop("boolean", "Show spreadsheet formulas alongside computed values"),
+		"drop_outputs":   prop("boolean", "Leave out Jupyter notebook cell outputs"),
+		"doc_comments":   prop("boolean", "Render a source file's leading doc comment as prose above the code"),
+		"chunk_tokens":   prop("integer", "Return heading-aware chunks of at most this many tokens as JSON"),
+		"chunk_overlap":  prop("integer", "Tokens of context repeated at the start of each chunk"),
+		"respect_robots": prop("boolean", "Refuse URLs disallowed by robots.txt"),
+	}
+}
+
+func schema(required []string, extra map[string]any) map[string]any {
+	props := optionProps()
+	for k, v := range extra {
+		props[k] = v
+	}
+	return map[string]any{"type": "object", "properties": props, "required": required}
+}
+
+var readOnly = map[string]any{"readOnlyHint": true, "openWorldHint": true}
+
+var toolList = []tool{
+	{
+		Name:        "convert_url",
+		Title:       "Convert URL to Markdown",
+		Description: "Fetch a web page or document (HTML, PDF, DOCX, XLSX, images, ...) and return clean Markdown.",
+		InputSchema: schema([]string{"url"}, map[string]any{
+			"url": prop("string", "URL to convert"),
+		}),
+		Annotations: readOnly,
+	},
+	{
+		Name:        "convert_batch",
+		Title:       "Convert URLs to Markdown",
+		Description: "Convert several URLs concurrently with the same options. Returns one text item per URL, in input order.",
+		InputSchema: schema([]string{"urls"}, map[string]any{
+			"urls":        stringList(fmt.Sprintf("URLs to convert (at most %d)", maxBatchURLs)),
+			"concurrency": prop("integer", "Conversions in flight at once (default 4)"),
+		}),
+		Annotations: readOnly,
+	},
+	{
+		Name:        "crawl_site",
+		Title:       "Crawl a site",
+		Description: "Follow same-origin links from a seed URL breadth-first and return one Markdown text item per page.",
+		InputSchema: schema([]string{"url"}, map[string]any{
+			"url":         prop("string", "Seed URL"),
+			"max_depth":   prop("integer", "Maximum link depth from the seed (default 3)"),
+			"max_pages":   prop("integer", fmt.Sprintf("Maximum pages to convert (default %d, at most %d)", defaultCrawlPages, maxCrawlPages)),
+			"include":     stringList("Only follow URL paths matching these globs, e.g. /docs/**"),
+			"exclude":     stringList("Skip URL paths matching these globs"),
+			"concurrency": prop("integer", "Pages converted in parallel (default 4)"),
+		}),
+		Annotations: readOnly,
+	},
+}
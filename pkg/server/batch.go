@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/elonfeng/url2md/pkg/converter"
+)
+
+const (
+	defaultBatchConcurrency = 4
+	maxBatchConcurrency     = 16
+)
+
+type batchRequest struct {
+	convertRequest
+	URLs        []string `json:"urls"`
+	Concurrency int      `json:"concurrency,omitempty"`
+}
+
+type batchError struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// handleBatch converts many URLs with shared options and streams one JSON
+// object per URL, in completion order, as newline-delimited JSON.
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+	if len(req.URLs) == 0 {
+		writeError(w, http.StatusBadRequest, "urls is required")
+		return
+	}
+	if len(req.URLs) > maxJobURLs {
+		writeError(w, http.StatusBadRequest, "too many urls")
+		return
+	}
+	opts := s.requestOptions(&req.convertRequest)
+	if err := converter.ValidateOptions(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	concurrency = min(concurrency, maxBatchConcurrency, len(req.URLs))
+
+	opts.HTTPClient = &http.Client{Timeout: opts.Timeout}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	var (
+		mu  sync.Mutex
+		enc = json.NewEncoder(w)
+		wg  sync.WaitGroup
+	)
+	emit := func(v any) {
+		mu.Lock()
+		defer mu.Unlock()
+		enc.Encode(v)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	urls := make(chan string)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range urls {
+				o := opts
+				result, err := s.conv.Convert(r.Context(), u, &o)
+				if err != nil {
+					emit(batchError{URL: u, Error: err.Error()})
+					continue
+				}
+				emit(newConvertResponse(result))
+			}
+		}()
+	}
+
+feed:
+	for _, u := range req.URLs {
+		select {
+		case urls <- normalizeURL(u):
+		case <-r.Context().Done():
+			break feed // client went away
+		}
+	}
+	close(urls)
+	wg.Wait()
+}
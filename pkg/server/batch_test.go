@@ -0,0 +1,68 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchEndpoint_StreamsNDJSON(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprintf(w, "# %s\n\nBody.", r.URL.Path)
+	}))
+	defer target.Close()
+
+	handler := New(0).Handler()
+	payload := fmt.Sprintf(`{"urls":["%[1]s/a","%[1]s/b","%[1]s/broken"],"method":"negotiate","concurrency":2}`, target.URL)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(payload)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("unexpected Content-Type %q", ct)
+	}
+
+	results := map[string]map[string]any{}
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var line map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		results[line["url"].(string)] = line
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(results))
+	}
+	for _, path := range []string{"/a", "/b"} {
+		line := results[target.URL+path]
+		if md, _ := line["markdown"].(string); !strings.HasPrefix(md, "# "+path) {
+			t.Errorf("%s: unexpected line %v", path, line)
+		}
+	}
+	if results[target.URL+"/broken"]["error"] == nil {
+		t.Error("expected error line for /broken")
+	}
+}
+
+func TestBatchEndpoint_BadRequest(t *testing.T) {
+	handler := New(0).Handler()
+	for _, body := range []string{`{}`, `{"urls":[]}`, `[`, `{"urls":["a"],"tokenizer":"nope"}`} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", body, w.Code)
+		}
+	}
+}
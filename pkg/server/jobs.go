@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/elonfeng/url2md/pkg/converter"
+)
+
+const (
+	defaultJobWorkers = 4
+	maxQueuedJobs     = 100
+	maxJobURLs        = 1000
+	jobTTL            = time.Hour       // how long finished jobs stay queryable
+	jobURLTimeout     = 2 * time.Minute // per URL when the server has no timeout
+)
+
+// Job states reported by GET /jobs/{id}.
+const (
+	jobQueued  = "queued"
+	jobRunning = "running"
+	jobDone    = "done"
+	jobFailed  = "failed" // every URL failed
+)
+
+type jobRequest struct {
+	convertRequest
+	URLs []string `json:"urls,omitempty"`
+}
+
+type jobResult struct {
+	URL    string           `json:"url"`
+	Result *convertResponse `json:"result,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+type jobStatus struct {
+	ID         string      `json:"id"`
+	Status     string      `json:"status"`
+	Total      int         `json:"total"`
+	Completed  int         `json:"completed"`
+	Failed     int         `json:"failed"`
+	Results    []jobResult `json:"results,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+type job struct {
+	mu     sync.Mutex
+	status jobStatus
+	urls   []string
+	opts   converter.Options
+}
+
+func (j *job) snapshot() jobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	st := j.status
+	st.Results = append([]jobResult(nil), j.status.Results...)
+	return st
+}
+
+// jobStore holds queued and recently finished jobs and the worker pool that
+// runs them.
+type jobStore struct {
+	mu      sync.Mutex
+	jobs    map[string]*job
+	queue   chan *job
+	workers int
+	once    sync.Once
+}
+
+func newJobStore(workers int) *jobStore {
+	if workers <= 0 {
+		workers = defaultJobWorkers
+	}
+	return &jobStore{
+		jobs:    make(map[string]*job),
+		queue:   make(chan *job, maxQueuedJobs),
+		workers: workers,
+	}
+}
+
+// SetJobWorkers sets how many jobs run concurrently. It must be called
+// before the first job is submitted.
+func (s *Server) SetJobWorkers(n int) {
+	s.jobs = newJobStore(n)
+}
+
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
+	var req jobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return
+	}
+
+	urls := req.URLs
+	if req.URL != "" {
+		urls = append([]string{req.URL}, urls...)
+	}
+	if len(urls) == 0 {
+		writeError(w, http.StatusBadRequest, "url or urls is required")
+		return
+	}
+	if len(urls) > maxJobURLs {
+		writeError(w, http.StatusBadRequest, "too many urls")
+		return
+	}
+	for i, u := range urls {
+		urls[i] = normalizeURL(u)
+	}
+	opts := s.requestOptions(&req.convertRequest)
+	if err := converter.ValidateOptions(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	j := &job{
+		urls: urls,
+		opts: opts,
+		status: jobStatus{
+			ID:        newJobID(),
+			Status:    jobQueued,
+			Total:     len(urls),
+			CreatedAt: time.Now().UTC(),
+		},
+	}
+
+	store := s.jobs
+	store.once.Do(func() {
+		for range store.workers {
+			go s.runJobs(store)
+		}
+	})
+	store.sweep()
+
+	store.mu.Lock()
+	store.jobs[j.status.ID] = j
+	store.mu.Unlock()
+
+	select {
+	case store.queue <- j:
+	default:
+		store.mu.Lock()
+		delete(store.jobs, j.status.ID)
+		store.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, "job queue is full, retry later")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/jobs/"+j.status.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(j.snapshot())
+}
+
+func (s *Server) handleGetJob(w http.ResponseWriter, r *http.Request) {
+	s.jobs.sweep()
+
+	s.jobs.mu.Lock()
+	j, ok := s.jobs.jobs[r.PathValue("id")]
+	s.jobs.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(j.snapshot())
+}
+
+// runJobs is a pool worker: it converts the URLs of one job at a time.
+func (s *Server) runJobs(store *jobStore) {
+	for j := range store.queue {
+		j.mu.Lock()
+		j.status.Status = jobRunning
+		j.mu.Unlock()
+
+		timeout := s.timeout
+		if timeout <= 0 {
+			timeout = jobURLTimeout
+		}
+		for _, u := range j.urls {
+			opts := j.opts
+			res := jobResult{URL: u}
+			ctx, cancel := context.WithTimeout(s.ctx, timeout)
+			result, err := s.conv.Convert(ctx, u, &opts)
+			cancel()
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				resp := newConvertResponse(result)
+				res.Result = &resp
+			}
+
+			j.mu.Lock()
+			j.status.Results = append(j.status.Results, res)
+			j.status.Completed++
+			if err != nil {
+				j.status.Failed++
+			}
+			j.mu.Unlock()
+		}
+
+		j.mu.Lock()
+		now := time.Now().UTC()
+		j.status.FinishedAt = &now
+		j.status.Status = jobDone
+		if j.status.Failed == j.status.Total {
+			j.status.Status = jobFailed
+		}
+		j.mu.Unlock()
+	}
+}
+
+// sweep forgets jobs that finished more than jobTTL ago.
+func (js *jobStore) sweep() {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+	for id, j := range js.jobs {
+		j.mu.Lock()
+		expired := j.status.FinishedAt != nil && time.Since(*j.status.FinishedAt) > jobTTL
+		j.mu.Unlock()
+		if expired {
+			delete(js.jobs, id)
+		}
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
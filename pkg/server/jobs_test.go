@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitJob polls a job until it finishes or five seconds pass.
+func waitJob(t *testing.T, handler http.Handler, id string) jobStatus {
+	t.Helper()
+	var status jobStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jobs/"+id, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+		json.NewDecoder(w.Body).Decode(&status)
+		if status.Status == jobDone || status.Status == jobFailed || time.Now().After(deadline) {
+			return status
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobs_Lifecycle(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprintf(w, "# Page %s\n\nBody.", r.URL.Path)
+	}))
+	defer target.Close()
+
+	srv := New(0)
+	handler := srv.Handler()
+
+	payload := fmt.Sprintf(`{"urls":["%s/a","%s/missing"],"method":"negotiate"}`, target.URL, target.URL)
+	req := httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(payload))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var created jobStatus
+	json.NewDecoder(w.Body).Decode(&created)
+	if created.ID == "" || created.Total != 2 {
+		t.Fatalf("unexpected job: %+v", created)
+	}
+	if loc := w.Header().Get("Location"); loc != "/jobs/"+created.ID {
+		t.Errorf("unexpected Location %q", loc)
+	}
+
+	status := waitJob(t, handler, created.ID)
+	if status.Status != jobDone || status.Completed != 2 || status.Failed != 1 {
+		t.Fatalf("unexpected final status: %+v", status)
+	}
+	if status.FinishedAt == nil {
+		t.Error("expected finished_at to be set")
+	}
+	for _, r := range status.Results {
+		switch {
+		case strings.HasSuffix(r.URL, "/a"):
+			if r.Result == nil || !strings.Contains(r.Result.Markdown, "# Page /a") {
+				t.Errorf("unexpected result for %s: %+v", r.URL, r)
+			}
+		case strings.HasSuffix(r.URL, "/missing"):
+			if r.Error == "" {
+				t.Errorf("expected error for %s", r.URL)
+			}
+		}
+	}
+}
+
+func TestJobs_StalledUpstream(t *testing.T) {
+	release := make(chan struct{})
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer target.Close()
+	defer close(release)
+
+	submit := func(srv *Server) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"url":"`+target.URL+`/slow","method":"static"}`)))
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("expected 202, got %d; body: %s", w.Code, w.Body.String())
+		}
+		var created jobStatus
+		json.NewDecoder(w.Body).Decode(&created)
+		return created.ID
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		srv := New(0)
+		srv.SetTimeout(200 * time.Millisecond)
+		status := waitJob(t, srv.Handler(), submit(srv))
+		if status.Status != jobFailed || status.Failed != 1 {
+			t.Errorf("expected the stalled job to fail, got %+v", status)
+		}
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		srv := New(0)
+		id := submit(srv)
+		time.Sleep(50 * time.Millisecond)
+		if err := srv.Shutdown(context.Background()); err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+		status := waitJob(t, srv.Handler(), id)
+		if status.Status != jobFailed || status.Failed != 1 {
+			t.Errorf("expected shutdown to fail the running job, got %+v", status)
+		}
+	})
+}
+
+func TestJobs_Errors(t *testing.T) {
+	handler := New(0).Handler()
+
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPost, "/jobs", `{}`, http.StatusBadRequest},
+		{http.MethodPost, "/jobs", `not json`, http.StatusBadRequest},
+		{http.MethodPost, "/jobs", `{"url":"https://example.com","tokenizer":"nope"}`, http.StatusBadRequest},
+		{http.MethodPost, "/jobs", `{"url":"https://example.com","pages":"x"}`, http.StatusBadRequest},
+		{http.MethodPost, "/jobs", `{"url":"https://example.com","range":"B2:A1"}`, http.StatusBadRequest},
+		{http.MethodGet, "/jobs/unknown", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if w.Code != tt.want {
+			t.Errorf("%s %s %q: expected %d, got %d", tt.method, tt.path, tt.body, tt.want, w.Code)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,23 +16,42 @@ import (
 )
 
 type convertRequest struct {
-	URL          string `json:"url"`
-	Method       string `json:"method,omitempty"`
-	RetainImages bool   `json:"retain_images,omitempty"`
-	RetainLinks  *bool  `json:"retain_links,omitempty"`
-	Frontmatter  *bool  `json:"frontmatter,omitempty"`
+	URL           string `json:"url"`
+	Method        string `json:"method,omitempty"`
+	RetainImages  bool   `json:"retain_images,omitempty"`
+	RetainLinks   *bool  `json:"retain_links,omitempty"`
+	LinkMode      string `json:"link_mode,omitempty"`
+	Frontmatter   *bool  `json:"frontmatter,omitempty"`
+	EnableBrowser bool   `json:"enable_browser,omitempty"`
+	ChunkTokens   int    `json:"chunk_tokens,omitempty"`
+	ChunkOverlap  int    `json:"chunk_overlap,omitempty"`
+	Tokenizer     string `json:"tokenizer,omitempty"`
+	MaxTokens     int    `json:"max_tokens,omitempty"`
+	Pages         string `json:"pages,omitempty"`
+	Sheets        string `json:"sheets,omitempty"`
+	Range         string `json:"range,omitempty"`
+	MaxRows       int    `json:"max_rows,omitempty"`
+	IncludeHidden bool   `json:"include_hidden,omitempty"`
+	Formulas      bool   `json:"formulas,omitempty"`
+	DropOutputs   bool   `json:"drop_outputs,omitempty"`
+	DocComments   bool   `json:"doc_comments,omitempty"`
 }
 
 type convertResponse struct {
-	URL         string            `json:"url"`
-	Markdown    string            `json:"markdown"`
-	Title       string            `json:"title"`
-	Description string            `json:"description"`
-	TokenCount  int               `json:"token_count"`
-	Method      string            `json:"method"`
-	Metadata    map[string]string `json:"metadata,omitempty"`
-	FetchMs     int64             `json:"fetch_ms"`
-	ConvertMs   int64             `json:"convert_ms"`
+	URL                string            `json:"url"`
+	Markdown           string            `json:"markdown"`
+	Title              string            `json:"title"`
+	Description        string            `json:"description"`
+	TokenCount         int               `json:"token_count"`
+	Tokenizer          string            `json:"tokenizer"`
+	Truncated          bool              `json:"truncated,omitempty"`
+	OriginalTokenCount int               `json:"original_token_count,omitempty"`
+	Method             string            `json:"method"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+	Chunks             []converter.Chunk `json:"chunks,omitempty"`
+	Cached             bool              `json:"cached,omitempty"`
+	FetchMs            int64             `json:"fetch_ms"`
+	ConvertMs          int64             `json:"convert_ms"`
 }
 
 type errorResponse struct {
@@ -38,27 +60,54 @@ type errorResponse struct {
 
 // Server is the url2md HTTP server.
 type Server struct {
-	conv converter.Converter
-	port int
+	conv    converter.Converter
+	port    int
+	cache   converter.Cache
+	jobs    *jobStore
+	timeout time.Duration // per conversion; zero uses the converter default
+	http    *http.Server
+
+	// ctx is the parent of every job conversion; Shutdown cancels it.
+	ctx  context.Context
+	stop context.CancelFunc
 }
 
 // New creates a new Server.
 func New(port int) *Server {
-	return &Server{
+	ctx, stop := context.WithCancel(context.Background())
+	s := &Server{
 		conv: converter.New(),
 		port: port,
+		jobs: newJobStore(defaultJobWorkers),
+		ctx:  ctx,
+		stop: stop,
 	}
+	s.http = &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: s.Handler()}
+	return s
 }
 
-// ListenAndServe starts the HTTP server.
-func (s *Server) ListenAndServe() error {
+// Handler returns the HTTP handler serving all endpoints.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleConvert)
 	mux.HandleFunc("/health", s.handleHealth)
+	mux.HandleFunc("POST /upload", s.handleUpload)
+	mux.HandleFunc("POST /batch", s.handleBatch)
+	mux.HandleFunc("POST /jobs", s.handleCreateJob)
+	mux.HandleFunc("GET /jobs/{id}", s.handleGetJob)
+	return mux
+}
+
+// ListenAndServe starts the HTTP server.
+func (s *Server) ListenAndServe() error {
+	fmt.Printf("url2md server listening on %s\n", s.http.Addr)
+	return s.http.ListenAndServe()
+}
 
-	addr := fmt.Sprintf(":%d", s.port)
-	fmt.Printf("url2md server listening on %s\n", addr)
-	return http.ListenAndServe(addr, mux)
+// Shutdown cancels running jobs and gracefully stops the HTTP server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stop()
+	return s.http.Shutdown(ctx)
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -67,10 +116,10 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
-	opts := *converter.DefaultOptions()
-	opts.Vision = visionFromEnv()
-
-	var targetURL string
+	var (
+		opts      converter.Options
+		targetURL string
+	)
 
 	switch r.Method {
 	case http.MethodGet:
@@ -86,21 +135,7 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
 			targetURL = path
 		}
 
-		if m := r.URL.Query().Get("method"); m != "" {
-			opts.Method = m
-		}
-		if r.URL.Query().Get("retain_images") == "true" {
-			opts.RetainImages = true
-		}
-		if r.URL.Query().Get("retain_links") == "false" {
-			opts.RetainLinks = false
-		}
-		if r.URL.Query().Get("enable_browser") == "true" {
-			opts.EnableBrowser = true
-		}
-		if r.URL.Query().Get("frontmatter") == "false" {
-			opts.Frontmatter = false
-		}
+		opts = s.queryOptions(r.URL.Query())
 
 	case http.MethodPost:
 		var req convertRequest
@@ -109,16 +144,7 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		targetURL = req.URL
-		if req.Method != "" {
-			opts.Method = req.Method
-		}
-		opts.RetainImages = req.RetainImages
-		if req.RetainLinks != nil {
-			opts.RetainLinks = *req.RetainLinks
-		}
-		if req.Frontmatter != nil {
-			opts.Frontmatter = *req.Frontmatter
-		}
+		opts = s.requestOptions(&req)
 
 	default:
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -130,35 +156,156 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// ensure scheme
-	if !strings.HasPrefix(targetURL, "http://") && !strings.HasPrefix(targetURL, "https://") {
-		targetURL = "https://" + targetURL
+	if err := converter.ValidateOptions(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	result, err := s.conv.Convert(r.Context(), targetURL, &opts)
+	result, err := s.conv.Convert(r.Context(), normalizeURL(targetURL), &opts)
 	if err != nil {
 		writeError(w, http.StatusBadGateway, err.Error())
 		return
 	}
 
+	s.writeResult(w, result)
+}
+
+// writeResult writes a successful conversion with its summary headers.
+func (s *Server) writeResult(w http.ResponseWriter, result *converter.Result) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("X-Markdown-Tokens", fmt.Sprintf("%d", result.TokenCount))
+	w.Header().Set("X-Tokenizer", result.Tokenizer)
 	w.Header().Set("X-Convert-Method", result.Method)
+	if result.Cached {
+		w.Header().Set("X-Cache", "HIT")
+	} else if s.cache != nil {
+		w.Header().Set("X-Cache", "MISS")
+	}
 	w.Header().Set("X-Fetch-Time", fmt.Sprintf("%dms", result.FetchTime.Milliseconds()))
 
+	json.NewEncoder(w).Encode(newConvertResponse(result))
+}
+
+// defaultOptions returns the converter defaults with the server's settings.
+func (s *Server) defaultOptions() converter.Options {
+	opts := *converter.DefaultOptions()
+	opts.Vision = visionFromEnv()
+	opts.Cache = s.cache
+	if s.timeout > 0 {
+		opts.Timeout = s.timeout
+	}
+	return opts
+}
+
+// queryOptions builds conversion options from query or form parameters.
+func (s *Server) queryOptions(q url.Values) converter.Options {
+	opts := s.defaultOptions()
+
+	if m := q.Get("method"); m != "" {
+		opts.Method = m
+	}
+	if q.Get("retain_images") == "true" {
+		opts.RetainImages = true
+	}
+	if q.Get("retain_links") == "false" {
+		opts.RetainLinks = false
+	}
+	opts.LinkMode = q.Get("link_mode")
+	if q.Get("enable_browser") == "true" {
+		opts.EnableBrowser = true
+	}
+	if q.Get("frontmatter") == "false" {
+		opts.Frontmatter = false
+	}
+	if n, err := strconv.Atoi(q.Get("chunk_tokens")); err == nil && n > 0 {
+		overlap, _ := strconv.Atoi(q.Get("chunk_overlap"))
+		opts.Chunk = &converter.ChunkOptions{MaxTokens: n, Overlap: overlap}
+	}
+	opts.Tokenizer = q.Get("tokenizer")
+	if n, err := strconv.Atoi(q.Get("max_tokens")); err == nil && n > 0 {
+		opts.MaxTokens = n
+	}
+	opts.Pages = q.Get("pages")
+	opts.Sheets = q.Get("sheets")
+	opts.CellRange = q.Get("range")
+	if n, err := strconv.Atoi(q.Get("max_rows")); err == nil && n > 0 {
+		opts.MaxRows = n
+	}
+	if q.Get("include_hidden") == "true" {
+		opts.IncludeHidden = true
+	}
+	if q.Get("formulas") == "true" {
+		opts.Formulas = true
+	}
+	if q.Get("drop_outputs") == "true" {
+		opts.DropOutputs = true
+	}
+	if q.Get("doc_comments") == "true" {
+		opts.DocComments = true
+	}
+	return opts
+}
+
+// requestOptions builds conversion options from a JSON request body.
+func (s *Server) requestOptions(req *convertRequest) converter.Options {
+	opts := s.defaultOptions()
+
+	if req.Method != "" {
+		opts.Method = req.Method
+	}
+	opts.RetainImages = req.RetainImages
+	if req.RetainLinks != nil {
+		opts.RetainLinks = *req.RetainLinks
+	}
+	opts.LinkMode = req.LinkMode
+	if req.Frontmatter != nil {
+		opts.Frontmatter = *req.Frontmatter
+	}
+	opts.EnableBrowser = req.EnableBrowser
+	if req.ChunkTokens > 0 {
+		opts.Chunk = &converter.ChunkOptions{MaxTokens: req.ChunkTokens, Overlap: req.ChunkOverlap}
+	}
+	opts.Tokenizer = req.Tokenizer
+	opts.MaxTokens = req.MaxTokens
+	opts.Pages = req.Pages
+	opts.Sheets = req.Sheets
+	opts.CellRange = req.Range
+	opts.MaxRows = req.MaxRows
+	opts.IncludeHidden = req.IncludeHidden
+	opts.Formulas = req.Formulas
+	opts.DropOutputs = req.DropOutputs
+	opts.DocComments = req.DocComments
+	return opts
+}
+
+// normalizeURL adds an https:// scheme when the URL has none.
+func normalizeURL(u string) string {
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		return "https://" + u
+	}
+	return u
+}
+
+func newConvertResponse(result *converter.Result) convertResponse {
 	resp := convertResponse{
 		URL:         result.URL,
 		Markdown:    result.Markdown,
 		Title:       result.Title,
 		Description: result.Description,
 		TokenCount:  result.TokenCount,
+		Tokenizer:   result.Tokenizer,
+		Truncated:   result.Truncated,
 		Method:      result.Method,
 		Metadata:    result.Metadata,
+		Chunks:      result.Chunks,
+		Cached:      result.Cached,
 		FetchMs:     result.FetchTime.Milliseconds(),
 		ConvertMs:   result.ConvertTime.Milliseconds(),
 	}
-
-	json.NewEncoder(w).Encode(resp)
+	if result.Truncated {
+		resp.OriginalTokenCount = result.OriginalTokenCount
+	}
+	return resp
 }
 
 func writeError(w http.ResponseWriter, code int, msg string) {
@@ -167,9 +314,15 @@ func writeError(w http.ResponseWriter, code int, msg string) {
 	json.NewEncoder(w).Encode(errorResponse{Error: msg})
 }
 
-// SetTimeout configures the converter timeout (used for testing).
+// SetCache enables result caching with conditional revalidation.
+func (s *Server) SetCache(c converter.Cache) {
+	s.cache = c
+}
+
+// SetTimeout bounds the time spent fetching each URL. Zero keeps the
+// converter default.
 func (s *Server) SetTimeout(d time.Duration) {
-	// Not directly exposed, but could be extended.
+	s.timeout = d
 }
 
 func visionFromEnv() *filetype.VisionConfig {
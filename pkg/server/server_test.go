@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestHealthEndpoint(t *testing.T) {
@@ -96,3 +97,124 @@ func TestConvertEndpoint_MethodNotAllowed(t *testing.T) {
 		t.Errorf("expected 405, got %d", w.Code)
 	}
 }
+
+func TestConvertEndpoint_Chunks(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/markdown")
+		fmt.Fprint(w, "# Doc\n\nFirst section text.\n\n## Part\n\nSecond section text.")
+	}))
+	defer target.Close()
+
+	srv := New(0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", srv.handleConvert)
+
+	payload := fmt.Sprintf(`{"url":"%s","method":"negotiate","chunk_tokens":50}`, target.URL)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(payload))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+
+	var resp struct {
+		Chunks []struct {
+			Headings []string `json:"headings"`
+			Text     string   `json:"text"`
+		} `json:"chunks"`
+	}
+	json.NewDecoder(w.Body).Decode(&resp)
+	if len(resp.Chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(resp.Chunks))
+	}
+	if strings.Join(resp.Chunks[1].Headings, "/") != "Doc/Part" {
+		t.Errorf("unexpected headings: %v", resp.Chunks[1].Headings)
+	}
+}
+
+func TestConvertEndpoint_UnknownTokenizer(t *testing.T) {
+	srv := New(0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", srv.handleConvert)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"url":"https://example.com","tokenizer":"nope"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestConvertEndpoint_InvalidPages(t *testing.T) {
+	srv := New(0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", srv.handleConvert)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"url":"https://example.com/report.pdf","pages":"5-2"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestInvalidOptions_AllEndpoints(t *testing.T) {
+	handler := New(0).Handler()
+
+	for _, opt := range []struct{ key, value string }{
+		{"tokenizer", "nope"},
+		{"pages", "5-2"},
+		{"range", "B2:A1"},
+		{"link_mode", "footnote"},
+	} {
+		field := fmt.Sprintf("%q:%q", opt.key, opt.value)
+		requests := map[string]*http.Request{
+			"convert": httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"url":"https://example.com/a",`+field+`}`)),
+			"batch":   httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`{"urls":["https://example.com/a"],`+field+`}`)),
+			"jobs":    httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"url":"https://example.com/a",`+field+`}`)),
+			"upload":  httptest.NewRequest(http.MethodPost, "/upload?filename=a.txt&"+opt.key+"="+opt.value, strings.NewReader("text")),
+		}
+
+		var bodies []string
+		for name, req := range requests {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("%s with invalid %s: expected 400, got %d", name, opt.key, w.Code)
+			}
+			bodies = append(bodies, w.Body.String())
+		}
+		for _, b := range bodies[1:] {
+			if b != bodies[0] {
+				t.Errorf("invalid %s: endpoints disagree: %q vs %q", opt.key, bodies[0], b)
+			}
+		}
+	}
+}
+
+func TestSetTimeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	srv := New(0)
+	srv.SetTimeout(100 * time.Millisecond)
+	start := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(fmt.Sprintf(`{"url":"%s/page","method":"static"}`, slow.URL)))
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", w.Code)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the server timeout to apply, took %v", elapsed)
+	}
+}
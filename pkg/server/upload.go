@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/elonfeng/url2md/pkg/converter"
+)
+
+// maxUploadSize matches the download limit of the static layer.
+const maxUploadSize = 50 << 20
+
+// handleUpload converts a document sent in the request instead of fetched
+// from a URL: either a multipart form with a "file" field or a raw body whose
+// Content-Type names its format. The file name (the multipart file name, or
+// the filename query parameter or Content-Disposition header of a raw body)
+// takes priority when detecting the type. Options are read from query or form
+// parameters as for GET requests.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+
+	var (
+		data        []byte
+		name        string
+		contentType string
+		err         error
+	)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(8 << 20); err != nil {
+			writeUploadError(w, err)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "file is required")
+			return
+		}
+		defer file.Close()
+		name, contentType = header.Filename, header.Header.Get("Content-Type")
+		data, err = io.ReadAll(file)
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+	} else {
+		r.Form = r.URL.Query()
+		name, contentType = r.Form.Get("filename"), r.Header.Get("Content-Type")
+		if name == "" {
+			if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Disposition")); err == nil {
+				name = params["filename"]
+			}
+		}
+		data, err = io.ReadAll(r.Body)
+		if err != nil {
+			writeUploadError(w, err)
+			return
+		}
+	}
+	if len(data) == 0 {
+		writeError(w, http.StatusBadRequest, "empty upload")
+		return
+	}
+	// clients may send a full path; keep the base name
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+
+	opts := s.queryOptions(r.Form)
+	if err := converter.ValidateOptions(&opts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result, err := converter.ConvertBytes(r.Context(), data, name, contentType, &opts)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	s.writeResult(w, result)
+}
+
+// writeUploadError reports a failure to read the upload, distinguishing
+// bodies over maxUploadSize.
+func writeUploadError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, "upload exceeds 50 MB")
+		return
+	}
+	writeError(w, http.StatusBadRequest, "read upload: "+err.Error())
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadEndpoint_Multipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("tokenizer", "o200k_base")
+	fw, _ := mw.CreateFormFile("file", `C:\Users\me\plants.csv`)
+	fw.Write([]byte("name,qty\nfern,3\n"))
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	New(0).Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var resp convertResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if resp.URL != "plants.csv" {
+		t.Errorf("expected url plants.csv, got %q", resp.URL)
+	}
+	if !strings.Contains(resp.Markdown, "# plants.csv") || !strings.Contains(resp.Markdown, "| fern | 3 |") {
+		t.Errorf("expected CSV table, got:\n%s", resp.Markdown)
+	}
+	if resp.Tokenizer != "o200k_base" {
+		t.Errorf("expected form options to apply, got tokenizer %q", resp.Tokenizer)
+	}
+}
+
+func TestUploadEndpoint_RawBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`{"name":"fern","shade":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	New(0).Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body: %s", w.Code, w.Body.String())
+	}
+	var resp convertResponse
+	json.NewDecoder(w.Body).Decode(&resp)
+	if !strings.Contains(resp.Markdown, "```json") {
+		t.Errorf("expected JSON code block, got:\n%s", resp.Markdown)
+	}
+	if w.Header().Get("X-Convert-Method") != "static" {
+		t.Errorf("expected X-Convert-Method static, got %q", w.Header().Get("X-Convert-Method"))
+	}
+}
+
+func TestUploadEndpoint_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		body string
+		code int
+	}{
+		{"empty", "/upload", "", http.StatusBadRequest},
+		{"bad tokenizer", "/upload?tokenizer=nope&filename=a.txt", "hello", http.StatusBadRequest},
+		{"too large", "/upload?filename=a.txt", strings.Repeat("x", maxUploadSize+1), http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/octet-stream")
+			w := httptest.NewRecorder()
+			New(0).Handler().ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Errorf("expected %d, got %d; body: %s", tt.code, w.Code, w.Body.String())
+			}
+		})
+	}
+}